@@ -159,11 +159,61 @@ func (s *Store) OnBlockInitialSyncStateTransition(ctx context.Context, signed *e
 		return errors.New("nil block")
 	}
 
-	b := signed.Block
+	s.initSyncStateLock.Lock()
+	defer s.initSyncStateLock.Unlock()
+
+	return s.initSyncStateTransition(ctx, signed, nil /* pending */)
+}
+
+// OnBlockBatchInitialSyncStateTransition is called when a batch of contiguous initial sync blocks
+// is received. It runs the same state transition as OnBlockInitialSyncStateTransition on every
+// block in order, but defers saving the blocks so that they are written to the db in a single
+// transaction once the whole batch has been processed. Blocks are saved early only when a new
+// justified or finalized checkpoint is looked up in the db, or when a later block of the batch
+// fails, so that the blocks whose states were saved are never missing.
+func (s *Store) OnBlockBatchInitialSyncStateTransition(ctx context.Context, blks []*ethpb.SignedBeaconBlock) error {
+	ctx, span := trace.StartSpan(ctx, "forkchoice.onBlockBatch")
+	defer span.End()
+
+	for _, signed := range blks {
+		if signed == nil || signed.Block == nil {
+			return errors.New("nil block")
+		}
+	}
 
 	s.initSyncStateLock.Lock()
 	defer s.initSyncStateLock.Unlock()
 
+	var pending []*ethpb.SignedBeaconBlock
+	for _, signed := range blks {
+		if err := s.initSyncStateTransition(ctx, signed, &pending); err != nil {
+			if saveErr := s.savePendingBlocks(ctx, &pending); saveErr != nil {
+				log.WithError(saveErr).Error("Could not save the blocks processed before the failed block of the batch")
+			}
+			return err
+		}
+	}
+	return s.savePendingBlocks(ctx, &pending)
+}
+
+// savePendingBlocks saves the pending blocks of a batch in a single db transaction.
+func (s *Store) savePendingBlocks(ctx context.Context, pending *[]*ethpb.SignedBeaconBlock) error {
+	if pending == nil || len(*pending) == 0 {
+		return nil
+	}
+	if err := s.db.SaveBlocks(ctx, *pending); err != nil {
+		return errors.Wrapf(err, "could not save batch of %d blocks", len(*pending))
+	}
+	*pending = nil
+	return nil
+}
+
+// initSyncStateTransition runs the initial sync state transition on a single block. The block is
+// saved right away, unless the pending blocks of a batch are given, in which case it is added to
+// them. The caller must hold the initial sync state lock.
+func (s *Store) initSyncStateTransition(ctx context.Context, signed *ethpb.SignedBeaconBlock, pending *[]*ethpb.SignedBeaconBlock) error {
+	b := signed.Block
+
 	// Retrieve incoming block's pre state.
 	preState, err := s.cachedPreState(ctx, b)
 	if err != nil {
@@ -178,8 +228,10 @@ func (s *Store) OnBlockInitialSyncStateTransition(ctx context.Context, signed *e
 		return errors.Wrap(err, "could not execute state transition")
 	}
 
-	if err := s.db.SaveBlock(ctx, signed); err != nil {
-		return errors.Wrapf(err, "could not save block from slot %d", b.Slot)
+	if pending == nil {
+		if err := s.db.SaveBlock(ctx, signed); err != nil {
+			return errors.Wrapf(err, "could not save block from slot %d", b.Slot)
+		}
 	}
 	root, err := ssz.HashTreeRoot(b)
 	if err != nil {
@@ -193,9 +245,20 @@ func (s *Store) OnBlockInitialSyncStateTransition(ctx context.Context, signed *e
 			return errors.Wrap(err, "could not save state")
 		}
 	}
+	if pending != nil {
+		*pending = append(*pending, signed)
+	}
+
+	// Updating the checkpoints looks up their blocks, which may be pending blocks of the batch.
+	newJustified := postState.CurrentJustifiedCheckpoint.Epoch > s.justifiedCheckpt.Epoch
+	if newJustified || postState.FinalizedCheckpoint.Epoch > s.finalizedCheckpt.Epoch {
+		if err := s.savePendingBlocks(ctx, pending); err != nil {
+			return err
+		}
+	}
 
 	// Update justified check point.
-	if postState.CurrentJustifiedCheckpoint.Epoch > s.justifiedCheckpt.Epoch {
+	if newJustified {
 		if err := s.updateJustified(ctx, postState); err != nil {
 			return err
 		}
@@ -426,7 +489,7 @@ func (s *Store) rmStatesOlderThanLastFinalized(ctx context.Context, startSlot ui
 // Otherwise, delay incorporation of new justified checkpoint until next epoch boundary.
 // See https://ethresear.ch/t/prevention-of-bouncing-attack-on-ffg/6114 for more detailed analysis and discussion.
 func (s *Store) shouldUpdateCurrentJustified(ctx context.Context, newJustifiedCheckpt *ethpb.Checkpoint) (bool, error) {
-	if helpers.SlotsSinceEpochStarts(s.currentSlot()) < params.BeaconConfig().SafeSlotsToUpdateJustified {
+	if helpers.SlotsSinceEpochStart(s.currentSlot()) < params.BeaconConfig().SafeSlotsToUpdateJustified {
 		return true, nil
 	}
 	newJustifiedBlockSigned, err := s.db.Block(ctx, bytesutil.ToBytes32(newJustifiedCheckpt.Root))
@@ -8,17 +8,21 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
 	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	"github.com/prysmaticlabs/go-bitfield"
 	"github.com/prysmaticlabs/go-ssz"
 	"github.com/prysmaticlabs/prysm/beacon-chain/core/blocks"
 	"github.com/prysmaticlabs/prysm/beacon-chain/core/helpers"
+	"github.com/prysmaticlabs/prysm/beacon-chain/core/state"
+	"github.com/prysmaticlabs/prysm/beacon-chain/db"
 	testDB "github.com/prysmaticlabs/prysm/beacon-chain/db/testing"
 	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
 	"github.com/prysmaticlabs/prysm/shared/bytesutil"
 	"github.com/prysmaticlabs/prysm/shared/featureconfig"
 	"github.com/prysmaticlabs/prysm/shared/params"
 	"github.com/prysmaticlabs/prysm/shared/stateutil"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
 )
 
 func TestStore_OnBlock(t *testing.T) {
@@ -569,6 +573,166 @@ func TestUpdateJustified_CouldUpdateBest(t *testing.T) {
 	}
 }
 
+func TestOnBlockBatchInitialSyncStateTransition_JustifiedRootInBatch(t *testing.T) {
+	ctx := context.Background()
+	db := testDB.SetupDB(t)
+	defer testDB.TeardownDB(t, db)
+
+	store := NewForkChoiceService(ctx, db)
+
+	genesisState, _ := testutil.DeterministicGenesisState(t, 64)
+	genesisStateRoot, err := stateutil.HashTreeRootState(genesisState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Filling in the header's state root keeps the parent root of the next block
+	// independent of the justified checkpoint set below.
+	genesisState.LatestBlockHeader.StateRoot = genesisStateRoot[:]
+	genesis := blocks.NewGenesisBlock(genesisStateRoot[:])
+	genesisRoot, err := ssz.HashTreeRoot(genesis.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blk := &ethpb.SignedBeaconBlock{
+		Block: &ethpb.BeaconBlock{
+			Slot:       1,
+			ParentRoot: genesisRoot[:],
+			Body: &ethpb.BeaconBlockBody{
+				Eth1Data:     genesisState.Eth1Data,
+				RandaoReveal: make([]byte, 96),
+			},
+		},
+	}
+	blkRoot, err := ssz.HashTreeRoot(blk.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The post state of the batch justifies a block of the batch itself.
+	genesisState.CurrentJustifiedCheckpoint = &ethpb.Checkpoint{Epoch: 1, Root: blkRoot[:]}
+	if err := db.SaveBlock(ctx, genesis); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveState(ctx, genesisState, genesisRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	// Past the safe slots of the epoch, updating the justified checkpoint looks up its block.
+	store.genesisTime = uint64(time.Now().Unix()) - 10*params.BeaconConfig().SecondsPerSlot
+	store.justifiedCheckpt = &ethpb.Checkpoint{Root: genesisRoot[:]}
+	store.bestJustifiedCheckpt = &ethpb.Checkpoint{Root: genesisRoot[:]}
+	store.finalizedCheckpt = &ethpb.Checkpoint{Root: genesisRoot[:]}
+	store.prevFinalizedCheckpt = &ethpb.Checkpoint{Root: genesisRoot[:]}
+
+	if err := store.OnBlockBatchInitialSyncStateTransition(ctx, []*ethpb.SignedBeaconBlock{blk}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(store.justifiedCheckpt.Root, blkRoot[:]) {
+		t.Errorf("Wanted justified root %#x, got %#x", blkRoot, store.justifiedCheckpt.Root)
+	}
+	if !db.HasBlock(ctx, blkRoot) {
+		t.Error("Expected batch block to be saved")
+	}
+}
+
+// countingDB counts the block writes made through it.
+type countingDB struct {
+	db.Database
+	saveBlockCalls  int
+	saveBlocksCalls int
+}
+
+func (c *countingDB) SaveBlock(ctx context.Context, blk *ethpb.SignedBeaconBlock) error {
+	c.saveBlockCalls++
+	return c.Database.SaveBlock(ctx, blk)
+}
+
+func (c *countingDB) SaveBlocks(ctx context.Context, blks []*ethpb.SignedBeaconBlock) error {
+	c.saveBlocksCalls++
+	return c.Database.SaveBlocks(ctx, blks)
+}
+
+func TestOnBlockBatchInitialSyncStateTransition_SavesBlocksInSingleWrite(t *testing.T) {
+	ctx := context.Background()
+	beaconDB := testDB.SetupDB(t)
+	defer testDB.TeardownDB(t, beaconDB)
+	cdb := &countingDB{Database: beaconDB}
+
+	store := NewForkChoiceService(ctx, cdb)
+
+	genesisState, _ := testutil.DeterministicGenesisState(t, 64)
+	genesisStateRoot, err := stateutil.HashTreeRootState(genesisState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesisState.LatestBlockHeader.StateRoot = genesisStateRoot[:]
+	genesis := blocks.NewGenesisBlock(genesisStateRoot[:])
+	genesisRoot, err := ssz.HashTreeRoot(genesis.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := beaconDB.SaveBlock(ctx, genesis); err != nil {
+		t.Fatal(err)
+	}
+	if err := beaconDB.SaveState(ctx, genesisState, genesisRoot); err != nil {
+		t.Fatal(err)
+	}
+	store.justifiedCheckpt = &ethpb.Checkpoint{Root: genesisRoot[:]}
+	store.bestJustifiedCheckpt = &ethpb.Checkpoint{Root: genesisRoot[:]}
+	store.finalizedCheckpt = &ethpb.Checkpoint{Root: genesisRoot[:]}
+	store.prevFinalizedCheckpt = &ethpb.Checkpoint{Root: genesisRoot[:]}
+
+	// Each block carries its post state root, so that the next block's parent root
+	// matches the header of its pre state.
+	var blks []*ethpb.SignedBeaconBlock
+	var roots [][32]byte
+	parentRoot := genesisRoot
+	st := proto.Clone(genesisState).(*pb.BeaconState)
+	for slot := uint64(1); slot <= 3; slot++ {
+		blk := &ethpb.SignedBeaconBlock{
+			Block: &ethpb.BeaconBlock{
+				Slot:       slot,
+				ParentRoot: parentRoot[:],
+				Body: &ethpb.BeaconBlockBody{
+					Eth1Data:     genesisState.Eth1Data,
+					RandaoReveal: make([]byte, 96),
+				},
+			},
+		}
+		st, err = state.ExecuteStateTransitionNoVerify(ctx, st, blk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stRoot, err := stateutil.HashTreeRootState(st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blk.Block.StateRoot = stRoot[:]
+		st.LatestBlockHeader.StateRoot = stRoot[:]
+		parentRoot, err = ssz.HashTreeRoot(blk.Block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blks = append(blks, blk)
+		roots = append(roots, parentRoot)
+	}
+
+	if err := store.OnBlockBatchInitialSyncStateTransition(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+	if cdb.saveBlocksCalls != 1 {
+		t.Errorf("Wanted 1 batched block write, got %d", cdb.saveBlocksCalls)
+	}
+	if cdb.saveBlockCalls != 0 {
+		t.Errorf("Wanted no single block writes, got %d", cdb.saveBlockCalls)
+	}
+	for i, root := range roots {
+		if !beaconDB.HasBlock(ctx, root) {
+			t.Errorf("Expected block at slot %d to be saved", blks[i].Block.Slot)
+		}
+	}
+}
+
 func TestFilterBlockRoots_CanFilter(t *testing.T) {
 	ctx := context.Background()
 	db := testDB.SetupDB(t)
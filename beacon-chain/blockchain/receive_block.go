@@ -26,6 +26,7 @@ type BlockReceiver interface {
 	ReceiveBlockNoPubsub(ctx context.Context, block *ethpb.SignedBeaconBlock) error
 	ReceiveBlockNoPubsubForkchoice(ctx context.Context, block *ethpb.SignedBeaconBlock) error
 	ReceiveBlockNoVerify(ctx context.Context, block *ethpb.SignedBeaconBlock) error
+	ReceiveBlockBatch(ctx context.Context, blocks []*ethpb.SignedBeaconBlock) error
 }
 
 // ReceiveBlock is a function that defines the operations that are preformed on
@@ -243,6 +244,81 @@ func (s *Service) ReceiveBlockNoVerify(ctx context.Context, block *ethpb.SignedB
 	return nil
 }
 
+// ReceiveBlockBatch runs state transition on a batch of contiguous blocks without verifying the
+// blocks' BLS contents, the same way ReceiveBlockNoVerify does, but saves all of the blocks in a
+// single db write to amortize the write overhead during initial sync. The blocks must be sorted
+// by slot and each block must build on the previous one.
+func (s *Service) ReceiveBlockBatch(ctx context.Context, blocks []*ethpb.SignedBeaconBlock) error {
+	ctx, span := trace.StartSpan(ctx, "beacon-chain.blockchain.ReceiveBlockBatch")
+	defer span.End()
+	if len(blocks) == 0 {
+		return nil
+	}
+	blockCopies := make([]*ethpb.SignedBeaconBlock, len(blocks))
+	for i, b := range blocks {
+		blockCopies[i] = proto.Clone(b).(*ethpb.SignedBeaconBlock)
+	}
+
+	// Apply state transition on the incoming blocks without verifying their BLS contents.
+	if err := s.forkChoiceStore.OnBlockBatchInitialSyncStateTransition(ctx, blockCopies); err != nil {
+		return errors.Wrap(err, "could not process block batch from fork choice service")
+	}
+
+	roots := make([][32]byte, len(blockCopies))
+	for i, b := range blockCopies {
+		root, err := ssz.HashTreeRoot(b.Block)
+		if err != nil {
+			return errors.Wrapf(err, "could not get signing root of block %d", b.Block.Slot)
+		}
+		roots[i] = root
+	}
+
+	// Only the last block of the batch is a candidate for the new head.
+	lastBlock := blockCopies[len(blockCopies)-1]
+	lastRoot := roots[len(roots)-1]
+	if !bytes.Equal(lastRoot[:], s.HeadRoot()) {
+		if featureconfig.Get().InitSyncCacheState {
+			if err := s.saveHeadNoDB(ctx, lastBlock, lastRoot); err != nil {
+				err := errors.Wrap(err, "could not save head")
+				traceutil.AnnotateError(span, err)
+				return err
+			}
+		} else {
+			if err := s.saveHead(ctx, lastBlock, lastRoot); err != nil {
+				err := errors.Wrap(err, "could not save head")
+				traceutil.AnnotateError(span, err)
+				return err
+			}
+		}
+	}
+
+	for i, b := range blockCopies {
+		// Send notification of the processed block to the state feed.
+		s.stateNotifier.StateFeed().Send(&feed.Event{
+			Type: statefeed.BlockProcessed,
+			Data: &statefeed.BlockProcessedData{
+				BlockRoot: roots[i],
+				Verified:  false,
+			},
+		})
+
+		// Reports on block and fork choice metrics.
+		s.reportSlotMetrics(b.Block.Slot)
+	}
+
+	log.WithFields(logrus.Fields{
+		"startSlot": blockCopies[0].Block.Slot,
+		"endSlot":   lastBlock.Block.Slot,
+		"count":     len(blockCopies),
+	}).Debug("Finished applying state transition on block batch")
+
+	s.epochParticipationLock.Lock()
+	defer s.epochParticipationLock.Unlock()
+	s.epochParticipation[helpers.SlotToEpoch(lastBlock.Block.Slot)] = precompute.Balances
+
+	return nil
+}
+
 // This checks if the block is from a competing chain, emits warning and updates metrics.
 func isCompetingBlock(root []byte, slot uint64, headRoot []byte, headSlot uint64) {
 	if !bytes.Equal(root[:], headRoot) {
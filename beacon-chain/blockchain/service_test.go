@@ -50,6 +50,10 @@ func (s *store) OnBlockInitialSyncStateTransition(ctx context.Context, b *ethpb.
 	return nil
 }
 
+func (s *store) OnBlockBatchInitialSyncStateTransition(ctx context.Context, blks []*ethpb.SignedBeaconBlock) error {
+	return nil
+}
+
 func (s *store) OnAttestation(ctx context.Context, a *ethpb.Attestation) error {
 	return nil
 }
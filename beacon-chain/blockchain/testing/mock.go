@@ -118,6 +118,16 @@ func (ms *ChainService) ReceiveBlockNoPubsubForkchoice(ctx context.Context, bloc
 	return nil
 }
 
+// ReceiveBlockBatch mocks ReceiveBlockBatch method in chain service.
+func (ms *ChainService) ReceiveBlockBatch(ctx context.Context, blocks []*ethpb.SignedBeaconBlock) error {
+	for _, b := range blocks {
+		if err := ms.ReceiveBlockNoPubsubForkchoice(ctx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // HeadSlot mocks HeadSlot method in chain service.
 func (ms *ChainService) HeadSlot() uint64 {
 	if ms.State == nil {
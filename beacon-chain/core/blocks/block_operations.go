@@ -576,7 +576,8 @@ func ProcessAttestationNoVerify(ctx context.Context, beaconState *pb.BeaconState
 	}
 
 	data := att.Data
-	if data.Target.Epoch != helpers.PrevEpoch(beaconState) && data.Target.Epoch != helpers.CurrentEpoch(beaconState) {
+	currentTarget, validTarget := helpers.AttestationTargetEpochKind(beaconState, data)
+	if !validTarget {
 		return nil, fmt.Errorf(
 			"expected target epoch (%d) to be the previous epoch (%d) or the current epoch (%d)",
 			data.Target.Epoch,
@@ -626,7 +627,7 @@ func ProcessAttestationNoVerify(ctx context.Context, beaconState *pb.BeaconState
 	var ffgSourceEpoch uint64
 	var ffgSourceRoot []byte
 	var ffgTargetEpoch uint64
-	if data.Target.Epoch == helpers.CurrentEpoch(beaconState) {
+	if currentTarget {
 		ffgSourceEpoch = beaconState.CurrentJustifiedCheckpoint.Epoch
 		ffgSourceRoot = beaconState.CurrentJustifiedCheckpoint.Root
 		ffgTargetEpoch = helpers.CurrentEpoch(beaconState)
@@ -1020,27 +1021,16 @@ func VerifyExit(beaconState *pb.BeaconState, signed *ethpb.SignedVoluntaryExit)
 
 	validator := beaconState.Validators[exit.ValidatorIndex]
 	currentEpoch := helpers.CurrentEpoch(beaconState)
-	// Verify the validator is active.
-	if !helpers.IsActiveValidator(validator, currentEpoch) {
-		return errors.New("non-active validator cannot exit")
-	}
-	// Verify the validator has not yet exited.
-	if validator.ExitEpoch != params.BeaconConfig().FarFutureEpoch {
-		return fmt.Errorf("validator has already exited at epoch: %v", validator.ExitEpoch)
+	// Verify the validator is active, has not yet exited and has been active long enough. The spec
+	// asserts the exit epoch in between these conditions, but any failed assertion rejects the exit.
+	if err := helpers.IsValidVoluntaryExitEpoch(validator, currentEpoch); err != nil {
+		return err
 	}
 	// Exits must specify an epoch when they become valid; they are not valid before then.
 	if currentEpoch < exit.Epoch {
 		return fmt.Errorf("expected current epoch >= exit epoch, received %d < %d", currentEpoch, exit.Epoch)
 	}
-	// Verify the validator has been active long enough.
-	if currentEpoch < validator.ActivationEpoch+params.BeaconConfig().PersistentCommitteePeriod {
-		return fmt.Errorf(
-			"validator has not been active long enough to exit, wanted epoch %d >= %d",
-			currentEpoch,
-			validator.ActivationEpoch+params.BeaconConfig().PersistentCommitteePeriod,
-		)
-	}
-	domain := helpers.Domain(beaconState.Fork, exit.Epoch, params.BeaconConfig().DomainVoluntaryExit)
+	domain := helpers.VoluntaryExitDomain(beaconState.Fork, exit.Epoch)
 	if err := verifySigningRoot(exit, validator.PublicKey, signed.Signature, domain); err != nil {
 		return ErrSigFailedToVerify
 	}
@@ -1613,7 +1613,7 @@ func TestProcessVoluntaryExits_InvalidExitEpoch(t *testing.T) {
 	exits := []*ethpb.SignedVoluntaryExit{
 		{
 			Exit: &ethpb.VoluntaryExit{
-				Epoch: 10,
+				Epoch: params.BeaconConfig().PersistentCommitteePeriod + 1,
 			},
 		},
 	}
@@ -1624,7 +1624,7 @@ func TestProcessVoluntaryExits_InvalidExitEpoch(t *testing.T) {
 	}
 	state := &pb.BeaconState{
 		Validators: registry,
-		Slot:       0,
+		Slot:       params.BeaconConfig().PersistentCommitteePeriod * params.BeaconConfig().SlotsPerEpoch,
 	}
 	block := &ethpb.BeaconBlock{
 		Body: &ethpb.BeaconBlockBody{
@@ -171,6 +171,38 @@ func ProcessSlashings(state *pb.BeaconState) (*pb.BeaconState, error) {
 	return state, err
 }
 
+// EffectiveBalanceWithHysteresis returns the effective balance of a validator with the given
+// balance. The effective balance only changes once the balance moves out of the hysteresis band
+// around the current effective balance.
+func EffectiveBalanceWithHysteresis(effectiveBalance uint64, balance uint64) uint64 {
+	halfInc := params.BeaconConfig().EffectiveBalanceIncrement / 2
+	if balance < effectiveBalance || effectiveBalance+3*halfInc < balance {
+		effectiveBalance = params.BeaconConfig().MaxEffectiveBalance
+		if effectiveBalance > balance-balance%params.BeaconConfig().EffectiveBalanceIncrement {
+			effectiveBalance = balance - balance%params.BeaconConfig().EffectiveBalanceIncrement
+		}
+	}
+	return effectiveBalance
+}
+
+// EffectiveBalanceChanges returns the indices of the validators whose effective balance would
+// change given their new balances, without modifying the validators.
+func EffectiveBalanceChanges(validators []*ethpb.Validator, balances []uint64) ([]uint64, error) {
+	if len(validators) != len(balances) {
+		return nil, fmt.Errorf("validators length %d different than balances length %d", len(validators), len(balances))
+	}
+	var changed []uint64
+	for i, v := range validators {
+		if v == nil {
+			return nil, fmt.Errorf("validator %d is nil", i)
+		}
+		if EffectiveBalanceWithHysteresis(v.EffectiveBalance, balances[i]) != v.EffectiveBalance {
+			changed = append(changed, uint64(i))
+		}
+	}
+	return changed, nil
+}
+
 // ProcessFinalUpdates processes the final updates during epoch processing.
 //
 // Spec pseudocode definition:
@@ -224,14 +256,7 @@ func ProcessFinalUpdates(state *pb.BeaconState) (*pb.BeaconState, error) {
 		if i >= len(state.Balances) {
 			return nil, fmt.Errorf("validator index exceeds validator length in state %d >= %d", i, len(state.Balances))
 		}
-		balance := state.Balances[i]
-		halfInc := params.BeaconConfig().EffectiveBalanceIncrement / 2
-		if balance < v.EffectiveBalance || v.EffectiveBalance+3*halfInc < balance {
-			v.EffectiveBalance = params.BeaconConfig().MaxEffectiveBalance
-			if v.EffectiveBalance > balance-balance%params.BeaconConfig().EffectiveBalanceIncrement {
-				v.EffectiveBalance = balance - balance%params.BeaconConfig().EffectiveBalanceIncrement
-			}
-		}
+		v.EffectiveBalance = EffectiveBalanceWithHysteresis(v.EffectiveBalance, state.Balances[i])
 	}
 
 	// Set total slashed balances.
@@ -247,7 +272,10 @@ func ProcessFinalUpdates(state *pb.BeaconState) (*pb.BeaconState, error) {
 	if len(state.RandaoMixes) != int(randaoMixLength) {
 		return nil, fmt.Errorf("state randao length %d different than EpochsPerHistoricalVector %d", len(state.RandaoMixes), randaoMixLength)
 	}
-	mix := helpers.RandaoMix(state, currentEpoch)
+	mix, err := helpers.RandaoMix(state, currentEpoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get randao mix")
+	}
 	state.RandaoMixes[nextEpoch%randaoMixLength] = mix
 
 	// Set historical root accumulator.
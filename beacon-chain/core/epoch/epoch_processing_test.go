@@ -2,6 +2,7 @@ package epoch
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 
 	"github.com/gogo/protobuf/proto"
@@ -319,6 +320,71 @@ func TestProcessFinalUpdates_CanProcess(t *testing.T) {
 	}
 }
 
+func TestEffectiveBalanceChanges_MostWithinHysteresis(t *testing.T) {
+	maxBalance := params.BeaconConfig().MaxEffectiveBalance
+	inc := params.BeaconConfig().EffectiveBalanceIncrement
+	validators := make([]*ethpb.Validator, 100)
+	balances := make([]uint64, len(validators))
+	for i := range validators {
+		validators[i] = &ethpb.Validator{EffectiveBalance: maxBalance}
+		// Rewards and small penalties keep most balances within the hysteresis band.
+		balances[i] = maxBalance + uint64(i)*inc/100
+	}
+	// Below the effective balance.
+	balances[10] = maxBalance - 1
+	// Far below the effective balance.
+	balances[20] = maxBalance - 2*inc
+	// Above the hysteresis band of a lower effective balance.
+	validators[30].EffectiveBalance = maxBalance - inc
+	balances[30] = maxBalance - inc + 3*inc/2 + 1
+	// Within the hysteresis band of a lower effective balance.
+	validators[40].EffectiveBalance = maxBalance - inc
+	balances[40] = maxBalance - inc + 3*inc/2
+
+	changed, err := EffectiveBalanceChanges(validators, balances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint64{10, 20, 30}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("Wanted changed indices %v, received %v", want, changed)
+	}
+	if validators[10].EffectiveBalance != maxBalance || validators[30].EffectiveBalance != maxBalance-inc {
+		t.Error("Expected validators to not be modified")
+	}
+}
+
+func TestEffectiveBalanceChanges_MatchesFinalUpdates(t *testing.T) {
+	s := buildState(params.BeaconConfig().SlotsPerHistoricalRoot-1, params.BeaconConfig().SlotsPerEpoch)
+	s.Balances[0] = 29 * 1e9
+	s.Balances[1] = s.Validators[1].EffectiveBalance + 1e9
+	changed, err := EffectiveBalanceChanges(s.Validators, s.Balances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := proto.Clone(s).(*pb.BeaconState)
+	newS, err := ProcessFinalUpdates(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []uint64
+	for i, v := range newS.Validators {
+		if v.EffectiveBalance != before.Validators[i].EffectiveBalance {
+			want = append(want, uint64(i))
+		}
+	}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("Wanted changed indices %v, received %v", want, changed)
+	}
+}
+
+func TestEffectiveBalanceChanges_LengthMismatch(t *testing.T) {
+	validators := []*ethpb.Validator{{}, {}}
+	if _, err := EffectiveBalanceChanges(validators, []uint64{1}); err == nil {
+		t.Error("Expected error with mismatched balances length")
+	}
+}
+
 func TestProcessRegistryUpdates_NoRotation(t *testing.T) {
 	state := &pb.BeaconState{
 		Slot: 5 * params.BeaconConfig().SlotsPerEpoch,
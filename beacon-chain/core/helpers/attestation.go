@@ -131,11 +131,7 @@ func SlotSignature(state *pb.BeaconState, slot uint64, privKey *bls.SecretKey) (
 //    modulo = max(1, len(committee) // TARGET_AGGREGATORS_PER_COMMITTEE)
 //    return bytes_to_int(hash(slot_signature)[0:8]) % modulo == 0
 func IsAggregator(committeeCount uint64, slot uint64, index uint64, slotSig []byte) (bool, error) {
-	modulo := uint64(1)
-	if committeeCount/params.BeaconConfig().TargetAggregatorsPerCommittee > 1 {
-		modulo = committeeCount / params.BeaconConfig().TargetAggregatorsPerCommittee
-	}
-
+	modulo := AggregatorModulo(committeeCount)
 	b := hashutil.Hash(slotSig)
 	return binary.LittleEndian.Uint64(b[:8])%modulo == 0, nil
 }
@@ -163,3 +159,23 @@ func AggregateSignature(attestations []*ethpb.Attestation) (*bls.Signature, erro
 func IsAggregated(attestation *ethpb.Attestation) bool {
 	return attestation.AggregationBits.Count() > 1
 }
+
+// AttestationTargetEpochKind returns whether the target of the attestation data is the current
+// epoch of the state, and whether the target is valid, i.e. either the current or the previous
+// epoch. At genesis, where the previous epoch is the current epoch, the target is the current epoch.
+//
+// Spec pseudocode definition:
+//   assert data.target.epoch in (get_previous_epoch(state), get_current_epoch(state))
+func AttestationTargetEpochKind(state *pb.BeaconState, data *ethpb.AttestationData) (current bool, valid bool) {
+	if data == nil || data.Target == nil {
+		return false, false
+	}
+	switch data.Target.Epoch {
+	case CurrentEpoch(state):
+		return true, true
+	case PrevEpoch(state):
+		return false, true
+	default:
+		return false, false
+	}
+}
@@ -302,3 +302,35 @@ func TestAggregateSignature_False(t *testing.T) {
 		t.Error("Signature not suppose to verify")
 	}
 }
+
+func TestAttestationTargetEpochKind(t *testing.T) {
+	slotsPerEpoch := params.BeaconConfig().SlotsPerEpoch
+	tests := []struct {
+		name        string
+		stateSlot   uint64
+		targetEpoch uint64
+		current     bool
+		valid       bool
+	}{
+		{name: "current epoch", stateSlot: 3*slotsPerEpoch + 5, targetEpoch: 3, current: true, valid: true},
+		{name: "previous epoch", stateSlot: 3*slotsPerEpoch + 5, targetEpoch: 2, current: false, valid: true},
+		{name: "before previous epoch", stateSlot: 3*slotsPerEpoch + 5, targetEpoch: 1, current: false, valid: false},
+		{name: "future epoch", stateSlot: 3*slotsPerEpoch + 5, targetEpoch: 4, current: false, valid: false},
+		{name: "genesis epoch", stateSlot: 5, targetEpoch: 0, current: true, valid: true},
+		{name: "future epoch at genesis", stateSlot: 5, targetEpoch: 1, current: false, valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &pb.BeaconState{Slot: tt.stateSlot}
+			data := &ethpb.AttestationData{Target: &ethpb.Checkpoint{Epoch: tt.targetEpoch}}
+			current, valid := helpers.AttestationTargetEpochKind(state, data)
+			if current != tt.current || valid != tt.valid {
+				t.Errorf("Wanted current %v and valid %v, received current %v and valid %v", tt.current, tt.valid, current, valid)
+			}
+		})
+	}
+
+	if _, valid := helpers.AttestationTargetEpochKind(&pb.BeaconState{}, &ethpb.AttestationData{}); valid {
+		t.Error("Wanted attestation data without a target to be invalid")
+	}
+}
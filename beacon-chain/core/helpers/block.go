@@ -2,6 +2,7 @@ package helpers
 
 import (
 	"github.com/pkg/errors"
+	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
 	"github.com/prysmaticlabs/prysm/shared/params"
 )
@@ -34,3 +35,53 @@ func BlockRootAtSlot(state *pb.BeaconState, slot uint64) ([]byte, error) {
 func BlockRoot(state *pb.BeaconState, epoch uint64) ([]byte, error) {
 	return BlockRootAtSlot(state, StartSlot(epoch))
 }
+
+// BlockLimits are the maximum numbers of each operation a block body may contain.
+type BlockLimits struct {
+	MaxProposerSlashings uint64
+	MaxAttesterSlashings uint64
+	MaxAttestations      uint64
+	MaxDeposits          uint64
+	MaxVoluntaryExits    uint64
+}
+
+// BlockOperationLimits returns the maximum numbers of each operation a block body may contain, as
+// configured in the beacon config.
+func BlockOperationLimits() BlockLimits {
+	cfg := params.BeaconConfig()
+	return BlockLimits{
+		MaxProposerSlashings: cfg.MaxProposerSlashings,
+		MaxAttesterSlashings: cfg.MaxAttesterSlashings,
+		MaxAttestations:      cfg.MaxAttestations,
+		MaxDeposits:          cfg.MaxDeposits,
+		MaxVoluntaryExits:    cfg.MaxVoluntaryExits,
+	}
+}
+
+// ValidateBlockOperationCounts returns an error if the block body contains more of an operation
+// than the block operation limits allow. This is a cheap check of a block before it is processed,
+// which does not verify the number of deposits against the deposits outstanding in the state.
+func ValidateBlockOperationCounts(block *ethpb.BeaconBlock) error {
+	if block == nil || block.Body == nil {
+		return errors.New("nil block body")
+	}
+	body := block.Body
+	limits := BlockOperationLimits()
+	counts := []struct {
+		name  string
+		count int
+		max   uint64
+	}{
+		{"proposer slashings", len(body.ProposerSlashings), limits.MaxProposerSlashings},
+		{"attester slashings", len(body.AttesterSlashings), limits.MaxAttesterSlashings},
+		{"attestations", len(body.Attestations), limits.MaxAttestations},
+		{"deposits", len(body.Deposits), limits.MaxDeposits},
+		{"voluntary exits", len(body.VoluntaryExits), limits.MaxVoluntaryExits},
+	}
+	for _, c := range counts {
+		if uint64(c.count) > c.max {
+			return errors.Errorf("number of %s (%d) in block body exceeds allowed threshold of %d", c.name, c.count, c.max)
+		}
+	}
+	return nil
+}
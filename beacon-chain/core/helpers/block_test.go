@@ -3,8 +3,10 @@ package helpers_test
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 
+	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	"github.com/prysmaticlabs/prysm/beacon-chain/core/helpers"
 	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
 	"github.com/prysmaticlabs/prysm/shared/params"
@@ -118,3 +120,59 @@ func TestBlockRootAtSlot_OutOfBounds(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateBlockOperationCounts(t *testing.T) {
+	limits := helpers.BlockOperationLimits()
+	tests := []struct {
+		name string
+		body func(extra uint64) *ethpb.BeaconBlockBody
+	}{
+		{
+			name: "proposer slashings",
+			body: func(extra uint64) *ethpb.BeaconBlockBody {
+				return &ethpb.BeaconBlockBody{ProposerSlashings: make([]*ethpb.ProposerSlashing, limits.MaxProposerSlashings+extra)}
+			},
+		},
+		{
+			name: "attester slashings",
+			body: func(extra uint64) *ethpb.BeaconBlockBody {
+				return &ethpb.BeaconBlockBody{AttesterSlashings: make([]*ethpb.AttesterSlashing, limits.MaxAttesterSlashings+extra)}
+			},
+		},
+		{
+			name: "attestations",
+			body: func(extra uint64) *ethpb.BeaconBlockBody {
+				return &ethpb.BeaconBlockBody{Attestations: make([]*ethpb.Attestation, limits.MaxAttestations+extra)}
+			},
+		},
+		{
+			name: "deposits",
+			body: func(extra uint64) *ethpb.BeaconBlockBody {
+				return &ethpb.BeaconBlockBody{Deposits: make([]*ethpb.Deposit, limits.MaxDeposits+extra)}
+			},
+		},
+		{
+			name: "voluntary exits",
+			body: func(extra uint64) *ethpb.BeaconBlockBody {
+				return &ethpb.BeaconBlockBody{VoluntaryExits: make([]*ethpb.SignedVoluntaryExit, limits.MaxVoluntaryExits+extra)}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := helpers.ValidateBlockOperationCounts(&ethpb.BeaconBlock{Body: tt.body(0)}); err != nil {
+				t.Errorf("Wanted a block at the limit to be valid, received %v", err)
+			}
+			err := helpers.ValidateBlockOperationCounts(&ethpb.BeaconBlock{Body: tt.body(1)})
+			if err == nil || !strings.Contains(err.Error(), "number of "+tt.name) {
+				t.Errorf("Wanted an error for too many %s, received %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestValidateBlockOperationCounts_NilBody(t *testing.T) {
+	if err := helpers.ValidateBlockOperationCounts(&ethpb.BeaconBlock{}); err == nil {
+		t.Error("Expected an error for a block without a body")
+	}
+}
@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+)
+
+// CurrentJustifiedEpoch returns the epoch of the state's current justified checkpoint, or 0 if
+// the state has no current justified checkpoint.
+func CurrentJustifiedEpoch(state *pb.BeaconState) uint64 {
+	if state.CurrentJustifiedCheckpoint == nil {
+		return 0
+	}
+	return state.CurrentJustifiedCheckpoint.Epoch
+}
+
+// PreviousJustifiedEpoch returns the epoch of the state's previous justified checkpoint, or 0 if
+// the state has no previous justified checkpoint.
+func PreviousJustifiedEpoch(state *pb.BeaconState) uint64 {
+	if state.PreviousJustifiedCheckpoint == nil {
+		return 0
+	}
+	return state.PreviousJustifiedCheckpoint.Epoch
+}
+
+// IsJustified returns true if the epoch is the epoch of either the current or the previous
+// justified checkpoint of the state.
+func IsJustified(state *pb.BeaconState, epoch uint64) bool {
+	return epoch == CurrentJustifiedEpoch(state) || epoch == PreviousJustifiedEpoch(state)
+}
+
+// FinalizedSlot returns the start slot of the epoch of the state's finalized checkpoint, or 0 if
+// the state has no finalized checkpoint.
+func FinalizedSlot(state *pb.BeaconState) uint64 {
+	if state.FinalizedCheckpoint == nil {
+		return 0
+	}
+	return StartSlot(state.FinalizedCheckpoint.Epoch)
+}
+
+// JustifiedSlot returns the start slot of the epoch of the state's current justified checkpoint,
+// or 0 if the state has no current justified checkpoint.
+func JustifiedSlot(state *pb.BeaconState) uint64 {
+	return StartSlot(CurrentJustifiedEpoch(state))
+}
@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"testing"
+
+	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/params"
+)
+
+func TestJustifiedEpochs(t *testing.T) {
+	state := &pb.BeaconState{
+		CurrentJustifiedCheckpoint:  &ethpb.Checkpoint{Epoch: 7},
+		PreviousJustifiedCheckpoint: &ethpb.Checkpoint{Epoch: 5},
+	}
+	if epoch := CurrentJustifiedEpoch(state); epoch != 7 {
+		t.Errorf("CurrentJustifiedEpoch() = %d, want 7", epoch)
+	}
+	if epoch := PreviousJustifiedEpoch(state); epoch != 5 {
+		t.Errorf("PreviousJustifiedEpoch() = %d, want 5", epoch)
+	}
+	for epoch, want := range map[uint64]bool{4: false, 5: true, 6: false, 7: true, 8: false} {
+		if got := IsJustified(state, epoch); got != want {
+			t.Errorf("IsJustified(%d) = %v, want %v", epoch, got, want)
+		}
+	}
+}
+
+func TestJustifiedEpochs_NilCheckpoints(t *testing.T) {
+	state := &pb.BeaconState{}
+	if epoch := CurrentJustifiedEpoch(state); epoch != 0 {
+		t.Errorf("CurrentJustifiedEpoch() = %d, want 0", epoch)
+	}
+	if epoch := PreviousJustifiedEpoch(state); epoch != 0 {
+		t.Errorf("PreviousJustifiedEpoch() = %d, want 0", epoch)
+	}
+}
+
+func TestFinalizedAndJustifiedSlots(t *testing.T) {
+	slotsPerEpoch := params.BeaconConfig().SlotsPerEpoch
+	for _, epoch := range []uint64{0, 1, 2, 100} {
+		state := &pb.BeaconState{
+			FinalizedCheckpoint:        &ethpb.Checkpoint{Epoch: epoch},
+			CurrentJustifiedCheckpoint: &ethpb.Checkpoint{Epoch: epoch + 1},
+		}
+		if slot := FinalizedSlot(state); slot != epoch*slotsPerEpoch {
+			t.Errorf("FinalizedSlot() for finalized epoch %d = %d, want %d", epoch, slot, epoch*slotsPerEpoch)
+		}
+		if slot := JustifiedSlot(state); slot != (epoch+1)*slotsPerEpoch {
+			t.Errorf("JustifiedSlot() for justified epoch %d = %d, want %d", epoch+1, slot, (epoch+1)*slotsPerEpoch)
+		}
+	}
+
+	state := &pb.BeaconState{}
+	if slot := FinalizedSlot(state); slot != 0 {
+		t.Errorf("FinalizedSlot() = %d, want 0", slot)
+	}
+	if slot := JustifiedSlot(state); slot != 0 {
+		t.Errorf("JustifiedSlot() = %d, want 0", slot)
+	}
+}
@@ -47,6 +47,20 @@ func SlotCommitteeCount(activeValidatorCount uint64) uint64 {
 	return committeePerSlot
 }
 
+// ValidateCommitteeIndex returns an error if the committee index is out of range for the
+// number of committees at the given slot.
+func ValidateCommitteeIndex(state *pb.BeaconState, slot uint64, committeeIndex uint64) error {
+	activeValidatorCount, err := ActiveValidatorCount(state, SlotToEpoch(slot))
+	if err != nil {
+		return errors.Wrap(err, "could not get active validator count")
+	}
+	committeeCount := SlotCommitteeCount(activeValidatorCount)
+	if committeeIndex >= committeeCount {
+		return fmt.Errorf("committee index %d is out of range, slot %d has %d committees", committeeIndex, slot, committeeCount)
+	}
+	return nil
+}
+
 // BeaconCommitteeFromState returns the crosslink committee of a given slot and committee index. This
 // is a spec implementation where state is used as an argument. In case of state retrieval
 // becomes expensive, consider using BeaconCommittee below.
@@ -90,6 +104,28 @@ func BeaconCommitteeFromState(state *pb.BeaconState, slot uint64, committeeIndex
 	return BeaconCommittee(indices, seed, slot, committeeIndex)
 }
 
+// ValidatorCommitteeIndex returns the index of the committee of the given slot the validator is
+// assigned to attest in. Found is false if the validator is not in any committee of the slot.
+func ValidatorCommitteeIndex(state *pb.BeaconState, slot uint64, validatorIndex uint64) (uint64, bool, error) {
+	activeValidatorCount, err := ActiveValidatorCount(state, SlotToEpoch(slot))
+	if err != nil {
+		return 0, false, errors.Wrap(err, "could not get active validator count")
+	}
+	committeeCount := SlotCommitteeCount(activeValidatorCount)
+	for committeeIndex := uint64(0); committeeIndex < committeeCount; committeeIndex++ {
+		committee, err := BeaconCommitteeFromState(state, slot, committeeIndex)
+		if err != nil {
+			return 0, false, errors.Wrapf(err, "could not get committee %d at slot %d", committeeIndex, slot)
+		}
+		for _, index := range committee {
+			if index == validatorIndex {
+				return committeeIndex, true, nil
+			}
+		}
+	}
+	return 0, false, nil
+}
+
 // BeaconCommittee returns the crosslink committee of a given slot and committee index. The
 // validator indices and seed are provided as an argument rather than a direct implementation
 // from the spec definition. Having them as an argument allows for cheaper computation run time.
@@ -389,17 +425,67 @@ func ShuffledIndices(state *pb.BeaconState, epoch uint64) ([]uint64, error) {
 		}
 	}
 
-	validatorCount := uint64(len(indices))
-	shuffledIndices := make([]uint64, validatorCount)
-	for i := 0; i < len(shuffledIndices); i++ {
-		permutedIndex, err := ShuffledIndex(uint64(i), validatorCount, seed)
+	return ShuffledPermutation(indices, seed)
+}
+
+// CommitteesForEpoch returns all the beacon committees of the epoch, indexed by the slot within
+// the epoch and then by committee index. All committees are derived from a single shuffle of the
+// active validator indices, which is shared through the committee cache per seed when the cache
+// is enabled. This is much cheaper than computing each committee with BeaconCommittee.
+//
+// The committees share the underlying shuffled list and must not be modified.
+func CommitteesForEpoch(state *pb.BeaconState, epoch uint64) ([][][]uint64, error) {
+	seed, err := Seed(state, epoch, params.BeaconConfig().DomainBeaconAttester)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get seed for epoch %d", epoch)
+	}
+
+	var shuffledIndices []uint64
+	if featureconfig.Get().EnableNewCache {
+		shuffledIndices, err = committeeCache.ShuffledIndices(seed)
 		if err != nil {
-			return []uint64{}, errors.Wrapf(err, "could not get shuffled index at index %d", i)
+			return nil, errors.Wrap(err, "could not interface with committee cache")
+		}
+	}
+	if shuffledIndices == nil {
+		shuffledIndices, err = ShuffledIndices(state, epoch)
+		if err != nil {
+			return nil, err
+		}
+		if featureconfig.Get().EnableNewCache {
+			if err := UpdateCommitteeCache(state, epoch); err != nil {
+				return nil, errors.Wrap(err, "could not update committee cache")
+			}
 		}
-		shuffledIndices[i] = indices[permutedIndex]
 	}
 
-	return shuffledIndices, nil
+	validatorCount := uint64(len(shuffledIndices))
+	committeesPerSlot := SlotCommitteeCount(validatorCount)
+	count := committeesPerSlot * params.BeaconConfig().SlotsPerEpoch
+
+	committees := make([][][]uint64, params.BeaconConfig().SlotsPerEpoch)
+	for slot := range committees {
+		committees[slot] = make([][]uint64, committeesPerSlot)
+		for committeeIndex := range committees[slot] {
+			index := uint64(slot)*committeesPerSlot + uint64(committeeIndex)
+			start := sliceutil.SplitOffset(validatorCount, count, index)
+			end := sliceutil.SplitOffset(validatorCount, count, index+1)
+			committees[slot][committeeIndex] = shuffledIndices[start:end]
+		}
+	}
+	return committees, nil
+}
+
+// CommitteeKnownFromSlot returns the first slot from which the committees of the epoch can be
+// computed deterministically. The committees are shuffled with the seed of the epoch, which is
+// derived from the randao mix at the end of the epoch MinSeedLookahead+1 epochs before.
+// Committees of the first epochs are known from genesis.
+func CommitteeKnownFromSlot(epoch uint64) uint64 {
+	lookahead := params.BeaconConfig().MinSeedLookahead
+	if epoch <= lookahead {
+		return 0
+	}
+	return StartSlot(epoch - lookahead)
 }
 
 // UpdateCommitteeCache gets called at the beginning of every epoch to cache the committee shuffled indices
@@ -9,6 +9,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/prysmaticlabs/prysm/beacon-chain/cache"
 	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
 	"github.com/prysmaticlabs/prysm/shared/featureconfig"
 	"github.com/prysmaticlabs/prysm/shared/params"
@@ -870,3 +871,150 @@ func TestBeaconCommitteeFromState_UpdateCacheForPreviousEpoch(t *testing.T) {
 		t.Error("did not cache active indices")
 	}
 }
+
+func TestValidateCommitteeIndex(t *testing.T) {
+	committeesPerSlot := uint64(4)
+	validators := make([]*ethpb.Validator, committeesPerSlot*params.BeaconConfig().SlotsPerEpoch*params.BeaconConfig().TargetCommitteeSize)
+	for i := 0; i < len(validators); i++ {
+		validators[i] = &ethpb.Validator{
+			ExitEpoch: params.BeaconConfig().FarFutureEpoch,
+		}
+	}
+	state := &pb.BeaconState{Validators: validators}
+
+	tests := []struct {
+		name           string
+		committeeIndex uint64
+		wantErr        bool
+	}{
+		{name: "First index", committeeIndex: 0},
+		{name: "Boundary index", committeeIndex: committeesPerSlot - 1},
+		{name: "Committee count", committeeIndex: committeesPerSlot, wantErr: true},
+		{name: "Beyond committee count", committeeIndex: committeesPerSlot + 10, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCommitteeIndex(state, 1, tt.committeeIndex); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCommitteeIndex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorCommitteeIndex(t *testing.T) {
+	committeesPerSlot := uint64(2)
+	validators := make([]*ethpb.Validator, committeesPerSlot*params.BeaconConfig().SlotsPerEpoch*params.BeaconConfig().TargetCommitteeSize)
+	for i := 0; i < len(validators); i++ {
+		validators[i] = &ethpb.Validator{
+			ExitEpoch: params.BeaconConfig().FarFutureEpoch,
+		}
+	}
+	state := &pb.BeaconState{
+		Validators:  validators,
+		RandaoMixes: make([][]byte, params.BeaconConfig().EpochsPerHistoricalVector),
+	}
+	slot := uint64(3)
+
+	committee, err := BeaconCommitteeFromState(state, slot, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committeeIndex, found, err := ValidatorCommitteeIndex(state, slot, committee[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || committeeIndex != 1 {
+		t.Errorf("Wanted validator %d in committee 1, received committee %d found %v", committee[0], committeeIndex, found)
+	}
+
+	// Validators are assigned to a single slot per epoch, so a validator of another slot is not in
+	// any committee of the slot.
+	other, err := BeaconCommitteeFromState(state, slot+1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found, err := ValidatorCommitteeIndex(state, slot, other[0]); err != nil {
+		t.Fatal(err)
+	} else if found {
+		t.Errorf("Wanted validator %d to not be in a committee at slot %d", other[0], slot)
+	}
+}
+
+func TestCommitteesForEpoch_AgreesWithBeaconCommittee(t *testing.T) {
+	committeesPerSlot := uint64(2)
+	validators := make([]*ethpb.Validator, committeesPerSlot*params.BeaconConfig().SlotsPerEpoch*params.BeaconConfig().TargetCommitteeSize)
+	for i := 0; i < len(validators); i++ {
+		validators[i] = &ethpb.Validator{
+			ExitEpoch: params.BeaconConfig().FarFutureEpoch,
+		}
+	}
+	state := &pb.BeaconState{
+		Validators:  validators,
+		Slot:        params.BeaconConfig().SlotsPerEpoch,
+		RandaoMixes: make([][]byte, params.BeaconConfig().EpochsPerHistoricalVector),
+	}
+
+	// Start from an empty cache, as cached shuffles of other validator sets may share the seed.
+	committeeCache = cache.NewCommitteesCache()
+	defer func() {
+		committeeCache = cache.NewCommitteesCache()
+	}()
+	for _, newCache := range []bool{false, true} {
+		featureconfig.Init(&featureconfig.Flags{EnableNewCache: newCache})
+		for epoch := uint64(0); epoch < 2; epoch++ {
+			committees, err := CommitteesForEpoch(state, epoch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if uint64(len(committees)) != params.BeaconConfig().SlotsPerEpoch {
+				t.Fatalf("Wanted committees for %d slots, received %d", params.BeaconConfig().SlotsPerEpoch, len(committees))
+			}
+			for i, slotCommittees := range committees {
+				if uint64(len(slotCommittees)) != committeesPerSlot {
+					t.Fatalf("Wanted %d committees at slot %d, received %d", committeesPerSlot, i, len(slotCommittees))
+				}
+				slot := StartSlot(epoch) + uint64(i)
+				for committeeIndex, committee := range slotCommittees {
+					want, err := BeaconCommitteeWithoutCache(state, slot, uint64(committeeIndex))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !reflect.DeepEqual(committee, want) {
+						t.Errorf("New cache %v: computed different committee at slot %d and index %d", newCache, slot, committeeIndex)
+					}
+				}
+			}
+		}
+	}
+	featureconfig.Init(nil)
+}
+
+func TestCommitteeKnownFromSlot(t *testing.T) {
+	lookahead := params.BeaconConfig().MinSeedLookahead
+	slotsPerEpoch := params.BeaconConfig().SlotsPerEpoch
+	tests := []struct {
+		epoch uint64
+		slot  uint64
+	}{
+		{epoch: 0, slot: 0},
+		{epoch: lookahead, slot: 0},
+		{epoch: lookahead + 1, slot: slotsPerEpoch},
+		{epoch: 10, slot: (10 - lookahead) * slotsPerEpoch},
+	}
+	for _, tt := range tests {
+		if got := CommitteeKnownFromSlot(tt.epoch); got != tt.slot {
+			t.Errorf("CommitteeKnownFromSlot(%d) = %d, want %d", tt.epoch, got, tt.slot)
+		}
+	}
+}
+
+func TestCommitteeKnownFromSlot_SeedMixIsFinal(t *testing.T) {
+	// The randao mix the seed of an epoch is derived from belongs to the epoch just before the slot
+	// its committees are known from, so that mix doesn't change anymore from that slot onwards.
+	epoch := uint64(10)
+	seedMixEpoch := epoch - params.BeaconConfig().MinSeedLookahead - 1
+	if SlotToEpoch(CommitteeKnownFromSlot(epoch)) != seedMixEpoch+1 {
+		t.Errorf("Wanted committees of epoch %d to be known from the start of epoch %d, received slot %d",
+			epoch, seedMixEpoch+1, CommitteeKnownFromSlot(epoch))
+	}
+}
@@ -1,6 +1,9 @@
 package helpers
 
 import (
+	"context"
+
+	"github.com/pkg/errors"
 	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
 	"github.com/prysmaticlabs/prysm/shared/bytesutil"
 	"github.com/prysmaticlabs/prysm/shared/hashutil"
@@ -22,18 +25,39 @@ func Seed(state *pb.BeaconState, epoch uint64, domain []byte) ([32]byte, error)
 	lookAheadEpoch := epoch + params.BeaconConfig().EpochsPerHistoricalVector -
 		params.BeaconConfig().MinSeedLookahead - 1
 
-	randaoMix := RandaoMix(state, lookAheadEpoch)
+	randaoMix, err := RandaoMix(state, lookAheadEpoch)
+	if err != nil {
+		return [32]byte{}, errors.Wrap(err, "could not get randao mix")
+	}
+
+	return SeedWithMix(epoch, domain, bytesutil.ToBytes32(randaoMix)), nil
+}
 
-	seed := append(domain, bytesutil.Bytes8(epoch)...)
-	seed = append(seed, randaoMix...)
+// SeedWithContext is Seed, but returns the context error if the context has
+// already been cancelled.
+func SeedWithContext(ctx context.Context, state *pb.BeaconState, epoch uint64, domain []byte) ([32]byte, error) {
+	if ctx.Err() != nil {
+		return [32]byte{}, ctx.Err()
+	}
+	return Seed(state, epoch, domain)
+}
 
-	seed32 := hashutil.Hash(seed)
+// SeedWithMix returns the seed for the given epoch and domain type computed from an
+// explicitly provided randao mix rather than the mix stored in the state. This allows
+// committee and proposer derivation to be driven with controlled randomness.
+func SeedWithMix(epoch uint64, domainType []byte, randaoMix [32]byte) [32]byte {
+	seed := make([]byte, 0, len(domainType)+8+len(randaoMix))
+	seed = append(seed, domainType...)
+	seed = append(seed, bytesutil.Bytes8(epoch)...)
+	seed = append(seed, randaoMix[:]...)
 
-	return seed32, nil
+	return hashutil.Hash(seed)
 }
 
 // RandaoMix returns the randao mix (xor'ed seed)
-// of a given slot. It is used to shuffle validators.
+// of a given epoch. It is used to shuffle validators.
+// It errors if the state's randao mixes are too short
+// to contain the mix of the epoch.
 //
 // Spec pseudocode definition:
 //   def get_randao_mix(state: BeaconState, epoch: Epoch) -> Hash:
@@ -41,9 +65,13 @@ func Seed(state *pb.BeaconState, epoch uint64, domain []byte) ([32]byte, error)
 //    Return the randao mix at a recent ``epoch``.
 //    """
 //    return state.randao_mixes[epoch % EPOCHS_PER_HISTORICAL_VECTOR]
-func RandaoMix(state *pb.BeaconState, epoch uint64) []byte {
-	newMixLength := len(state.RandaoMixes[epoch%params.BeaconConfig().EpochsPerHistoricalVector])
-	newMix := make([]byte, newMixLength)
-	copy(newMix, state.RandaoMixes[epoch%params.BeaconConfig().EpochsPerHistoricalVector])
-	return newMix
+func RandaoMix(state *pb.BeaconState, epoch uint64) ([]byte, error) {
+	index := epoch % params.BeaconConfig().EpochsPerHistoricalVector
+	if index >= uint64(len(state.RandaoMixes)) {
+		return nil, errors.Errorf("randao mixes of length %d do not contain the mix at index %d for epoch %d",
+			len(state.RandaoMixes), index, epoch)
+	}
+	newMix := make([]byte, len(state.RandaoMixes[index]))
+	copy(newMix, state.RandaoMixes[index])
+	return newMix, nil
 }
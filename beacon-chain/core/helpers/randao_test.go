@@ -37,7 +37,10 @@ func TestRandaoMix_OK(t *testing.T) {
 	}
 	for _, test := range tests {
 		state.Slot = (test.epoch + 1) * params.BeaconConfig().SlotsPerEpoch
-		mix := RandaoMix(state, test.epoch)
+		mix, err := RandaoMix(state, test.epoch)
+		if err != nil {
+			t.Fatal(err)
+		}
 		if !bytes.Equal(test.randaoMix, mix) {
 			t.Errorf("Incorrect randao mix. Wanted: %#x, got: %#x",
 				test.randaoMix, mix)
@@ -72,7 +75,10 @@ func TestRandaoMix_CopyOK(t *testing.T) {
 	}
 	for _, test := range tests {
 		state.Slot = (test.epoch + 1) * params.BeaconConfig().SlotsPerEpoch
-		mix := RandaoMix(state, test.epoch)
+		mix, err := RandaoMix(state, test.epoch)
+		if err != nil {
+			t.Fatal(err)
+		}
 		uniqueNumber := params.BeaconConfig().EpochsPerHistoricalVector + 1000
 		binary.LittleEndian.PutUint64(mix, uniqueNumber)
 
@@ -86,6 +92,49 @@ func TestRandaoMix_CopyOK(t *testing.T) {
 	}
 }
 
+func TestRandaoMix_Wraparound(t *testing.T) {
+	length := params.BeaconConfig().EpochsPerHistoricalVector
+	randaoMixes := make([][]byte, length)
+	for i := 0; i < len(randaoMixes); i++ {
+		intInBytes := make([]byte, 32)
+		binary.LittleEndian.PutUint64(intInBytes, uint64(i))
+		randaoMixes[i] = intInBytes
+	}
+	state := &pb.BeaconState{RandaoMixes: randaoMixes}
+	tests := []struct {
+		epoch     uint64
+		randaoMix []byte
+	}{
+		{epoch: length - 1, randaoMix: randaoMixes[length-1]},
+		{epoch: length, randaoMix: randaoMixes[0]},
+		{epoch: length + 1, randaoMix: randaoMixes[1]},
+		{epoch: 3*length + 7, randaoMix: randaoMixes[7]},
+	}
+	for _, test := range tests {
+		mix, err := RandaoMix(state, test.epoch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(test.randaoMix, mix) {
+			t.Errorf("Incorrect randao mix at epoch %d. Wanted: %#x, got: %#x",
+				test.epoch, test.randaoMix, mix)
+		}
+	}
+}
+
+func TestRandaoMix_TruncatedMixes(t *testing.T) {
+	state := &pb.BeaconState{RandaoMixes: make([][]byte, 10)}
+	if _, err := RandaoMix(state, 9); err != nil {
+		t.Errorf("Unexpected error for a mix within the truncated mixes: %v", err)
+	}
+	if _, err := RandaoMix(state, 10); err == nil {
+		t.Error("Expected error for a mix beyond the truncated mixes")
+	}
+	if _, err := Seed(state, 10, params.BeaconConfig().DomainBeaconAttester); err == nil {
+		t.Error("Expected seed error with truncated mixes")
+	}
+}
+
 func TestGenerateSeed_OK(t *testing.T) {
 	randaoMixes := make([][]byte, params.BeaconConfig().EpochsPerHistoricalVector)
 	for i := 0; i < len(randaoMixes); i++ {
@@ -110,3 +159,36 @@ func TestGenerateSeed_OK(t *testing.T) {
 			got, wanted)
 	}
 }
+
+func TestSeedWithMix_MatchesSeed(t *testing.T) {
+	randaoMixes := make([][]byte, params.BeaconConfig().EpochsPerHistoricalVector)
+	for i := 0; i < len(randaoMixes); i++ {
+		intInBytes := make([]byte, 32)
+		binary.LittleEndian.PutUint64(intInBytes, uint64(i))
+		randaoMixes[i] = intInBytes
+	}
+	state := &pb.BeaconState{RandaoMixes: randaoMixes}
+
+	for _, epoch := range []uint64{0, 10, 2344, 99999} {
+		state.Slot = epoch * params.BeaconConfig().SlotsPerEpoch
+		want, err := Seed(state, epoch, params.BeaconConfig().DomainBeaconAttester)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lookAheadEpoch := epoch + params.BeaconConfig().EpochsPerHistoricalVector -
+			params.BeaconConfig().MinSeedLookahead - 1
+		mix := bytesutil.ToBytes32(randaoMixes[lookAheadEpoch%params.BeaconConfig().EpochsPerHistoricalVector])
+		got := SeedWithMix(epoch, params.BeaconConfig().DomainBeaconAttester, mix)
+		if got != want {
+			t.Errorf("Incorrect seed for epoch %d. Wanted: %#x, got: %#x", epoch, want, got)
+		}
+	}
+}
+
+func TestSeedWithMix_DifferentMixes(t *testing.T) {
+	a := SeedWithMix(1, params.BeaconConfig().DomainBeaconProposer, [32]byte{'a'})
+	b := SeedWithMix(1, params.BeaconConfig().DomainBeaconProposer, [32]byte{'b'})
+	if a == b {
+		t.Error("Expected different seeds for different randao mixes")
+	}
+}
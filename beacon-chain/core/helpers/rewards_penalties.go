@@ -1,11 +1,16 @@
 package helpers
 
 import (
+	"github.com/pkg/errors"
+	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/mathutil"
+	"github.com/prysmaticlabs/prysm/shared/params"
 )
 
 // TotalBalance returns the total amount at stake in Gwei
-// of input validators.
+// of input validators. The indices must be in range of the
+// state's validator registry.
 //
 // Spec pseudocode definition:
 //   def get_total_balance(state: BeaconState, indices: Set[ValidatorIndex]) -> Gwei:
@@ -46,6 +51,193 @@ func TotalActiveBalance(state *pb.BeaconState) (uint64, error) {
 	return total, nil
 }
 
+// ProposerBoostWeight returns the fork choice weight in Gwei added to a timely proposed block,
+// which is the ProposerScoreBoost percentage of the weight of the committee of a slot.
+//
+// Spec pseudocode definition:
+//   committee_weight = get_total_active_balance(state) // SLOTS_PER_EPOCH
+//   proposer_score = (committee_weight * PROPOSER_SCORE_BOOST) // 100
+func ProposerBoostWeight(state *pb.BeaconState) (uint64, error) {
+	totalBalance, err := TotalActiveBalance(state)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not get total active balance")
+	}
+	committeeWeight := totalBalance / params.BeaconConfig().SlotsPerEpoch
+	return committeeWeight * params.BeaconConfig().ProposerScoreBoost / 100, nil
+}
+
+// ExpectedEpochReward returns a rough estimate in Gwei of the reward the validator earns in an
+// epoch. It assumes every active validator attests to the correct source, target and head and
+// that the attestation is included in the next slot, and ignores penalties and proposer rewards.
+// Under these assumptions each of the source, target and head components pays the full base
+// reward, and the inclusion component pays the base reward less the proposer's share, i.e.
+//   4 * base_reward - base_reward // PROPOSER_REWARD_QUOTIENT
+// Validators which are not active in the current epoch earn no reward.
+func ExpectedEpochReward(state *pb.BeaconState, validatorIndex uint64) (uint64, error) {
+	if validatorIndex >= uint64(len(state.Validators)) {
+		return 0, errors.Errorf("validator index %d out of range, registry has %d validators", validatorIndex, len(state.Validators))
+	}
+	validator := state.Validators[validatorIndex]
+	if !IsActiveValidator(validator, CurrentEpoch(state)) {
+		return 0, nil
+	}
+	totalBalance, err := TotalActiveBalance(state)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not get total active balance")
+	}
+	if totalBalance == 0 {
+		return 0, errors.New("total active balance is 0")
+	}
+	baseReward := validator.EffectiveBalance * params.BeaconConfig().BaseRewardFactor /
+		mathutil.IntegerSquareRoot(totalBalance) / params.BeaconConfig().BaseRewardsPerEpoch
+	proposerReward := baseReward / params.BeaconConfig().ProposerRewardQuotient
+	return 4*baseReward - proposerReward, nil
+}
+
+// ProposerRewardForAttestations returns an estimate in Gwei of the reward the proposer of the block
+// earns for including its attestations, which is the proposer's share of the base reward of each
+// distinct validator attesting in the block:
+//   base_reward // PROPOSER_REWARD_QUOTIENT
+// It is an estimate: attestations are not validated, and an attester counts even if its earliest
+// attestation of the epoch, which alone earns the proposer reward, was included in an earlier block.
+func ProposerRewardForAttestations(state *pb.BeaconState, block *ethpb.BeaconBlock) (uint64, error) {
+	if block == nil || block.Body == nil {
+		return 0, errors.New("nil block body")
+	}
+	totalBalance, err := TotalActiveBalance(state)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not get total active balance")
+	}
+	if totalBalance == 0 {
+		return 0, errors.New("total active balance is 0")
+	}
+	sqrtBalance := mathutil.IntegerSquareRoot(totalBalance)
+	attested := make(map[uint64]bool)
+	reward := uint64(0)
+	for _, att := range block.Body.Attestations {
+		if att == nil || att.Data == nil {
+			return 0, errors.New("nil attestation data")
+		}
+		committee, err := BeaconCommitteeFromState(state, att.Data.Slot, att.Data.CommitteeIndex)
+		if err != nil {
+			return 0, errors.Wrap(err, "could not get attestation committee")
+		}
+		indices, err := AttestingIndices(att.AggregationBits, committee)
+		if err != nil {
+			return 0, errors.Wrap(err, "could not get attesting indices")
+		}
+		for _, idx := range indices {
+			if attested[idx] {
+				continue
+			}
+			attested[idx] = true
+			baseReward := state.Validators[idx].EffectiveBalance * params.BeaconConfig().BaseRewardFactor /
+				sqrtBalance / params.BeaconConfig().BaseRewardsPerEpoch
+			reward += baseReward / params.BeaconConfig().ProposerRewardQuotient
+		}
+	}
+	return reward, nil
+}
+
+// BaseRewardPerIncrement returns the base reward in Gwei of the Altair reward scheme for each
+// effective balance increment of a validator, given the total active balance.
+//
+// Spec pseudocode definition:
+//  def get_base_reward_per_increment(state: BeaconState) -> Gwei:
+//    return Gwei(EFFECTIVE_BALANCE_INCREMENT * BASE_REWARD_FACTOR // integer_squareroot(get_total_active_balance(state)))
+func BaseRewardPerIncrement(totalActiveBalance uint64) (uint64, error) {
+	if totalActiveBalance == 0 {
+		return 0, errors.New("total active balance is 0")
+	}
+	return params.BeaconConfig().EffectiveBalanceIncrement * params.BeaconConfig().BaseRewardFactor /
+		mathutil.IntegerSquareRoot(totalActiveBalance), nil
+}
+
+// BaseRewardAltair returns the base reward in Gwei of the Altair reward scheme of a validator with
+// the effective balance, given the total active balance.
+//
+// Spec pseudocode definition:
+//  def get_base_reward(state: BeaconState, index: ValidatorIndex) -> Gwei:
+//    """
+//    Return the base reward for the validator defined by ``index`` with respect to the current ``state``.
+//    """
+//    increments = state.validators[index].effective_balance // EFFECTIVE_BALANCE_INCREMENT
+//    return Gwei(increments * get_base_reward_per_increment(state))
+func BaseRewardAltair(effectiveBalance uint64, totalActiveBalance uint64) (uint64, error) {
+	perIncrement, err := BaseRewardPerIncrement(totalActiveBalance)
+	if err != nil {
+		return 0, err
+	}
+	increments := effectiveBalance / params.BeaconConfig().EffectiveBalanceIncrement
+	return increments * perIncrement, nil
+}
+
+// SyncCommitteeReward returns the reward in Gwei of the Altair reward scheme earned by each
+// participant of the sync committee in a slot, and the reward earned by the proposer of the slot
+// for including each participant.
+//
+// Spec pseudocode definition:
+//    total_active_increments = get_total_active_balance(state) // EFFECTIVE_BALANCE_INCREMENT
+//    total_base_rewards = Gwei(get_base_reward_per_increment(state) * total_active_increments)
+//    max_participant_rewards = Gwei(total_base_rewards * SYNC_REWARD_WEIGHT // WEIGHT_DENOMINATOR // SLOTS_PER_EPOCH)
+//    participant_reward = Gwei(max_participant_rewards // SYNC_COMMITTEE_SIZE)
+//    proposer_reward = Gwei(participant_reward * PROPOSER_WEIGHT // (WEIGHT_DENOMINATOR - PROPOSER_WEIGHT))
+func SyncCommitteeReward(state *pb.BeaconState) (participantReward uint64, proposerReward uint64, err error) {
+	cfg := params.BeaconConfig()
+	if cfg.SyncCommitteeSize == 0 {
+		return 0, 0, errors.New("sync committee size is 0")
+	}
+	if cfg.WeightDenominator <= cfg.ProposerWeight {
+		return 0, 0, errors.Errorf("weight denominator %d is not greater than the proposer weight %d", cfg.WeightDenominator, cfg.ProposerWeight)
+	}
+	totalBalance, err := TotalActiveBalance(state)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "could not get total active balance")
+	}
+	perIncrement, err := BaseRewardPerIncrement(totalBalance)
+	if err != nil {
+		return 0, 0, err
+	}
+	totalBaseRewards := perIncrement * (totalBalance / cfg.EffectiveBalanceIncrement)
+	maxParticipantRewards := totalBaseRewards * cfg.SyncRewardWeight / cfg.WeightDenominator / cfg.SlotsPerEpoch
+	participantReward = maxParticipantRewards / cfg.SyncCommitteeSize
+	proposerReward = participantReward * cfg.ProposerWeight / (cfg.WeightDenominator - cfg.ProposerWeight)
+	return participantReward, proposerReward, nil
+}
+
+// InclusionDelay returns the number of slots between the slot of an attestation and the slot it
+// was included in. It returns an error if the attestation is included before the minimum inclusion
+// delay has passed, or after the inclusion window of an epoch.
+//
+// Spec pseudocode definition:
+//    assert data.slot + MIN_ATTESTATION_INCLUSION_DELAY <= state.slot <= data.slot + SLOTS_PER_EPOCH
+func InclusionDelay(attestationSlot uint64, inclusionSlot uint64) (uint64, error) {
+	if inclusionSlot < attestationSlot {
+		return 0, errors.Errorf("inclusion slot %d precedes attestation slot %d", inclusionSlot, attestationSlot)
+	}
+	delay := inclusionSlot - attestationSlot
+	if delay < params.BeaconConfig().MinAttestationInclusionDelay {
+		return 0, errors.Errorf("inclusion delay %d is shorter than the minimum of %d", delay, params.BeaconConfig().MinAttestationInclusionDelay)
+	}
+	if delay > params.BeaconConfig().SlotsPerEpoch {
+		return 0, errors.Errorf("inclusion delay %d is beyond the inclusion window of %d slots", delay, params.BeaconConfig().SlotsPerEpoch)
+	}
+	return delay, nil
+}
+
+// InclusionReward returns the part of the reward which is earned for the inclusion delay of an
+// attestation, scaling the given reward by the inverse of the delay. A delay of 0 earns no reward.
+//
+// Spec pseudocode definition:
+//    max_attester_reward = get_base_reward(state, index) - proposer_reward
+//    rewards[index] += Gwei(max_attester_reward // attestation.inclusion_delay)
+func InclusionReward(baseReward uint64, delay uint64) uint64 {
+	if delay == 0 {
+		return 0
+	}
+	return baseReward / delay
+}
+
 // IncreaseBalance increases validator with the given 'index' balance by 'delta' in Gwei.
 //
 // Spec pseudocode definition:
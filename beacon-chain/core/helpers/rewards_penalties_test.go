@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-bitfield"
 	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
 	"github.com/prysmaticlabs/prysm/shared/params"
 )
@@ -23,6 +24,19 @@ func TestTotalBalance_OK(t *testing.T) {
 	}
 }
 
+func TestTotalBalance_SingleIndex(t *testing.T) {
+	state := &pb.BeaconState{Validators: []*ethpb.Validator{
+		{EffectiveBalance: 27 * 1e9}, {EffectiveBalance: 28 * 1e9},
+	}}
+
+	balance := TotalBalance(state, []uint64{1})
+	wanted := state.Validators[1].EffectiveBalance
+
+	if balance != wanted {
+		t.Errorf("Incorrect TotalBalance. Wanted: %d, got: %d", wanted, balance)
+	}
+}
+
 func TestTotalBalance_ReturnsOne(t *testing.T) {
 	state := &pb.BeaconState{Validators: []*ethpb.Validator{}}
 
@@ -66,6 +80,278 @@ func TestTotalActiveBalance_OK(t *testing.T) {
 	}
 }
 
+func TestProposerBoostWeight(t *testing.T) {
+	tests := []struct {
+		validatorCount uint64
+		exitedCount    uint64
+		wanted         uint64
+	}{
+		// 64 * 32 ETH / 32 slots = 64 ETH committee weight, 70% of which is 44.8 ETH.
+		{validatorCount: 64, wanted: 44800000000},
+		// Exited validators don't contribute: 32 * 32 ETH / 32 slots * 70% = 22.4 ETH.
+		{validatorCount: 64, exitedCount: 32, wanted: 22400000000},
+		// 16384 * 32 ETH / 32 slots * 70% = 11468.8 ETH.
+		{validatorCount: 16384, wanted: 11468800000000},
+		{validatorCount: 0, wanted: 0},
+	}
+	for _, test := range tests {
+		validators := make([]*ethpb.Validator, test.validatorCount)
+		for i := range validators {
+			validators[i] = &ethpb.Validator{
+				EffectiveBalance: params.BeaconConfig().MaxEffectiveBalance,
+				ExitEpoch:        params.BeaconConfig().FarFutureEpoch,
+			}
+			if uint64(i) < test.exitedCount {
+				validators[i].ExitEpoch = 0
+			}
+		}
+		weight, err := ProposerBoostWeight(&pb.BeaconState{Validators: validators})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if weight != test.wanted {
+			t.Errorf("Incorrect ProposerBoostWeight for %d validators. Wanted: %d, got: %d", test.validatorCount, test.wanted, weight)
+		}
+	}
+}
+
+func TestExpectedEpochReward(t *testing.T) {
+	validators := make([]*ethpb.Validator, 64)
+	for i := range validators {
+		validators[i] = &ethpb.Validator{
+			EffectiveBalance: params.BeaconConfig().MaxEffectiveBalance,
+			ExitEpoch:        params.BeaconConfig().FarFutureEpoch,
+		}
+	}
+	// Not yet active.
+	validators[63].ActivationEpoch = params.BeaconConfig().FarFutureEpoch
+	validators[63].EffectiveBalance = 0
+	state := &pb.BeaconState{Validators: validators}
+
+	// Total active balance is 63 * 32 ETH, the integer square root of which is 1419859. The base
+	// reward is 32 ETH * 64 / 1419859 / 4 = 360599, so the estimate is 4 * 360599 - 360599 / 8.
+	reward, err := ExpectedEpochReward(state, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wanted := uint64(1397322); reward != wanted {
+		t.Errorf("Incorrect ExpectedEpochReward. Wanted: %d, got: %d", wanted, reward)
+	}
+
+	reward, err = ExpectedEpochReward(state, 63)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reward != 0 {
+		t.Errorf("Wanted no reward for an inactive validator, got: %d", reward)
+	}
+
+	if _, err := ExpectedEpochReward(state, 64); err == nil {
+		t.Error("Expected error for an out of range validator index")
+	}
+}
+
+func TestProposerRewardForAttestations(t *testing.T) {
+	validators := make([]*ethpb.Validator, 64)
+	for i := range validators {
+		validators[i] = &ethpb.Validator{
+			EffectiveBalance: params.BeaconConfig().MaxEffectiveBalance,
+			ExitEpoch:        params.BeaconConfig().FarFutureEpoch,
+		}
+	}
+	state := &pb.BeaconState{
+		Validators:  validators,
+		RandaoMixes: make([][]byte, params.BeaconConfig().EpochsPerHistoricalVector),
+	}
+	firstCommittee, err := BeaconCommitteeFromState(state, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondCommittee, err := BeaconCommitteeFromState(state, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allBits := bitfield.NewBitlist(uint64(len(firstCommittee)))
+	for i := range firstCommittee {
+		allBits.SetBitAt(uint64(i), true)
+	}
+	firstBit := bitfield.NewBitlist(uint64(len(secondCommittee)))
+	firstBit.SetBitAt(0, true)
+	block := &ethpb.BeaconBlock{
+		Slot: 3,
+		Body: &ethpb.BeaconBlockBody{
+			Attestations: []*ethpb.Attestation{
+				{Data: &ethpb.AttestationData{Slot: 1}, AggregationBits: allBits},
+				{Data: &ethpb.AttestationData{Slot: 2}, AggregationBits: firstBit},
+				// Attesters already included in the block are only counted once.
+				{Data: &ethpb.AttestationData{Slot: 1}, AggregationBits: allBits},
+			},
+		},
+	}
+
+	// Total active balance is 64 * 32 ETH, the integer square root of which is 1431083. The base
+	// reward is 32 ETH * 64 / 1431083 / 4 = 357771, of which the proposer earns 357771 / 8 = 44721
+	// for each distinct attester.
+	reward, err := ProposerRewardForAttestations(state, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wanted := uint64(len(firstCommittee)+1) * 44721; reward != wanted {
+		t.Errorf("Incorrect ProposerRewardForAttestations. Wanted: %d, got: %d", wanted, reward)
+	}
+
+	reward, err = ProposerRewardForAttestations(state, &ethpb.BeaconBlock{Body: &ethpb.BeaconBlockBody{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reward != 0 {
+		t.Errorf("Wanted no reward for a block without attestations, got: %d", reward)
+	}
+
+	if _, err := ProposerRewardForAttestations(state, &ethpb.BeaconBlock{}); err == nil {
+		t.Error("Expected error for a block without a body")
+	}
+}
+
+func TestBaseRewardAltair(t *testing.T) {
+	maxBalance := params.BeaconConfig().MaxEffectiveBalance
+	// Vectors for the mainnet BASE_REWARD_FACTOR of 64 and an effective balance increment of 1 ETH,
+	// with the given number of validators at the max effective balance.
+	tests := []struct {
+		validators       uint64
+		effectiveBalance uint64
+		perIncrement     uint64
+		baseReward       uint64
+	}{
+		{validators: 64, effectiveBalance: maxBalance, perIncrement: 44721, baseReward: 1431072},
+		{validators: 1024, effectiveBalance: maxBalance, perIncrement: 11180, baseReward: 357760},
+		{validators: 16384, effectiveBalance: maxBalance, perIncrement: 2795, baseReward: 89440},
+		{validators: 100000, effectiveBalance: maxBalance, perIncrement: 1131, baseReward: 36192},
+		// Effective balances below an increment do not earn a base reward.
+		{validators: 1024, effectiveBalance: 1e9 - 1, perIncrement: 11180, baseReward: 0},
+		{validators: 1024, effectiveBalance: 16e9, perIncrement: 11180, baseReward: 178880},
+	}
+	for _, tt := range tests {
+		totalBalance := tt.validators * maxBalance
+		perIncrement, err := BaseRewardPerIncrement(totalBalance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perIncrement != tt.perIncrement {
+			t.Errorf("BaseRewardPerIncrement(%d) = %d, want %d", totalBalance, perIncrement, tt.perIncrement)
+		}
+		baseReward, err := BaseRewardAltair(tt.effectiveBalance, totalBalance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if baseReward != tt.baseReward {
+			t.Errorf("BaseRewardAltair(%d, %d) = %d, want %d", tt.effectiveBalance, totalBalance, baseReward, tt.baseReward)
+		}
+	}
+}
+
+func TestBaseRewardAltair_ZeroTotalBalance(t *testing.T) {
+	if _, err := BaseRewardPerIncrement(0); err == nil {
+		t.Error("Expected an error for a total active balance of 0")
+	}
+	if _, err := BaseRewardAltair(params.BeaconConfig().MaxEffectiveBalance, 0); err == nil {
+		t.Error("Expected an error for a total active balance of 0")
+	}
+}
+
+func TestSyncCommitteeReward(t *testing.T) {
+	// Vectors for the mainnet sync committee size of 512, sync reward weight of 2, proposer weight
+	// of 8 and weight denominator of 64, with the given number of validators at the max effective
+	// balance.
+	tests := []struct {
+		validators        uint64
+		participantReward uint64
+		proposerReward    uint64
+	}{
+		{validators: 64, participantReward: 174, proposerReward: 24},
+		{validators: 1024, participantReward: 698, proposerReward: 99},
+		{validators: 16384, participantReward: 2795, proposerReward: 399},
+		{validators: 100000, participantReward: 6903, proposerReward: 986},
+	}
+	for _, tt := range tests {
+		validators := make([]*ethpb.Validator, tt.validators)
+		for i := range validators {
+			validators[i] = &ethpb.Validator{
+				EffectiveBalance: params.BeaconConfig().MaxEffectiveBalance,
+				ExitEpoch:        params.BeaconConfig().FarFutureEpoch,
+			}
+		}
+		participantReward, proposerReward, err := SyncCommitteeReward(&pb.BeaconState{Validators: validators})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if participantReward != tt.participantReward {
+			t.Errorf("Incorrect participant reward for %d validators. Wanted: %d, got: %d", tt.validators, tt.participantReward, participantReward)
+		}
+		if proposerReward != tt.proposerReward {
+			t.Errorf("Incorrect proposer reward for %d validators. Wanted: %d, got: %d", tt.validators, tt.proposerReward, proposerReward)
+		}
+	}
+
+	if _, _, err := SyncCommitteeReward(&pb.BeaconState{}); err == nil {
+		t.Error("Expected error for a state without active balance")
+	}
+}
+
+func TestSyncCommitteeReward_ZeroCommitteeSize(t *testing.T) {
+	defer params.OverrideBeaconConfig(params.BeaconConfig())
+	c := *params.BeaconConfig()
+	c.SyncCommitteeSize = 0
+	params.OverrideBeaconConfig(&c)
+
+	state := &pb.BeaconState{Validators: []*ethpb.Validator{
+		{EffectiveBalance: c.MaxEffectiveBalance, ExitEpoch: c.FarFutureEpoch},
+	}}
+	if _, _, err := SyncCommitteeReward(state); err == nil {
+		t.Error("Expected error for a sync committee size of 0")
+	}
+}
+func TestInclusionDelay(t *testing.T) {
+	slotsPerEpoch := params.BeaconConfig().SlotsPerEpoch
+	tests := []struct {
+		name            string
+		attestationSlot uint64
+		inclusionSlot   uint64
+		want            uint64
+		wantErr         bool
+	}{
+		{name: "Next slot", attestationSlot: 10, inclusionSlot: 11, want: 1},
+		{name: "End of inclusion window", attestationSlot: 10, inclusionSlot: 10 + slotsPerEpoch, want: slotsPerEpoch},
+		{name: "Beyond inclusion window", attestationSlot: 10, inclusionSlot: 11 + slotsPerEpoch, wantErr: true},
+		{name: "Same slot", attestationSlot: 10, inclusionSlot: 10, wantErr: true},
+		{name: "Included before attestation slot", attestationSlot: 10, inclusionSlot: 9, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InclusionDelay(tt.attestationSlot, tt.inclusionSlot)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InclusionDelay() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("InclusionDelay() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInclusionReward(t *testing.T) {
+	baseReward := uint64(360599)
+	if reward := InclusionReward(baseReward, 1); reward != baseReward {
+		t.Errorf("Wanted the full reward at delay 1, got: %d", reward)
+	}
+	if reward := InclusionReward(baseReward, 4); reward != baseReward/4 {
+		t.Errorf("Wanted a quarter of the reward at delay 4, got: %d", reward)
+	}
+	if reward := InclusionReward(baseReward, 0); reward != 0 {
+		t.Errorf("Wanted no reward at delay 0, got: %d", reward)
+	}
+}
+
 func TestGetBalance_OK(t *testing.T) {
 	tests := []struct {
 		i uint64
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 
+	"github.com/pkg/errors"
 	"github.com/prysmaticlabs/prysm/shared/bytesutil"
 	"github.com/prysmaticlabs/prysm/shared/hashutil"
 	"github.com/prysmaticlabs/prysm/shared/params"
@@ -44,6 +45,22 @@ func UnShuffledIndex(index uint64, indexCount uint64, seed [32]byte) (uint64, er
 	return ComputeShuffledIndex(index, indexCount, seed, false /* un-shuffle */)
 }
 
+// ShuffledPermutation returns the full permutation of the indices shuffled with the seed, in the
+// order committees are drawn from. The i-th shuffled index is
+// indices[compute_shuffled_index(i, len(indices), seed)].
+func ShuffledPermutation(indices []uint64, seed [32]byte) ([]uint64, error) {
+	indexCount := uint64(len(indices))
+	shuffledIndices := make([]uint64, indexCount)
+	for i := uint64(0); i < indexCount; i++ {
+		permutedIndex, err := ShuffledIndex(i, indexCount, seed)
+		if err != nil {
+			return []uint64{}, errors.Wrapf(err, "could not get shuffled index at index %d", i)
+		}
+		shuffledIndices[i] = indices[permutedIndex]
+	}
+	return shuffledIndices, nil
+}
+
 // ComputeShuffledIndex returns the shuffled validator index corresponding to seed and index count.
 // Spec pseudocode definition:
 //   def compute_shuffled_index(index: ValidatorIndex, index_count: uint64, seed: Hash) -> ValidatorIndex:
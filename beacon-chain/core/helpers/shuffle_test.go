@@ -182,3 +182,41 @@ func TestSplitIndicesAndOffset_OK(t *testing.T) {
 		}
 	}
 }
+
+func TestShuffledPermutation_Bijection(t *testing.T) {
+	seed := [32]byte{123, 42}
+	indices := make([]uint64, 1000)
+	for i := range indices {
+		// Use indices that differ from their positions to catch mixing them up.
+		indices[i] = uint64(3 * i)
+	}
+	shuffled, err := ShuffledPermutation(indices, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shuffled) != len(indices) {
+		t.Fatalf("Wanted %d shuffled indices, received %d", len(indices), len(shuffled))
+	}
+	seen := make(map[uint64]bool, len(indices))
+	for _, index := range shuffled {
+		if index%3 != 0 || index >= uint64(3*len(indices)) {
+			t.Fatalf("Shuffled index %d is not one of the input indices", index)
+		}
+		if seen[index] {
+			t.Fatalf("Index %d appears more than once in the permutation", index)
+		}
+		seen[index] = true
+	}
+	if reflect.DeepEqual(shuffled, indices) {
+		t.Error("Expected the permutation to reorder the indices")
+	}
+	for i := uint64(0); i < uint64(len(indices)); i++ {
+		permuted, err := ShuffledIndex(i, uint64(len(indices)), seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shuffled[i] != indices[permuted] {
+			t.Fatalf("Wanted shuffled index %d at position %d, received %d", indices[permuted], i, shuffled[i])
+		}
+	}
+}
@@ -59,6 +59,26 @@ func NextEpoch(state *pb.BeaconState) uint64 {
 	return SlotToEpoch(state.Slot) + 1
 }
 
+// FinalizationLag returns the number of epochs between the current epoch and
+// the finalized checkpoint of the state. It is 0 if the finalized epoch is
+// not before the current epoch.
+func FinalizationLag(state *pb.BeaconState, currentEpoch uint64) uint64 {
+	var finalizedEpoch uint64
+	if state.FinalizedCheckpoint != nil {
+		finalizedEpoch = state.FinalizedCheckpoint.Epoch
+	}
+	if finalizedEpoch >= currentEpoch {
+		return 0
+	}
+	return currentEpoch - finalizedEpoch
+}
+
+// IsFinalizationStalled returns true if the finalization lag of the state
+// exceeds the threshold number of epochs.
+func IsFinalizationStalled(state *pb.BeaconState, currentEpoch uint64, threshold uint64) bool {
+	return FinalizationLag(state, currentEpoch) > threshold
+}
+
 // StartSlot returns the first slot number of the
 // current epoch.
 //
@@ -85,8 +105,22 @@ func IsEpochEnd(slot uint64) bool {
 }
 
 // SlotsSinceEpochStarts returns number of slots since the start of the epoch.
+//
+// Deprecated: use SlotsSinceEpochStart.
 func SlotsSinceEpochStarts(slot uint64) uint64 {
-	return slot - StartSlot(SlotToEpoch(slot))
+	return SlotsSinceEpochStart(slot)
+}
+
+// SlotsSinceEpochStart returns the number of slots since the start of the slot's epoch. This is
+// 0 at the first slot of an epoch.
+func SlotsSinceEpochStart(slot uint64) uint64 {
+	return slot % params.BeaconConfig().SlotsPerEpoch
+}
+
+// SlotsUntilEpochEnd returns the number of slots after the given slot until the last slot of its
+// epoch. This is 0 at the last slot of an epoch.
+func SlotsUntilEpochEnd(slot uint64) uint64 {
+	return params.BeaconConfig().SlotsPerEpoch - 1 - SlotsSinceEpochStart(slot)
 }
 
 // Allow for slots "from the future" within a certain tolerance.
@@ -3,6 +3,7 @@ package helpers
 import (
 	"testing"
 
+	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
 	"github.com/prysmaticlabs/prysm/shared/params"
 )
@@ -120,6 +121,14 @@ func TestIsEpochStart(t *testing.T) {
 			slot:   epochLength * 2,
 			result: true,
 		},
+		{
+			slot:   epochLength + epochLength/2,
+			result: false,
+		},
+		{
+			slot:   2*epochLength - 1,
+			result: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -148,6 +157,14 @@ func TestIsEpochEnd(t *testing.T) {
 			slot:   epochLength - 1,
 			result: true,
 		},
+		{
+			slot:   epochLength + epochLength/2,
+			result: false,
+		},
+		{
+			slot:   2*epochLength - 1,
+			result: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -174,3 +191,67 @@ func TestSlotsSinceEpochStarts(t *testing.T) {
 		}
 	}
 }
+
+func TestSlotsSinceEpochStart(t *testing.T) {
+	slotsPerEpoch := params.BeaconConfig().SlotsPerEpoch
+	tests := []struct {
+		slot        uint64
+		wantedSlots uint64
+	}{
+		{slot: 0, wantedSlots: 0},
+		{slot: slotsPerEpoch - 1, wantedSlots: slotsPerEpoch - 1},
+		{slot: slotsPerEpoch, wantedSlots: 0},
+		{slot: 2*slotsPerEpoch - 1, wantedSlots: slotsPerEpoch - 1},
+		{slot: 10*slotsPerEpoch + 2, wantedSlots: 2},
+	}
+	for _, tt := range tests {
+		if got := SlotsSinceEpochStart(tt.slot); got != tt.wantedSlots {
+			t.Errorf("SlotsSinceEpochStart(%d) = %v, want %v", tt.slot, got, tt.wantedSlots)
+		}
+	}
+}
+
+func TestSlotsUntilEpochEnd(t *testing.T) {
+	slotsPerEpoch := params.BeaconConfig().SlotsPerEpoch
+	tests := []struct {
+		slot        uint64
+		wantedSlots uint64
+	}{
+		{slot: 0, wantedSlots: slotsPerEpoch - 1},
+		{slot: slotsPerEpoch - 1, wantedSlots: 0},
+		{slot: slotsPerEpoch, wantedSlots: slotsPerEpoch - 1},
+		{slot: 2*slotsPerEpoch - 1, wantedSlots: 0},
+		{slot: 10*slotsPerEpoch + 2, wantedSlots: slotsPerEpoch - 3},
+	}
+	for _, tt := range tests {
+		if got := SlotsUntilEpochEnd(tt.slot); got != tt.wantedSlots {
+			t.Errorf("SlotsUntilEpochEnd(%d) = %v, want %v", tt.slot, got, tt.wantedSlots)
+		}
+	}
+}
+
+func TestFinalizationLag(t *testing.T) {
+	tests := []struct {
+		finalizedEpoch uint64
+		currentEpoch   uint64
+		wantedLag      uint64
+		stalled        bool
+	}{
+		{finalizedEpoch: 0, currentEpoch: 0, wantedLag: 0, stalled: false},
+		{finalizedEpoch: 8, currentEpoch: 10, wantedLag: 2, stalled: false},
+		{finalizedEpoch: 6, currentEpoch: 10, wantedLag: 4, stalled: false},
+		{finalizedEpoch: 5, currentEpoch: 10, wantedLag: 5, stalled: true},
+		{finalizedEpoch: 10, currentEpoch: 100, wantedLag: 90, stalled: true},
+		{finalizedEpoch: 12, currentEpoch: 10, wantedLag: 0, stalled: false},
+	}
+	threshold := uint64(4)
+	for _, tt := range tests {
+		state := &pb.BeaconState{FinalizedCheckpoint: &ethpb.Checkpoint{Epoch: tt.finalizedEpoch}}
+		if got := FinalizationLag(state, tt.currentEpoch); got != tt.wantedLag {
+			t.Errorf("FinalizationLag(%d, %d) = %d, want %d", tt.finalizedEpoch, tt.currentEpoch, got, tt.wantedLag)
+		}
+		if got := IsFinalizationStalled(state, tt.currentEpoch, threshold); got != tt.stalled {
+			t.Errorf("IsFinalizationStalled(%d, %d, %d) = %v, want %v", tt.finalizedEpoch, tt.currentEpoch, threshold, got, tt.stalled)
+		}
+	}
+}
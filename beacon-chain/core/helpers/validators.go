@@ -1,6 +1,9 @@
 package helpers
 
 import (
+	"bytes"
+	"context"
+
 	"github.com/pkg/errors"
 	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
@@ -11,6 +14,10 @@ import (
 	"github.com/prysmaticlabs/prysm/shared/params"
 )
 
+// ctxCheckInterval is the number of validators iterated over between checks
+// for context cancellation in registry wide loops.
+const ctxCheckInterval = 1024
+
 // IsActiveValidator returns the boolean value on whether the validator
 // is active or not.
 //
@@ -43,6 +50,49 @@ func IsSlashableValidator(validator *ethpb.Validator, epoch uint64) bool {
 	return beforeWithdrawable && active && !validator.Slashed
 }
 
+// IsSlashingWithinWindow returns true if the validator has been slashed and its
+// slashing still falls within the EpochsPerSlashingsVector window relative to the
+// current epoch, meaning its effective balance is still accounted for in the
+// state's slashings vector.
+//
+// A validator slashed in epoch s has a withdrawable epoch of at least
+// s + EPOCHS_PER_SLASHINGS_VECTOR, so the slashing is within the window when
+//   current_epoch < validator.withdrawable_epoch <= current_epoch + EPOCHS_PER_SLASHINGS_VECTOR
+func IsSlashingWithinWindow(validator *ethpb.Validator, currentEpoch uint64) bool {
+	if !validator.Slashed {
+		return false
+	}
+	windowEnd := currentEpoch + params.BeaconConfig().EpochsPerSlashingsVector
+	return currentEpoch < validator.WithdrawableEpoch && validator.WithdrawableEpoch <= windowEnd
+}
+
+// IsValidVoluntaryExitEpoch returns an error if the validator may not initiate a voluntary exit in
+// the current epoch, describing the condition which is not met.
+//
+// Spec pseudocode definition:
+//    # Verify the validator is active
+//    assert is_active_validator(validator, get_current_epoch(state))
+//    # Verify the validator has not yet exited
+//    assert validator.exit_epoch == FAR_FUTURE_EPOCH
+//    # Verify the validator has been active long enough
+//    assert get_current_epoch(state) >= validator.activation_epoch + PERSISTENT_COMMITTEE_PERIOD
+func IsValidVoluntaryExitEpoch(validator *ethpb.Validator, currentEpoch uint64) error {
+	if !IsActiveValidator(validator, currentEpoch) {
+		return errors.New("non-active validator cannot exit")
+	}
+	if validator.ExitEpoch != params.BeaconConfig().FarFutureEpoch {
+		return errors.Errorf("validator has already exited at epoch: %v", validator.ExitEpoch)
+	}
+	if currentEpoch < validator.ActivationEpoch+params.BeaconConfig().PersistentCommitteePeriod {
+		return errors.Errorf(
+			"validator has not been active long enough to exit, wanted epoch %d >= %d",
+			currentEpoch,
+			validator.ActivationEpoch+params.BeaconConfig().PersistentCommitteePeriod,
+		)
+	}
+	return nil
+}
+
 // ActiveValidatorIndices filters out active validators based on validator status
 // and returns their indices in a list.
 //
@@ -57,8 +107,14 @@ func IsSlashableValidator(validator *ethpb.Validator, epoch uint64) bool {
 //    """
 //    return [ValidatorIndex(i) for i, v in enumerate(state.validators) if is_active_validator(v, epoch)]
 func ActiveValidatorIndices(state *pb.BeaconState, epoch uint64) ([]uint64, error) {
+	return ActiveValidatorIndicesWithContext(context.Background(), state, epoch)
+}
+
+// ActiveValidatorIndicesWithContext is ActiveValidatorIndices, but returns early
+// with the context error once the context is cancelled.
+func ActiveValidatorIndicesWithContext(ctx context.Context, state *pb.BeaconState, epoch uint64) ([]uint64, error) {
 	if featureconfig.Get().EnableNewCache {
-		seed, err := Seed(state, epoch, params.BeaconConfig().DomainBeaconAttester)
+		seed, err := SeedWithContext(ctx, state, epoch, params.BeaconConfig().DomainBeaconAttester)
 		if err != nil {
 			return nil, errors.Wrap(err, "could not get seed")
 		}
@@ -73,6 +129,9 @@ func ActiveValidatorIndices(state *pb.BeaconState, epoch uint64) ([]uint64, erro
 
 	var indices []uint64
 	for i, v := range state.Validators {
+		if i%ctxCheckInterval == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if IsActiveValidator(v, epoch) {
 			indices = append(indices, uint64(i))
 		}
@@ -87,6 +146,39 @@ func ActiveValidatorIndices(state *pb.BeaconState, epoch uint64) ([]uint64, erro
 	return indices, nil
 }
 
+// ActiveIndicesDelta returns the indices of the validators which became active and those which
+// became inactive between epoch A and epoch B. The active sets are read through
+// ActiveValidatorIndices so cached active sets are reused when available.
+func ActiveIndicesDelta(state *pb.BeaconState, epochA uint64, epochB uint64) (activated []uint64, deactivated []uint64, err error) {
+	indicesA, err := ActiveValidatorIndices(state, epochA)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not get active indices at epoch %d", epochA)
+	}
+	indicesB, err := ActiveValidatorIndices(state, epochB)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not get active indices at epoch %d", epochB)
+	}
+
+	// Both index lists are in ascending order, so they are merged in a single pass.
+	i, j := 0, 0
+	for i < len(indicesA) && j < len(indicesB) {
+		switch {
+		case indicesA[i] == indicesB[j]:
+			i++
+			j++
+		case indicesA[i] < indicesB[j]:
+			deactivated = append(deactivated, indicesA[i])
+			i++
+		default:
+			activated = append(activated, indicesB[j])
+			j++
+		}
+	}
+	deactivated = append(deactivated, indicesA[i:]...)
+	activated = append(activated, indicesB[j:]...)
+	return activated, deactivated, nil
+}
+
 // ActiveValidatorCount returns the number of active validators in the state
 // at the given epoch.
 func ActiveValidatorCount(state *pb.BeaconState, epoch uint64) (uint64, error) {
@@ -100,6 +192,25 @@ func ActiveValidatorCount(state *pb.BeaconState, epoch uint64) (uint64, error) {
 	return count, nil
 }
 
+// SlotsActive returns the number of slots the validator has been active for as of the current slot,
+// from the start slot of its activation epoch until the current slot or the start slot of its exit
+// epoch, whichever is earlier. Validators which are not active yet have been active for 0 slots.
+func SlotsActive(validator *ethpb.Validator, currentSlot uint64) uint64 {
+	farFutureEpoch := params.BeaconConfig().FarFutureEpoch
+	if validator.ActivationEpoch == farFutureEpoch {
+		return 0
+	}
+	activationSlot := StartSlot(validator.ActivationEpoch)
+	endSlot := currentSlot
+	if validator.ExitEpoch != farFutureEpoch && StartSlot(validator.ExitEpoch) < endSlot {
+		endSlot = StartSlot(validator.ExitEpoch)
+	}
+	if endSlot <= activationSlot {
+		return 0
+	}
+	return endSlot - activationSlot
+}
+
 // DelayedActivationExitEpoch takes in epoch number and returns when
 // the validator is eligible for activation and exit.
 //
@@ -113,6 +224,131 @@ func DelayedActivationExitEpoch(epoch uint64) uint64 {
 	return epoch + 1 + params.BeaconConfig().MaxSeedLookahead
 }
 
+// ValidatorWithdrawableEpoch returns the epoch at which the validator's
+// balance becomes withdrawable.
+func ValidatorWithdrawableEpoch(validator *ethpb.Validator) uint64 {
+	return validator.WithdrawableEpoch
+}
+
+// EstimateWithdrawableEpochOnExit returns the epoch at which a validator
+// exiting at the given exit epoch becomes withdrawable.
+func EstimateWithdrawableEpochOnExit(exitEpoch uint64) uint64 {
+	return exitEpoch + params.BeaconConfig().MinValidatorWithdrawabilityDelay
+}
+
+// HasETH1WithdrawalCredential returns true if the validator's withdrawal credentials withdraw to
+// an ETH1 address.
+//
+// Spec pseudocode definition:
+//  def has_eth1_withdrawal_credential(validator: Validator) -> bool:
+//    """
+//    Check if ``validator`` has an 0x01 prefixed "eth1" withdrawal credential.
+//    """
+//    return validator.withdrawal_credentials[:1] == ETH1_ADDRESS_WITHDRAWAL_PREFIX
+func HasETH1WithdrawalCredential(validator *ethpb.Validator) bool {
+	return len(validator.WithdrawalCredentials) > 0 &&
+		validator.WithdrawalCredentials[0] == params.BeaconConfig().ETH1AddressWithdrawalPrefixByte
+}
+
+// HasBLSWithdrawalCredential returns true if the validator's withdrawal credentials are the hash
+// of a BLS withdrawal public key, which have yet to be changed to withdraw to an ETH1 address.
+func HasBLSWithdrawalCredential(validator *ethpb.Validator) bool {
+	return len(validator.WithdrawalCredentials) > 0 &&
+		validator.WithdrawalCredentials[0] == params.BeaconConfig().BLSWithdrawalPrefixByte
+}
+
+// HasCompoundingWithdrawalCredential returns true if the validator's withdrawal credentials withdraw
+// to an ETH1 address and compound rewards above the max effective balance.
+//
+// Spec pseudocode definition:
+//  def has_compounding_withdrawal_credential(validator: Validator) -> bool:
+//    """
+//    Check if ``validator`` has an 0x02 prefixed "compounding" withdrawal credential.
+//    """
+//    return is_compounding_withdrawal_credential(validator.withdrawal_credentials)
+func HasCompoundingWithdrawalCredential(validator *ethpb.Validator) bool {
+	return len(validator.WithdrawalCredentials) > 0 &&
+		validator.WithdrawalCredentials[0] == params.BeaconConfig().CompoundingWithdrawalPrefixByte
+}
+
+// MaxEffectiveBalanceForValidator returns the max effective balance of the validator, which is
+// higher for validators with compounding withdrawal credentials. The max effective balance of
+// compounding validators falls back to the max effective balance when the config does not set one.
+// It is not used by consensus code until fork-gated Electra processing exists, as phase 0 accepts
+// any withdrawal credential prefix.
+//
+// Spec pseudocode definition:
+//  def get_max_effective_balance(validator: Validator) -> Gwei:
+//    """
+//    Get max effective balance for ``validator``.
+//    """
+//    if has_compounding_withdrawal_credential(validator):
+//        return MAX_EFFECTIVE_BALANCE_ELECTRA
+//    else:
+//        return MIN_ACTIVATION_BALANCE
+func MaxEffectiveBalanceForValidator(validator *ethpb.Validator) uint64 {
+	if HasCompoundingWithdrawalCredential(validator) && params.BeaconConfig().MaxEffectiveBalanceElectra != 0 {
+		return params.BeaconConfig().MaxEffectiveBalanceElectra
+	}
+	return MinActivationBalance()
+}
+
+// ValidatorIndicesWithExecutionCredentials returns the indices of the validators whose withdrawal
+// credentials withdraw to an ETH1 execution address, or nil if there are none.
+func ValidatorIndicesWithExecutionCredentials(state *pb.BeaconState) []uint64 {
+	return validatorIndicesWhere(state, HasETH1WithdrawalCredential)
+}
+
+// ValidatorIndicesWithBLSCredentials returns the indices of the validators whose withdrawal
+// credentials still use the BLS prefix, or nil if there are none.
+func ValidatorIndicesWithBLSCredentials(state *pb.BeaconState) []uint64 {
+	return validatorIndicesWhere(state, HasBLSWithdrawalCredential)
+}
+
+func validatorIndicesWhere(state *pb.BeaconState, predicate func(*ethpb.Validator) bool) []uint64 {
+	var indices []uint64
+	for i, v := range state.Validators {
+		if predicate(v) {
+			indices = append(indices, uint64(i))
+		}
+	}
+	return indices
+}
+
+// IsFullyWithdrawable returns true if the validator's whole balance can be withdrawn at the epoch.
+//
+// Spec pseudocode definition:
+//  def is_fully_withdrawable_validator(validator: Validator, balance: Gwei, epoch: Epoch) -> bool:
+//    """
+//    Check if ``validator`` is fully withdrawable.
+//    """
+//    return (
+//        has_eth1_withdrawal_credential(validator)
+//        and validator.withdrawable_epoch <= epoch
+//        and balance > 0
+//    )
+func IsFullyWithdrawable(validator *ethpb.Validator, balance uint64, epoch uint64) bool {
+	return HasETH1WithdrawalCredential(validator) && validator.WithdrawableEpoch <= epoch && balance > 0
+}
+
+// IsPartiallyWithdrawable returns true if the validator's balance in excess of the max effective
+// balance can be withdrawn.
+//
+// Spec pseudocode definition:
+//  def is_partially_withdrawable_validator(validator: Validator, balance: Gwei) -> bool:
+//    """
+//    Check if ``validator`` is partially withdrawable.
+//    """
+//    has_max_effective_balance = validator.effective_balance == MAX_EFFECTIVE_BALANCE
+//    has_excess_balance = balance > MAX_EFFECTIVE_BALANCE
+//    return has_eth1_withdrawal_credential(validator) and has_max_effective_balance and has_excess_balance
+func IsPartiallyWithdrawable(validator *ethpb.Validator, balance uint64) bool {
+	maxEffectiveBalance := params.BeaconConfig().MaxEffectiveBalance
+	return HasETH1WithdrawalCredential(validator) &&
+		validator.EffectiveBalance == maxEffectiveBalance &&
+		balance > maxEffectiveBalance
+}
+
 // ValidatorChurnLimit returns the number of validators that are allowed to
 // enter and exit validator pool for an epoch.
 //
@@ -131,6 +367,119 @@ func ValidatorChurnLimit(activeValidatorCount uint64) (uint64, error) {
 	return churnLimit, nil
 }
 
+// MaxSlashableValidatorsPerEpoch returns the bound on how many slashed validators are processed
+// in the current epoch of the state. Slashing a validator initiates its exit, which is limited by
+// the validator churn limit of the active validators, so more slashings in an epoch than this
+// bound delay the exits of the slashed validators to later epochs.
+func MaxSlashableValidatorsPerEpoch(state *pb.BeaconState) (uint64, error) {
+	activeCount, err := ActiveValidatorCount(state, CurrentEpoch(state))
+	if err != nil {
+		return 0, errors.Wrap(err, "could not get active validator count")
+	}
+	return ValidatorChurnLimit(activeCount)
+}
+
+// AggregatorModulo returns the modulo used to select aggregators out of a
+// committee of the given size.
+//
+// Spec pseudocode definition:
+//   modulo = max(1, len(committee) // TARGET_AGGREGATORS_PER_COMMITTEE)
+func AggregatorModulo(committeeSize uint64) uint64 {
+	modulo := committeeSize / params.BeaconConfig().TargetAggregatorsPerCommittee
+	if modulo < 1 {
+		modulo = 1
+	}
+	return modulo
+}
+
+// ActivationQueue returns the indices of the validators which are eligible for activation
+// but not yet active as of the state's current epoch.
+func ActivationQueue(state *pb.BeaconState) []uint64 {
+	currentEpoch := CurrentEpoch(state)
+	var indices []uint64
+	for i, v := range state.Validators {
+		if v.ActivationEligibilityEpoch != params.BeaconConfig().FarFutureEpoch && v.ActivationEpoch > currentEpoch {
+			indices = append(indices, uint64(i))
+		}
+	}
+	return indices
+}
+
+// ExitingValidatorIndices returns the indices of the validators which have initiated an
+// exit that has not yet taken effect as of the state's current epoch.
+func ExitingValidatorIndices(state *pb.BeaconState) []uint64 {
+	currentEpoch := CurrentEpoch(state)
+	var indices []uint64
+	for i, v := range state.Validators {
+		if v.ExitEpoch != params.BeaconConfig().FarFutureEpoch && v.ExitEpoch > currentEpoch {
+			indices = append(indices, uint64(i))
+		}
+	}
+	return indices
+}
+
+// QueueDepths returns the number of validators in the activation and exit queues of the
+// state, along with the estimated number of epochs needed to drain each queue at the
+// current validator churn limit.
+func QueueDepths(state *pb.BeaconState) (entryQueue, exitQueue uint64, entryEpochs, exitEpochs uint64, err error) {
+	activeValidatorCount, err := ActiveValidatorCount(state, CurrentEpoch(state))
+	if err != nil {
+		return 0, 0, 0, 0, errors.Wrap(err, "could not get active validator count")
+	}
+	churn, err := ValidatorChurnLimit(activeValidatorCount)
+	if err != nil {
+		return 0, 0, 0, 0, errors.Wrap(err, "could not get churn limit")
+	}
+	entryQueue = uint64(len(ActivationQueue(state)))
+	exitQueue = uint64(len(ExitingValidatorIndices(state)))
+	entryEpochs = (entryQueue + churn - 1) / churn
+	exitEpochs = (exitQueue + churn - 1) / churn
+	return entryQueue, exitQueue, entryEpochs, exitEpochs, nil
+}
+
+// EpochsUntilActivation estimates the number of epochs from the state's current epoch until the
+// validator in the activation queue is activated. Validators yet to be scheduled are dequeued in
+// the order of their activation eligibility epoch and index at the current churn limit per epoch,
+// assuming finality keeps up, and activate after the activation delay. For validators already
+// scheduled, the epochs until their activation epoch are returned. An error is returned if the
+// validator is not in the activation queue.
+func EpochsUntilActivation(state *pb.BeaconState, validatorIndex uint64) (uint64, error) {
+	if validatorIndex >= uint64(len(state.Validators)) {
+		return 0, errors.Errorf("validator index %d is out of range of %d validators", validatorIndex, len(state.Validators))
+	}
+	currentEpoch := CurrentEpoch(state)
+	validator := state.Validators[validatorIndex]
+	if validator.ActivationEligibilityEpoch == params.BeaconConfig().FarFutureEpoch || validator.ActivationEpoch <= currentEpoch {
+		return 0, errors.Errorf("validator %d is not in the activation queue", validatorIndex)
+	}
+	if validator.ActivationEpoch != params.BeaconConfig().FarFutureEpoch {
+		return validator.ActivationEpoch - currentEpoch, nil
+	}
+
+	activeValidatorCount, err := ActiveValidatorCount(state, currentEpoch)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not get active validator count")
+	}
+	churn, err := ValidatorChurnLimit(activeValidatorCount)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not get churn limit")
+	}
+	// Validators ahead in the queue are eligible earlier, or at the same epoch with a lower index.
+	var position uint64
+	for _, i := range ActivationQueue(state) {
+		v := state.Validators[i]
+		if v.ActivationEpoch != params.BeaconConfig().FarFutureEpoch {
+			continue
+		}
+		if v.ActivationEligibilityEpoch < validator.ActivationEligibilityEpoch ||
+			(v.ActivationEligibilityEpoch == validator.ActivationEligibilityEpoch && i < validatorIndex) {
+			position++
+		}
+	}
+	dequeueEpoch := currentEpoch + position/churn
+	return DelayedActivationExitEpoch(dequeueEpoch) - currentEpoch, nil
+}
+
 // BeaconProposerIndex returns proposer index of a current slot.
 //
 // Spec pseudocode definition:
@@ -143,22 +492,72 @@ func ValidatorChurnLimit(activeValidatorCount uint64) (uint64, error) {
 //    indices = get_active_validator_indices(state, epoch)
 //    return compute_proposer_index(state, indices, seed)
 func BeaconProposerIndex(state *pb.BeaconState) (uint64, error) {
-	e := CurrentEpoch(state)
+	return BeaconProposerIndexWithContext(context.Background(), state)
+}
 
-	seed, err := Seed(state, e, params.BeaconConfig().DomainBeaconProposer)
+// BeaconProposerIndexWithContext is BeaconProposerIndex, but honors context
+// cancellation while computing the active indices, seed and proposer index.
+func BeaconProposerIndexWithContext(ctx context.Context, state *pb.BeaconState) (uint64, error) {
+	indices, err := ActiveValidatorIndicesWithContext(ctx, state, CurrentEpoch(state))
 	if err != nil {
-		return 0, errors.Wrap(err, "could not generate seed")
+		return 0, errors.Wrap(err, "could not get active indices")
 	}
 
-	seedWithSlot := append(seed[:], bytesutil.Bytes8(state.Slot)...)
-	seedWithSlotHash := hashutil.Hash(seedWithSlot)
+	return beaconProposerIndexFromActive(ctx, state, state.Slot, indices)
+}
+
+// BeaconProposerIndexFromActive returns the proposer index of the state's slot, sampled from
+// the provided active validator indices of the current epoch. This avoids recomputing the active
+// validator indices when the caller already knows them.
+func BeaconProposerIndexFromActive(state *pb.BeaconState, activeIndices []uint64) (uint64, error) {
+	return beaconProposerIndexFromActive(context.Background(), state, state.Slot, activeIndices)
+}
 
-	indices, err := ActiveValidatorIndices(state, e)
+// BeaconProposerIndexAtSlot returns the proposer index of the given slot rather than the state's
+// slot. The seed of the slot's epoch must be derivable from the state's randao mixes.
+func BeaconProposerIndexAtSlot(state *pb.BeaconState, slot uint64) (uint64, error) {
+	indices, err := ActiveValidatorIndices(state, SlotToEpoch(slot))
 	if err != nil {
 		return 0, errors.Wrap(err, "could not get active indices")
 	}
+	return beaconProposerIndexFromActive(context.Background(), state, slot, indices)
+}
+
+func beaconProposerIndexFromActive(ctx context.Context, state *pb.BeaconState, slot uint64, activeIndices []uint64) (uint64, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	seed, err := EpochProposerSeed(state, SlotToEpoch(slot))
+	if err != nil {
+		return 0, errors.Wrap(err, "could not generate seed")
+	}
+	return ComputeProposerIndexWithContext(ctx, state.Validators, activeIndices, proposerSeedAtSlot(seed, slot))
+}
+
+// EpochProposerSeed returns the proposer seed of the epoch, which is the same for every slot of
+// the epoch. Callers computing the proposers of several slots of an epoch can compute it once and
+// pass it to ProposerIndexWithEpochSeed for each slot.
+//
+// Spec pseudocode definition:
+//    epoch = get_current_epoch(state)
+//    seed = get_seed(state, epoch, DOMAIN_BEACON_PROPOSER)
+func EpochProposerSeed(state *pb.BeaconState, epoch uint64) ([32]byte, error) {
+	return Seed(state, epoch, params.BeaconConfig().DomainBeaconProposer)
+}
+
+// ProposerIndexWithEpochSeed returns the proposer index of the slot, sampled from the active
+// validator indices of the slot's epoch, using the epoch seed returned by EpochProposerSeed.
+func ProposerIndexWithEpochSeed(state *pb.BeaconState, slot uint64, activeIndices []uint64, epochSeed [32]byte) (uint64, error) {
+	return ComputeProposerIndex(state.Validators, activeIndices, proposerSeedAtSlot(epochSeed, slot))
+}
 
-	return ComputeProposerIndex(state.Validators, indices, seedWithSlotHash)
+// proposerSeedAtSlot derives the proposer seed of the slot from the epoch proposer seed.
+//
+// Spec pseudocode definition:
+//    seed = hash(get_seed(state, epoch, DOMAIN_BEACON_PROPOSER) + int_to_bytes(state.slot, length=8))
+func proposerSeedAtSlot(epochSeed [32]byte, slot uint64) [32]byte {
+	seedWithSlot := append(epochSeed[:], bytesutil.Bytes8(slot)...)
+	return hashutil.Hash(seedWithSlot)
 }
 
 // ComputeProposerIndex returns the index sampled by effective balance, which is used to calculate proposer.
@@ -182,6 +581,12 @@ func BeaconProposerIndex(state *pb.BeaconState) (uint64, error) {
 //            return ValidatorIndex(candidate_index)
 //        i += 1
 func ComputeProposerIndex(validators []*ethpb.Validator, activeIndices []uint64, seed [32]byte) (uint64, error) {
+	return ComputeProposerIndexWithContext(context.Background(), validators, activeIndices, seed)
+}
+
+// ComputeProposerIndexWithContext is ComputeProposerIndex, but stops sampling
+// candidates once the context is cancelled.
+func ComputeProposerIndexWithContext(ctx context.Context, validators []*ethpb.Validator, activeIndices []uint64, seed [32]byte) (uint64, error) {
 	length := uint64(len(activeIndices))
 	if length == 0 {
 		return 0, errors.New("empty active indices list")
@@ -189,6 +594,9 @@ func ComputeProposerIndex(validators []*ethpb.Validator, activeIndices []uint64,
 	maxRandomByte := uint64(1<<8 - 1)
 
 	for i := uint64(0); ; i++ {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
 		candidateIndex, err := ComputeShuffledIndex(i%length, length, seed, true /* shuffle */)
 		if err != nil {
 			return 0, err
@@ -232,6 +640,94 @@ func Domain(fork *pb.Fork, epoch uint64, domainType []byte) uint64 {
 	return bls.Domain(domainType, forkVersion)
 }
 
+// VoluntaryExitDomain returns the signature domain of a voluntary exit for the exit epoch.
+func VoluntaryExitDomain(fork *pb.Fork, epoch uint64) uint64 {
+	return Domain(fork, epoch, params.BeaconConfig().DomainVoluntaryExit)
+}
+
+// ProposerSlashingDomain returns the signature domain of the block headers of a proposer slashing
+// for the epoch of the headers.
+func ProposerSlashingDomain(fork *pb.Fork, epoch uint64) uint64 {
+	return Domain(fork, epoch, params.BeaconConfig().DomainBeaconProposer)
+}
+
+// AttesterSlashingDomain returns the signature domain of the attestations of an attester slashing
+// for the target epoch of an attestation.
+func AttesterSlashingDomain(fork *pb.Fork, epoch uint64) uint64 {
+	return Domain(fork, epoch, params.BeaconConfig().DomainBeaconAttester)
+}
+
+// VerifyBlockSignatureDomain recomputes the signature domain for the message epoch and
+// returns a descriptive error if it does not match the expected domain. This helps debug
+// signature verification failures around a fork boundary, where the message epoch decides
+// whether the previous or the current fork version is used.
+func VerifyBlockSignatureDomain(fork *pb.Fork, messageEpoch uint64, domainType []byte, expectedDomain uint64) error {
+	if fork == nil {
+		return errors.New("nil fork")
+	}
+	domain := Domain(fork, messageEpoch, domainType)
+	if domain == expectedDomain {
+		return nil
+	}
+	forkVersion := fork.CurrentVersion
+	if messageEpoch < fork.Epoch {
+		forkVersion = fork.PreviousVersion
+	}
+	return errors.Errorf(
+		"signature domain mismatch for message epoch %d: wanted %d, computed %d using fork version %#x (fork epoch %d)",
+		messageEpoch,
+		expectedDomain,
+		domain,
+		forkVersion,
+		fork.Epoch,
+	)
+}
+
+// ForkVersionAtEpoch returns the fork version in effect at the epoch, given a schedule of forks
+// ordered by their epochs. Each fork's current version takes effect at its epoch, and epochs before
+// the first fork use its previous version. This generalizes the choice Domain makes between the two
+// versions of a single fork to any number of forks.
+func ForkVersionAtEpoch(schedule []*pb.Fork, epoch uint64) ([]byte, error) {
+	if len(schedule) == 0 {
+		return nil, errors.New("empty fork schedule")
+	}
+	for i, fork := range schedule {
+		if fork == nil {
+			return nil, errors.Errorf("nil fork at index %d of fork schedule", i)
+		}
+		if i > 0 && fork.Epoch < schedule[i-1].Epoch {
+			return nil, errors.Errorf("fork schedule is not ordered by epoch: fork at index %d has epoch %d before epoch %d", i, fork.Epoch, schedule[i-1].Epoch)
+		}
+	}
+	if epoch < schedule[0].Epoch {
+		return schedule[0].PreviousVersion, nil
+	}
+	version := schedule[0].CurrentVersion
+	for _, fork := range schedule[1:] {
+		if epoch < fork.Epoch {
+			break
+		}
+		version = fork.CurrentVersion
+	}
+	return version, nil
+}
+
+// SyncCommitteePeriod returns the sync committee period of the epoch.
+func SyncCommitteePeriod(epoch uint64) uint64 {
+	return epoch / params.BeaconConfig().EpochsPerSyncCommitteePeriod
+}
+
+// IsInSyncCommitteePeriod returns true if the validator public key is one of the public keys of
+// the sync committee of a period.
+func IsInSyncCommitteePeriod(committee [][]byte, pubkey []byte) bool {
+	for _, member := range committee {
+		if bytes.Equal(member, pubkey) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsEligibleForActivationQueue checks if the validator is eligible to
 // be places into the activation queue.
 //
@@ -244,9 +740,22 @@ func Domain(fork *pb.Fork, epoch uint64, domainType []byte) uint64 {
 //        validator.activation_eligibility_epoch == FAR_FUTURE_EPOCH
 //        and validator.effective_balance == MAX_EFFECTIVE_BALANCE
 //    )
+//
+// The effective balance is checked against the configured minimum activation balance, which
+// for chains without one configured is MAX_EFFECTIVE_BALANCE.
 func IsEligibleForActivationQueue(validator *ethpb.Validator) bool {
 	return validator.ActivationEligibilityEpoch == params.BeaconConfig().FarFutureEpoch &&
-		validator.EffectiveBalance == params.BeaconConfig().MaxEffectiveBalance
+		validator.EffectiveBalance >= MinActivationBalance()
+}
+
+// MinActivationBalance returns the minimum effective balance required for a validator to be
+// placed into the activation queue, falling back to the max effective balance when the config
+// does not set one.
+func MinActivationBalance() uint64 {
+	if params.BeaconConfig().MinActivationBalance == 0 {
+		return params.BeaconConfig().MaxEffectiveBalance
+	}
+	return params.BeaconConfig().MinActivationBalance
 }
 
 // IsEligibleForActivation checks if the validator is eligible for activation.
@@ -266,3 +775,90 @@ func IsEligibleForActivation(state *pb.BeaconState, validator *ethpb.Validator)
 	return validator.ActivationEligibilityEpoch <= state.FinalizedCheckpoint.Epoch &&
 		validator.ActivationEpoch == params.BeaconConfig().FarFutureEpoch
 }
+
+// ShouldEject returns true if the validator is active in the current epoch and its effective
+// balance has dropped to the ejection balance or below.
+//
+// Spec pseudocode definition:
+//    if is_active_validator(validator, get_current_epoch(state)) and validator.effective_balance <= EJECTION_BALANCE:
+//        initiate_validator_exit(state, ValidatorIndex(index))
+func ShouldEject(validator *ethpb.Validator, currentEpoch uint64) bool {
+	return IsActiveValidator(validator, currentEpoch) &&
+		validator.EffectiveBalance <= params.BeaconConfig().EjectionBalance
+}
+
+// ValidateValidatorRegistry checks the validator registry of the given state for basic
+// invariants so that corrupt states, such as those imported from an external source,
+// are caught early. It verifies that:
+//   1. No validator in the registry is nil.
+//   2. Public keys and withdrawal credentials have the expected lengths.
+//   3. Activation eligibility, activation, exit and withdrawable epochs are monotonic.
+func ValidateValidatorRegistry(state *pb.BeaconState) error {
+	if state == nil {
+		return errors.New("nil state")
+	}
+	for i, v := range state.Validators {
+		if v == nil {
+			return errors.Errorf("validator %d is nil", i)
+		}
+		if len(v.PublicKey) != params.BeaconConfig().BLSPubkeyLength {
+			return errors.Errorf("validator %d has public key of length %d, wanted %d",
+				i, len(v.PublicKey), params.BeaconConfig().BLSPubkeyLength)
+		}
+		if len(v.WithdrawalCredentials) != 32 {
+			return errors.Errorf("validator %d has withdrawal credentials of length %d, wanted %d",
+				i, len(v.WithdrawalCredentials), 32)
+		}
+		if v.ActivationEpoch != params.BeaconConfig().FarFutureEpoch && v.ActivationEligibilityEpoch > v.ActivationEpoch {
+			return errors.Errorf("validator %d has activation eligibility epoch %d after activation epoch %d",
+				i, v.ActivationEligibilityEpoch, v.ActivationEpoch)
+		}
+		if v.ExitEpoch != params.BeaconConfig().FarFutureEpoch && v.ActivationEpoch > v.ExitEpoch {
+			return errors.Errorf("validator %d has activation epoch %d after exit epoch %d",
+				i, v.ActivationEpoch, v.ExitEpoch)
+		}
+		if v.ExitEpoch > v.WithdrawableEpoch {
+			return errors.Errorf("validator %d has exit epoch %d after withdrawable epoch %d",
+				i, v.ExitEpoch, v.WithdrawableEpoch)
+		}
+	}
+	return nil
+}
+
+// PubkeysForIndices returns the public keys of the validators at the given indices, in the same
+// order. It errors if any index is out of range of the validator registry.
+func PubkeysForIndices(state *pb.BeaconState, indices []uint64) ([][]byte, error) {
+	if state == nil {
+		return nil, errors.New("nil state")
+	}
+	pubkeys := make([][]byte, len(indices))
+	for i, idx := range indices {
+		if idx >= uint64(len(state.Validators)) {
+			return nil, errors.Errorf("validator index %d out of range, registry has %d validators", idx, len(state.Validators))
+		}
+		if state.Validators[idx] == nil {
+			return nil, errors.Errorf("validator %d is nil", idx)
+		}
+		pubkeys[i] = state.Validators[idx].PublicKey
+	}
+	return pubkeys, nil
+}
+
+// ValidateProposerIndex checks that the proposer index the block claims to be proposed by is the
+// expected proposer of the block's slot. It is a cheap check to reject spoofed blocks before
+// verifying their signature. Blocks don't carry their proposer index at this spec version, so the
+// claimed index is provided by the caller.
+func ValidateProposerIndex(state *pb.BeaconState, block *ethpb.BeaconBlock, proposerIndex uint64) error {
+	if block == nil {
+		return errors.New("nil block")
+	}
+	expected, err := BeaconProposerIndexAtSlot(state, block.Slot)
+	if err != nil {
+		return errors.Wrapf(err, "could not get proposer index at slot %d", block.Slot)
+	}
+	if proposerIndex != expected {
+		return errors.Errorf("block at slot %d claims proposer index %d, expected proposer index %d",
+			block.Slot, proposerIndex, expected)
+	}
+	return nil
+}
@@ -1,11 +1,17 @@
 package helpers
 
 import (
+	"bytes"
+	"context"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/bls"
 	"github.com/prysmaticlabs/prysm/shared/bytesutil"
 	"github.com/prysmaticlabs/prysm/shared/params"
 )
@@ -115,6 +121,236 @@ func TestIsSlashableValidator_InactiveSlashed(t *testing.T) {
 	}
 }
 
+func TestIsSlashingWithinWindow(t *testing.T) {
+	window := params.BeaconConfig().EpochsPerSlashingsVector
+	currentEpoch := uint64(10000)
+	tests := []struct {
+		name      string
+		validator *ethpb.Validator
+		want      bool
+	}{
+		{
+			name:      "not slashed",
+			validator: &ethpb.Validator{Slashed: false, WithdrawableEpoch: currentEpoch + window},
+			want:      false,
+		},
+		{
+			name:      "slashed this epoch",
+			validator: &ethpb.Validator{Slashed: true, WithdrawableEpoch: currentEpoch + window},
+			want:      true,
+		},
+		{
+			name:      "slashed just inside the window",
+			validator: &ethpb.Validator{Slashed: true, WithdrawableEpoch: currentEpoch + 1},
+			want:      true,
+		},
+		{
+			name:      "withdrawable at current epoch",
+			validator: &ethpb.Validator{Slashed: true, WithdrawableEpoch: currentEpoch},
+			want:      false,
+		},
+		{
+			name:      "withdrawable beyond the window",
+			validator: &ethpb.Validator{Slashed: true, WithdrawableEpoch: currentEpoch + window + 1},
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSlashingWithinWindow(tt.validator, currentEpoch); got != tt.want {
+				t.Errorf("IsSlashingWithinWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorIndicesWithCredentials(t *testing.T) {
+	eth1Credentials := append([]byte{params.BeaconConfig().ETH1AddressWithdrawalPrefixByte}, make([]byte, 31)...)
+	blsCredentials := append([]byte{params.BeaconConfig().BLSWithdrawalPrefixByte}, make([]byte, 31)...)
+	state := &pb.BeaconState{
+		Validators: []*ethpb.Validator{
+			{WithdrawalCredentials: blsCredentials},
+			{WithdrawalCredentials: eth1Credentials},
+			{WithdrawalCredentials: append([]byte{0x02}, make([]byte, 31)...)},
+			{WithdrawalCredentials: eth1Credentials},
+			{},
+			{WithdrawalCredentials: blsCredentials},
+		},
+	}
+	if got, want := ValidatorIndicesWithExecutionCredentials(state), []uint64{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Wanted validators %v with execution credentials, received %v", want, got)
+	}
+	if got, want := ValidatorIndicesWithBLSCredentials(state), []uint64{0, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Wanted validators %v with BLS credentials, received %v", want, got)
+	}
+
+	state.Validators = state.Validators[2:3]
+	if got := ValidatorIndicesWithExecutionCredentials(state); got != nil {
+		t.Errorf("Wanted nil for no validators with execution credentials, received %v", got)
+	}
+	if got := ValidatorIndicesWithBLSCredentials(state); got != nil {
+		t.Errorf("Wanted nil for no validators with BLS credentials, received %v", got)
+	}
+}
+
+func TestMaxEffectiveBalanceForValidator(t *testing.T) {
+	c := params.BeaconConfig()
+	tests := []struct {
+		name        string
+		credentials []byte
+		want        uint64
+	}{
+		{
+			name:        "bls credentials",
+			credentials: append([]byte{c.BLSWithdrawalPrefixByte}, make([]byte, 31)...),
+			want:        c.MaxEffectiveBalance,
+		},
+		{
+			name:        "eth1 credentials",
+			credentials: append([]byte{c.ETH1AddressWithdrawalPrefixByte}, make([]byte, 31)...),
+			want:        c.MaxEffectiveBalance,
+		},
+		{
+			name:        "compounding credentials",
+			credentials: append([]byte{c.CompoundingWithdrawalPrefixByte}, make([]byte, 31)...),
+			want:        c.MaxEffectiveBalanceElectra,
+		},
+		{
+			name: "no credentials",
+			want: c.MaxEffectiveBalance,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &ethpb.Validator{WithdrawalCredentials: tt.credentials}
+			if got := MaxEffectiveBalanceForValidator(v); got != tt.want {
+				t.Errorf("MaxEffectiveBalanceForValidator() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxEffectiveBalanceForValidator_FallsBackToMaxEffectiveBalance(t *testing.T) {
+	defer params.OverrideBeaconConfig(params.BeaconConfig())
+	c := *params.BeaconConfig()
+	c.MaxEffectiveBalanceElectra = 0
+	params.OverrideBeaconConfig(&c)
+
+	v := &ethpb.Validator{WithdrawalCredentials: append([]byte{c.CompoundingWithdrawalPrefixByte}, make([]byte, 31)...)}
+	if got := MaxEffectiveBalanceForValidator(v); got != c.MaxEffectiveBalance {
+		t.Errorf("MaxEffectiveBalanceForValidator() = %d, want %d", got, c.MaxEffectiveBalance)
+	}
+}
+
+func TestIsWithdrawable(t *testing.T) {
+	maxBalance := params.BeaconConfig().MaxEffectiveBalance
+	eth1Credentials := append([]byte{params.BeaconConfig().ETH1AddressWithdrawalPrefixByte}, make([]byte, 31)...)
+	blsCredentials := append([]byte{params.BeaconConfig().BLSWithdrawalPrefixByte}, make([]byte, 31)...)
+	epoch := uint64(100)
+	tests := []struct {
+		name          string
+		validator     *ethpb.Validator
+		balance       uint64
+		wantPartially bool
+		wantFully     bool
+	}{
+		{
+			name:          "excess balance",
+			validator:     &ethpb.Validator{WithdrawalCredentials: eth1Credentials, EffectiveBalance: maxBalance, WithdrawableEpoch: epoch + 1},
+			balance:       maxBalance + 1,
+			wantPartially: true,
+		},
+		{
+			name:      "max balance",
+			validator: &ethpb.Validator{WithdrawalCredentials: eth1Credentials, EffectiveBalance: maxBalance, WithdrawableEpoch: epoch + 1},
+			balance:   maxBalance,
+		},
+		{
+			name:      "excess balance below max effective balance",
+			validator: &ethpb.Validator{WithdrawalCredentials: eth1Credentials, EffectiveBalance: maxBalance - 1e9, WithdrawableEpoch: epoch + 1},
+			balance:   maxBalance + 1,
+		},
+		{
+			name:      "withdrawable",
+			validator: &ethpb.Validator{WithdrawalCredentials: eth1Credentials, EffectiveBalance: maxBalance - 1e9, WithdrawableEpoch: epoch},
+			balance:   maxBalance - 1e9,
+			wantFully: true,
+		},
+		{
+			name:          "withdrawable with excess balance",
+			validator:     &ethpb.Validator{WithdrawalCredentials: eth1Credentials, EffectiveBalance: maxBalance, WithdrawableEpoch: epoch},
+			balance:       maxBalance + 1,
+			wantPartially: true,
+			wantFully:     true,
+		},
+		{
+			name:      "withdrawable with no balance",
+			validator: &ethpb.Validator{WithdrawalCredentials: eth1Credentials, WithdrawableEpoch: epoch},
+			balance:   0,
+		},
+		{
+			name:      "BLS credentials",
+			validator: &ethpb.Validator{WithdrawalCredentials: blsCredentials, EffectiveBalance: maxBalance, WithdrawableEpoch: epoch},
+			balance:   maxBalance + 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPartiallyWithdrawable(tt.validator, tt.balance); got != tt.wantPartially {
+				t.Errorf("IsPartiallyWithdrawable() = %v, want %v", got, tt.wantPartially)
+			}
+			if got := IsFullyWithdrawable(tt.validator, tt.balance, epoch); got != tt.wantFully {
+				t.Errorf("IsFullyWithdrawable() = %v, want %v", got, tt.wantFully)
+			}
+		})
+	}
+}
+
+func TestIsValidVoluntaryExitEpoch(t *testing.T) {
+	period := params.BeaconConfig().PersistentCommitteePeriod
+	farFuture := params.BeaconConfig().FarFutureEpoch
+	currentEpoch := period + 10
+	tests := []struct {
+		name      string
+		validator *ethpb.Validator
+		wantErr   string
+	}{
+		{
+			name:      "valid exit",
+			validator: &ethpb.Validator{ActivationEpoch: 10, ExitEpoch: farFuture},
+		},
+		{
+			name:      "activated too recently",
+			validator: &ethpb.Validator{ActivationEpoch: 11, ExitEpoch: farFuture},
+			wantErr:   "validator has not been active long enough to exit",
+		},
+		{
+			name:      "already exiting",
+			validator: &ethpb.Validator{ActivationEpoch: 0, ExitEpoch: currentEpoch + 1},
+			wantErr:   "validator has already exited",
+		},
+		{
+			name:      "not active",
+			validator: &ethpb.Validator{ActivationEpoch: currentEpoch + 1, ExitEpoch: farFuture},
+			wantErr:   "non-active validator cannot exit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := IsValidVoluntaryExitEpoch(tt.validator, currentEpoch)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("IsValidVoluntaryExitEpoch() error = %v, wanted no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("IsValidVoluntaryExitEpoch() error = %v, wanted %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestBeaconProposerIndex_OK(t *testing.T) {
 	c := params.BeaconConfig()
 	c.MinGenesisActiveValidatorCount = 16384
@@ -175,6 +411,183 @@ func TestBeaconProposerIndex_OK(t *testing.T) {
 	}
 }
 
+func TestBeaconProposerIndexFromActive_MatchesBeaconProposerIndex(t *testing.T) {
+	validators := make([]*ethpb.Validator, 2048)
+	for i := 0; i < len(validators); i++ {
+		validators[i] = &ethpb.Validator{
+			ExitEpoch:        params.BeaconConfig().FarFutureEpoch,
+			EffectiveBalance: params.BeaconConfig().MaxEffectiveBalance,
+		}
+	}
+	state := &pb.BeaconState{
+		Validators:  validators,
+		RandaoMixes: make([][]byte, params.BeaconConfig().EpochsPerHistoricalVector),
+	}
+
+	for _, slot := range []uint64{1, 5, 19, 30, 43} {
+		state.Slot = slot
+		want, err := BeaconProposerIndex(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		activeIndices, err := ActiveValidatorIndices(state, CurrentEpoch(state))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := BeaconProposerIndexFromActive(state, activeIndices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Slot %d: wanted proposer index %d, received %d", slot, want, got)
+		}
+	}
+}
+
+func TestBeaconProposerIndexAtSlot_MatchesBeaconProposerIndex(t *testing.T) {
+	state := proposerIndexTestState()
+	for _, slot := range []uint64{1, 5, 19, 30, 43} {
+		got, err := BeaconProposerIndexAtSlot(state, slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state.Slot = slot
+		want, err := BeaconProposerIndex(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state.Slot = 0
+		if got != want {
+			t.Errorf("Slot %d: wanted proposer index %d, received %d", slot, want, got)
+		}
+	}
+}
+
+func TestProposerIndexWithEpochSeed_MatchesBeaconProposerIndexAtSlot(t *testing.T) {
+	state := proposerIndexTestState()
+	epoch := uint64(1)
+	seed, err := EpochProposerSeed(state, epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indices, err := ActiveValidatorIndices(state, epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := StartSlot(epoch)
+	for slot := start; slot < start+params.BeaconConfig().SlotsPerEpoch; slot++ {
+		want, err := BeaconProposerIndexAtSlot(state, slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ProposerIndexWithEpochSeed(state, slot, indices, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Slot %d: wanted proposer index %d, received %d", slot, want, got)
+		}
+	}
+}
+
+func BenchmarkBeaconProposerIndexAtSlot_FullEpoch(b *testing.B) {
+	state := proposerIndexTestState()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for slot := uint64(0); slot < params.BeaconConfig().SlotsPerEpoch; slot++ {
+			if _, err := BeaconProposerIndexAtSlot(state, slot); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkProposerIndexWithEpochSeed_FullEpoch(b *testing.B) {
+	state := proposerIndexTestState()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		seed, err := EpochProposerSeed(state, 0)
+		if err != nil {
+			b.Fatal(err)
+		}
+		indices, err := ActiveValidatorIndices(state, 0)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for slot := uint64(0); slot < params.BeaconConfig().SlotsPerEpoch; slot++ {
+			if _, err := ProposerIndexWithEpochSeed(state, slot, indices, seed); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestValidateProposerIndex(t *testing.T) {
+	state := proposerIndexTestState()
+	block := &ethpb.BeaconBlock{Slot: 19}
+	proposerIndex, err := BeaconProposerIndexAtSlot(state, block.Slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateProposerIndex(state, block, proposerIndex); err != nil {
+		t.Errorf("Expected the expected proposer to be valid, received %v", err)
+	}
+	wrongIndex := (proposerIndex + 1) % uint64(len(state.Validators))
+	if err := ValidateProposerIndex(state, block, wrongIndex); err == nil {
+		t.Error("Expected an error for a block from an unexpected proposer")
+	}
+	if err := ValidateProposerIndex(state, nil, proposerIndex); err == nil {
+		t.Error("Expected an error for a nil block")
+	}
+}
+
+func proposerIndexTestState() *pb.BeaconState {
+	validators := make([]*ethpb.Validator, 2048)
+	for i := 0; i < len(validators); i++ {
+		validators[i] = &ethpb.Validator{
+			ExitEpoch:        params.BeaconConfig().FarFutureEpoch,
+			EffectiveBalance: params.BeaconConfig().MaxEffectiveBalance,
+		}
+	}
+	return &pb.BeaconState{
+		Validators:  validators,
+		RandaoMixes: make([][]byte, params.BeaconConfig().EpochsPerHistoricalVector),
+	}
+}
+
+func TestBeaconProposerIndexWithContext_Cancelled(t *testing.T) {
+	// Without any effective balance no candidate is ever sampled as the proposer, so sampling only
+	// stops once the context is cancelled.
+	validators := make([]*ethpb.Validator, 64)
+	for i := 0; i < len(validators); i++ {
+		validators[i] = &ethpb.Validator{
+			ExitEpoch: params.BeaconConfig().FarFutureEpoch,
+		}
+	}
+	state := &pb.BeaconState{
+		Validators:  validators,
+		Slot:        1,
+		RandaoMixes: make([][]byte, params.BeaconConfig().EpochsPerHistoricalVector),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := BeaconProposerIndexWithContext(ctx, state); errors.Cause(err) != context.DeadlineExceeded {
+		t.Errorf("Wanted context deadline exceeded error, received %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	indices := []uint64{0, 1, 2, 3}
+	if _, err := ComputeProposerIndexWithContext(ctx, validators, indices, [32]byte{}); err != context.DeadlineExceeded {
+		t.Errorf("Wanted context deadline exceeded error, received %v", err)
+	}
+	// The active validator indices are checked for cancellation before iterating over validators.
+	if _, err := ActiveValidatorIndicesWithContext(ctx, state, 0); errors.Cause(err) != context.DeadlineExceeded {
+		t.Errorf("Wanted context deadline exceeded error, received %v", err)
+	}
+}
+
 func TestDelayedActivationExitEpoch_OK(t *testing.T) {
 	epoch := uint64(9999)
 	got := DelayedActivationExitEpoch(epoch)
@@ -184,6 +597,22 @@ func TestDelayedActivationExitEpoch_OK(t *testing.T) {
 	}
 }
 
+func TestValidatorWithdrawableEpoch_OK(t *testing.T) {
+	v := &ethpb.Validator{WithdrawableEpoch: 1234}
+	if got := ValidatorWithdrawableEpoch(v); got != 1234 {
+		t.Errorf("Wanted: %d, received: %d", 1234, got)
+	}
+}
+
+func TestEstimateWithdrawableEpochOnExit_OK(t *testing.T) {
+	exitEpoch := uint64(9999)
+	got := EstimateWithdrawableEpochOnExit(exitEpoch)
+	wanted := exitEpoch + params.BeaconConfig().MinValidatorWithdrawabilityDelay
+	if wanted != got {
+		t.Errorf("Wanted: %d, received: %d", wanted, got)
+	}
+}
+
 func TestChurnLimit_OK(t *testing.T) {
 	tests := []struct {
 		validatorCount int
@@ -222,6 +651,28 @@ func TestChurnLimit_OK(t *testing.T) {
 	}
 }
 
+func TestAggregatorModulo(t *testing.T) {
+	target := params.BeaconConfig().TargetAggregatorsPerCommittee
+	tests := []struct {
+		committeeSize uint64
+		want          uint64
+	}{
+		{committeeSize: 0, want: 1},
+		{committeeSize: 1, want: 1},
+		{committeeSize: target - 1, want: 1},
+		{committeeSize: target, want: 1},
+		{committeeSize: 2*target - 1, want: 1},
+		{committeeSize: 2 * target, want: 2},
+		{committeeSize: 128, want: 128 / target},
+		{committeeSize: 2048, want: 2048 / target},
+	}
+	for _, tt := range tests {
+		if got := AggregatorModulo(tt.committeeSize); got != tt.want {
+			t.Errorf("AggregatorModulo(%d) = %d, want %d", tt.committeeSize, got, tt.want)
+		}
+	}
+}
+
 func TestDomain_OK(t *testing.T) {
 	state := &pb.BeaconState{
 		Fork: &pb.Fork{
@@ -248,6 +699,94 @@ func TestDomain_OK(t *testing.T) {
 	}
 }
 
+func TestForkVersionAtEpoch(t *testing.T) {
+	schedule := []*pb.Fork{
+		{Epoch: 0, PreviousVersion: []byte{0, 0, 0, 0}, CurrentVersion: []byte{0, 0, 0, 0}},
+		{Epoch: 10, PreviousVersion: []byte{0, 0, 0, 0}, CurrentVersion: []byte{1, 0, 0, 0}},
+		{Epoch: 20, PreviousVersion: []byte{1, 0, 0, 0}, CurrentVersion: []byte{2, 0, 0, 0}},
+	}
+	tests := []struct {
+		epoch   uint64
+		version []byte
+	}{
+		{epoch: 0, version: []byte{0, 0, 0, 0}},
+		{epoch: 5, version: []byte{0, 0, 0, 0}},
+		{epoch: 9, version: []byte{0, 0, 0, 0}},
+		{epoch: 10, version: []byte{1, 0, 0, 0}},
+		{epoch: 15, version: []byte{1, 0, 0, 0}},
+		{epoch: 19, version: []byte{1, 0, 0, 0}},
+		{epoch: 20, version: []byte{2, 0, 0, 0}},
+		{epoch: 1000, version: []byte{2, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		version, err := ForkVersionAtEpoch(schedule, tt.epoch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(version, tt.version) {
+			t.Errorf("Epoch %d: wanted fork version %#x, received %#x", tt.epoch, tt.version, version)
+		}
+	}
+}
+
+func TestForkVersionAtEpoch_BeforeFirstFork(t *testing.T) {
+	schedule := []*pb.Fork{
+		{Epoch: 5, PreviousVersion: []byte{0, 0, 0, 1}, CurrentVersion: []byte{0, 0, 0, 2}},
+	}
+	version, err := ForkVersionAtEpoch(schedule, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(version, []byte{0, 0, 0, 1}) {
+		t.Errorf("Wanted the previous version of the first fork, received %#x", version)
+	}
+}
+
+func TestForkVersionAtEpoch_InvalidSchedule(t *testing.T) {
+	if _, err := ForkVersionAtEpoch(nil, 0); err == nil {
+		t.Error("Expected an error for an empty fork schedule")
+	}
+	unordered := []*pb.Fork{
+		{Epoch: 10, CurrentVersion: []byte{1, 0, 0, 0}},
+		{Epoch: 5, CurrentVersion: []byte{2, 0, 0, 0}},
+	}
+	if _, err := ForkVersionAtEpoch(unordered, 7); err == nil {
+		t.Error("Expected an error for a fork schedule not ordered by epoch")
+	}
+	if _, err := ForkVersionAtEpoch([]*pb.Fork{nil}, 0); err == nil {
+		t.Error("Expected an error for a nil fork")
+	}
+}
+
+func TestOperationDomains(t *testing.T) {
+	fork := &pb.Fork{
+		Epoch:           3,
+		PreviousVersion: []byte{0, 0, 0, 2},
+		CurrentVersion:  []byte{0, 0, 0, 3},
+	}
+	tests := []struct {
+		name       string
+		domain     func(fork *pb.Fork, epoch uint64) uint64
+		domainType []byte
+	}{
+		{name: "Voluntary exit", domain: VoluntaryExitDomain, domainType: params.BeaconConfig().DomainVoluntaryExit},
+		{name: "Proposer slashing", domain: ProposerSlashingDomain, domainType: params.BeaconConfig().DomainBeaconProposer},
+		{name: "Attester slashing", domain: AttesterSlashingDomain, domainType: params.BeaconConfig().DomainBeaconAttester},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, epoch := range []uint64{2, 3} {
+				if got, want := tt.domain(fork, epoch), Domain(fork, epoch, tt.domainType); got != want {
+					t.Errorf("Epoch %d: wanted domain %d, got %d", epoch, want, got)
+				}
+			}
+		})
+	}
+	if VoluntaryExitDomain(fork, 3) == ProposerSlashingDomain(fork, 3) || ProposerSlashingDomain(fork, 3) == AttesterSlashingDomain(fork, 3) {
+		t.Error("Expected the operation domains to differ")
+	}
+}
+
 // Test basic functionality of ActiveValidatorIndices without caching. This test will need to be
 // rewritten when releasing some cache flag.
 func TestActiveValidatorIndices(t *testing.T) {
@@ -404,6 +943,77 @@ func TestActiveValidatorIndices(t *testing.T) {
 	}
 }
 
+func TestVerifyBlockSignatureDomain(t *testing.T) {
+	fork := &pb.Fork{
+		Epoch:           3,
+		PreviousVersion: []byte{0, 0, 0, 2},
+		CurrentVersion:  []byte{0, 0, 0, 3},
+	}
+	domainType := params.BeaconConfig().DomainBeaconProposer
+	previousDomain := bls.Domain(domainType, fork.PreviousVersion)
+	currentDomain := bls.Domain(domainType, fork.CurrentVersion)
+	tests := []struct {
+		name           string
+		messageEpoch   uint64
+		expectedDomain uint64
+		wantErr        bool
+	}{
+		{name: "Before fork with previous version", messageEpoch: 2, expectedDomain: previousDomain},
+		{name: "Before fork with current version", messageEpoch: 2, expectedDomain: currentDomain, wantErr: true},
+		{name: "At fork with current version", messageEpoch: 3, expectedDomain: currentDomain},
+		{name: "At fork with previous version", messageEpoch: 3, expectedDomain: previousDomain, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyBlockSignatureDomain(fork, tt.messageEpoch, domainType, tt.expectedDomain); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyBlockSignatureDomain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestActiveIndicesDelta(t *testing.T) {
+	farFuture := params.BeaconConfig().FarFutureEpoch
+	state := &pb.BeaconState{
+		Validators: []*ethpb.Validator{
+			{ActivationEpoch: 0, ExitEpoch: farFuture}, // active at both epochs
+			{ActivationEpoch: 3, ExitEpoch: farFuture}, // activates between the epochs
+			{ActivationEpoch: 0, ExitEpoch: 4},         // exits between the epochs
+			{ActivationEpoch: 6, ExitEpoch: farFuture}, // not yet active at either epoch
+			{ActivationEpoch: 0, ExitEpoch: 1},         // exited before both epochs
+			{ActivationEpoch: 5, ExitEpoch: farFuture}, // activates at epoch B
+		},
+		RandaoMixes: make([][]byte, params.BeaconConfig().EpochsPerHistoricalVector),
+	}
+
+	activated, deactivated, err := ActiveIndicesDelta(state, 2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(activated, []uint64{1, 5}) {
+		t.Errorf("Wanted activated indices %v, received %v", []uint64{1, 5}, activated)
+	}
+	if !reflect.DeepEqual(deactivated, []uint64{2}) {
+		t.Errorf("Wanted deactivated indices %v, received %v", []uint64{2}, deactivated)
+	}
+
+	activated, deactivated, err = ActiveIndicesDelta(state, 5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(activated, []uint64{2}) || !reflect.DeepEqual(deactivated, []uint64{1, 5}) {
+		t.Errorf("Expected swapping the epochs to swap the delta, received activated %v and deactivated %v", activated, deactivated)
+	}
+
+	activated, deactivated, err = ActiveIndicesDelta(state, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activated) != 0 || len(deactivated) != 0 {
+		t.Errorf("Expected no delta for the same epoch, received activated %v and deactivated %v", activated, deactivated)
+	}
+}
+
 func TestComputeProposerIndex(t *testing.T) {
 	seed := bytesutil.ToBytes32([]byte("seed"))
 	type args struct {
@@ -552,6 +1162,54 @@ func TestIsEligibleForActivationQueue(t *testing.T) {
 	}
 }
 
+func TestIsEligibleForActivationQueue_MinActivationBalance(t *testing.T) {
+	defer params.OverrideBeaconConfig(params.BeaconConfig())
+	c := *params.BeaconConfig()
+	c.MaxEffectiveBalance = 2048 * 1e9
+	c.MinActivationBalance = 32 * 1e9
+	params.OverrideBeaconConfig(&c)
+
+	tests := []struct {
+		name             string
+		effectiveBalance uint64
+		want             bool
+	}{
+		{"Below minimum", 31 * 1e9, false},
+		{"Exactly minimum", 32 * 1e9, true},
+		{"Above minimum", 64 * 1e9, true},
+		{"Max effective balance", 2048 * 1e9, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &ethpb.Validator{
+				ActivationEligibilityEpoch: params.BeaconConfig().FarFutureEpoch,
+				EffectiveBalance:           tt.effectiveBalance,
+			}
+			if got := IsEligibleForActivationQueue(v); got != tt.want {
+				t.Errorf("IsEligibleForActivationQueue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinActivationBalance_FallsBackToMaxEffectiveBalance(t *testing.T) {
+	defer params.OverrideBeaconConfig(params.BeaconConfig())
+	c := *params.BeaconConfig()
+	c.MinActivationBalance = 0
+	params.OverrideBeaconConfig(&c)
+
+	if MinActivationBalance() != params.BeaconConfig().MaxEffectiveBalance {
+		t.Errorf("Wanted %d, received %d", params.BeaconConfig().MaxEffectiveBalance, MinActivationBalance())
+	}
+	v := &ethpb.Validator{
+		ActivationEligibilityEpoch: params.BeaconConfig().FarFutureEpoch,
+		EffectiveBalance:           params.BeaconConfig().MaxEffectiveBalance - params.BeaconConfig().EffectiveBalanceIncrement,
+	}
+	if IsEligibleForActivationQueue(v) {
+		t.Error("Expected validator below the max effective balance to be ineligible on a legacy chain")
+	}
+}
+
 func TestIsIsEligibleForActivation(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -580,3 +1238,316 @@ func TestIsIsEligibleForActivation(t *testing.T) {
 		})
 	}
 }
+
+func TestShouldEject(t *testing.T) {
+	farFutureEpoch := params.BeaconConfig().FarFutureEpoch
+	ejectionBalance := params.BeaconConfig().EjectionBalance
+	currentEpoch := uint64(5)
+	tests := []struct {
+		name      string
+		validator *ethpb.Validator
+		want      bool
+	}{
+		{"At ejection balance",
+			&ethpb.Validator{EffectiveBalance: ejectionBalance, ExitEpoch: farFutureEpoch},
+			true},
+		{"Below ejection balance",
+			&ethpb.Validator{EffectiveBalance: ejectionBalance - params.BeaconConfig().EffectiveBalanceIncrement, ExitEpoch: farFutureEpoch},
+			true},
+		{"Above ejection balance",
+			&ethpb.Validator{EffectiveBalance: ejectionBalance + params.BeaconConfig().EffectiveBalanceIncrement, ExitEpoch: farFutureEpoch},
+			false},
+		{"Not yet activated",
+			&ethpb.Validator{EffectiveBalance: ejectionBalance, ActivationEpoch: farFutureEpoch, ExitEpoch: farFutureEpoch},
+			false},
+		{"Pending activation",
+			&ethpb.Validator{EffectiveBalance: ejectionBalance, ActivationEpoch: currentEpoch + 1, ExitEpoch: farFutureEpoch},
+			false},
+		{"Exiting but still active",
+			&ethpb.Validator{EffectiveBalance: ejectionBalance, ExitEpoch: currentEpoch + 1},
+			true},
+		{"Already exited",
+			&ethpb.Validator{EffectiveBalance: ejectionBalance, ExitEpoch: currentEpoch},
+			false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShouldEject(tt.validator, currentEpoch); got != tt.want {
+				t.Errorf("ShouldEject() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateValidatorRegistry(t *testing.T) {
+	healthyValidator := func() *ethpb.Validator {
+		return &ethpb.Validator{
+			PublicKey:                  make([]byte, params.BeaconConfig().BLSPubkeyLength),
+			WithdrawalCredentials:      make([]byte, 32),
+			ActivationEligibilityEpoch: 1,
+			ActivationEpoch:            2,
+			ExitEpoch:                  params.BeaconConfig().FarFutureEpoch,
+			WithdrawableEpoch:          params.BeaconConfig().FarFutureEpoch,
+		}
+	}
+	wrongCredentials := healthyValidator()
+	wrongCredentials.WithdrawalCredentials = []byte{'a'}
+	wrongPubkey := healthyValidator()
+	wrongPubkey.PublicKey = []byte{'a'}
+	activatedBeforeEligible := healthyValidator()
+	activatedBeforeEligible.ActivationEligibilityEpoch = 3
+	exitedBeforeActivated := healthyValidator()
+	exitedBeforeActivated.ExitEpoch = 1
+	withdrawableBeforeExit := healthyValidator()
+	withdrawableBeforeExit.ExitEpoch = 10
+	withdrawableBeforeExit.WithdrawableEpoch = 5
+	pendingActivation := healthyValidator()
+	pendingActivation.ActivationEpoch = params.BeaconConfig().FarFutureEpoch
+	exited := healthyValidator()
+	exited.ExitEpoch = 10
+	exited.WithdrawableEpoch = 10 + params.BeaconConfig().MinValidatorWithdrawabilityDelay
+
+	tests := []struct {
+		name       string
+		validators []*ethpb.Validator
+		wantErr    bool
+	}{
+		{"Healthy", []*ethpb.Validator{healthyValidator(), pendingActivation, exited}, false},
+		{"Empty registry", []*ethpb.Validator{}, false},
+		{"Nil validator", []*ethpb.Validator{healthyValidator(), nil}, true},
+		{"Wrong length credentials", []*ethpb.Validator{wrongCredentials}, true},
+		{"Wrong length public key", []*ethpb.Validator{wrongPubkey}, true},
+		{"Activated before eligible", []*ethpb.Validator{activatedBeforeEligible}, true},
+		{"Exited before activated", []*ethpb.Validator{exitedBeforeActivated}, true},
+		{"Withdrawable before exit", []*ethpb.Validator{withdrawableBeforeExit}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &pb.BeaconState{Validators: tt.validators}
+			if err := ValidateValidatorRegistry(state); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateValidatorRegistry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueueDepths(t *testing.T) {
+	churnLimit := params.BeaconConfig().MinPerEpochChurnLimit
+	tests := []struct {
+		name        string
+		pending     int
+		exiting     int
+		entryEpochs uint64
+		exitEpochs  uint64
+	}{
+		{name: "Empty queues", pending: 0, exiting: 0, entryEpochs: 0, exitEpochs: 0},
+		{name: "Less than churn", pending: 1, exiting: 2, entryEpochs: 1, exitEpochs: 1},
+		{name: "Exactly churn", pending: int(churnLimit), exiting: int(churnLimit), entryEpochs: 1, exitEpochs: 1},
+		{name: "More than churn", pending: int(churnLimit) + 1, exiting: 3*int(churnLimit) + 1, entryEpochs: 2, exitEpochs: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validators []*ethpb.Validator
+			for i := 0; i < 100; i++ {
+				validators = append(validators, &ethpb.Validator{
+					ActivationEpoch: 0,
+					ExitEpoch:       params.BeaconConfig().FarFutureEpoch,
+				})
+			}
+			for i := 0; i < tt.pending; i++ {
+				validators = append(validators, &ethpb.Validator{
+					ActivationEligibilityEpoch: 1,
+					ActivationEpoch:            params.BeaconConfig().FarFutureEpoch,
+					ExitEpoch:                  params.BeaconConfig().FarFutureEpoch,
+				})
+			}
+			for i := 0; i < tt.exiting; i++ {
+				validators = append(validators, &ethpb.Validator{
+					ActivationEpoch: 0,
+					ExitEpoch:       10,
+				})
+			}
+			state := &pb.BeaconState{
+				Slot:       params.BeaconConfig().SlotsPerEpoch * 2,
+				Validators: validators,
+			}
+			entryQueue, exitQueue, entryEpochs, exitEpochs, err := QueueDepths(state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entryQueue != uint64(tt.pending) {
+				t.Errorf("Wanted entry queue %d, got %d", tt.pending, entryQueue)
+			}
+			if exitQueue != uint64(tt.exiting) {
+				t.Errorf("Wanted exit queue %d, got %d", tt.exiting, exitQueue)
+			}
+			if entryEpochs != tt.entryEpochs {
+				t.Errorf("Wanted entry epochs %d, got %d", tt.entryEpochs, entryEpochs)
+			}
+			if exitEpochs != tt.exitEpochs {
+				t.Errorf("Wanted exit epochs %d, got %d", tt.exitEpochs, exitEpochs)
+			}
+		})
+	}
+}
+
+func TestMaxSlashableValidatorsPerEpoch(t *testing.T) {
+	defer params.OverrideBeaconConfig(params.BeaconConfig())
+	c := *params.BeaconConfig()
+	c.ChurnLimitQuotient = 16
+	params.OverrideBeaconConfig(&c)
+
+	for _, activeCount := range []uint64{10, 64, 160, 1000} {
+		var validators []*ethpb.Validator
+		for i := uint64(0); i < activeCount; i++ {
+			validators = append(validators, &ethpb.Validator{ExitEpoch: params.BeaconConfig().FarFutureEpoch})
+		}
+		// Exited validators don't count towards the bound.
+		validators = append(validators, &ethpb.Validator{ExitEpoch: 0})
+		state := &pb.BeaconState{Validators: validators}
+
+		want, err := ValidatorChurnLimit(activeCount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MaxSlashableValidatorsPerEpoch(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%d active validators: wanted %d slashable validators per epoch, received %d", activeCount, want, got)
+		}
+	}
+}
+
+func TestEpochsUntilActivation(t *testing.T) {
+	churnLimit := params.BeaconConfig().MinPerEpochChurnLimit
+	farFuture := params.BeaconConfig().FarFutureEpoch
+	var validators []*ethpb.Validator
+	for i := 0; i < 100; i++ {
+		validators = append(validators, &ethpb.Validator{
+			ActivationEpoch: 0,
+			ExitEpoch:       farFuture,
+		})
+	}
+	// A large queue of validators eligible at epoch 1, followed by one eligible earlier, which is
+	// ahead of all of them.
+	queueStart := uint64(len(validators))
+	for i := uint64(0); i < 5*churnLimit; i++ {
+		validators = append(validators, &ethpb.Validator{
+			ActivationEligibilityEpoch: 1,
+			ActivationEpoch:            farFuture,
+			ExitEpoch:                  farFuture,
+		})
+	}
+	validators = append(validators, &ethpb.Validator{
+		ActivationEligibilityEpoch: 0,
+		ActivationEpoch:            farFuture,
+		ExitEpoch:                  farFuture,
+	})
+	// A validator already scheduled for activation.
+	validators = append(validators, &ethpb.Validator{
+		ActivationEligibilityEpoch: 0,
+		ActivationEpoch:            6,
+		ExitEpoch:                  farFuture,
+	})
+	state := &pb.BeaconState{
+		Slot:       params.BeaconConfig().SlotsPerEpoch * 2,
+		Validators: validators,
+	}
+	activationDelay := 1 + params.BeaconConfig().MaxSeedLookahead
+
+	// The validator halfway through the queue is behind 2.5 epochs of churn and the validator
+	// eligible earlier.
+	epochs, err := EpochsUntilActivation(state, queueStart+5*churnLimit/2-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2 + activationDelay; epochs != want {
+		t.Errorf("Wanted activation in %d epochs, got %d", want, epochs)
+	}
+	epochs, err = EpochsUntilActivation(state, uint64(len(validators)-2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epochs != activationDelay {
+		t.Errorf("Wanted the head of the queue activated in %d epochs, got %d", activationDelay, epochs)
+	}
+	epochs, err = EpochsUntilActivation(state, uint64(len(validators)-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epochs != 4 {
+		t.Errorf("Wanted the scheduled validator activated in 4 epochs, got %d", epochs)
+	}
+	if _, err := EpochsUntilActivation(state, 0); err == nil {
+		t.Error("Expected error for an active validator")
+	}
+	if _, err := EpochsUntilActivation(state, uint64(len(validators))); err == nil {
+		t.Error("Expected error for an out of range validator index")
+	}
+}
+
+func TestPubkeysForIndices(t *testing.T) {
+	validators := make([]*ethpb.Validator, 10)
+	for i := range validators {
+		validators[i] = &ethpb.Validator{PublicKey: []byte{byte(i)}}
+	}
+	state := &pb.BeaconState{Validators: validators}
+
+	tests := []struct {
+		name    string
+		indices []uint64
+		want    [][]byte
+		wantErr bool
+	}{
+		{name: "contiguous range", indices: []uint64{3, 4, 5}, want: [][]byte{{3}, {4}, {5}}},
+		{name: "keeps order", indices: []uint64{9, 0, 9}, want: [][]byte{{9}, {0}, {9}}},
+		{name: "no indices", indices: []uint64{}, want: [][]byte{}},
+		{name: "index at registry length", indices: []uint64{1, 10}, wantErr: true},
+		{name: "index far out of range", indices: []uint64{1 << 40, 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PubkeysForIndices(state, tt.indices)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PubkeysForIndices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PubkeysForIndices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncCommitteePeriod(t *testing.T) {
+	epochsPerPeriod := params.BeaconConfig().EpochsPerSyncCommitteePeriod
+	tests := []struct {
+		epoch  uint64
+		period uint64
+	}{
+		{epoch: 0, period: 0},
+		{epoch: epochsPerPeriod - 1, period: 0},
+		{epoch: epochsPerPeriod, period: 1},
+		{epoch: 2*epochsPerPeriod - 1, period: 1},
+		{epoch: 2 * epochsPerPeriod, period: 2},
+	}
+	for _, tt := range tests {
+		if got := SyncCommitteePeriod(tt.epoch); got != tt.period {
+			t.Errorf("SyncCommitteePeriod(%d) = %d, want %d", tt.epoch, got, tt.period)
+		}
+	}
+}
+
+func TestIsInSyncCommitteePeriod(t *testing.T) {
+	committee := [][]byte{{'A'}, {'B'}, {'C'}}
+	if !IsInSyncCommitteePeriod(committee, []byte{'B'}) {
+		t.Error("Expected a committee member to be in the sync committee")
+	}
+	if IsInSyncCommitteePeriod(committee, []byte{'D'}) {
+		t.Error("Expected a validator outside of the committee not to be in the sync committee")
+	}
+	if IsInSyncCommitteePeriod(nil, []byte{'A'}) {
+		t.Error("Expected no validator to be in an empty sync committee")
+	}
+}
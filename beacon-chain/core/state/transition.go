@@ -577,7 +577,7 @@ func verifyOperationLengths(state *pb.BeaconState, body *ethpb.BeaconBlockBody)
 // Spec pseudocode definition:
 //    If (state.slot + 1) % SLOTS_PER_EPOCH == 0:
 func CanProcessEpoch(state *pb.BeaconState) bool {
-	return (state.Slot+1)%params.BeaconConfig().SlotsPerEpoch == 0
+	return helpers.IsEpochEnd(state.Slot)
 }
 
 // ProcessEpochPrecompute describes the per epoch operations that are performed on the beacon state.
@@ -77,7 +77,7 @@ func InitiateValidatorExit(state *pb.BeaconState, idx uint64) (*pb.BeaconState,
 		exitQueueEpoch++
 	}
 	state.Validators[idx].ExitEpoch = exitQueueEpoch
-	state.Validators[idx].WithdrawableEpoch = exitQueueEpoch + params.BeaconConfig().MinValidatorWithdrawabilityDelay
+	state.Validators[idx].WithdrawableEpoch = helpers.EstimateWithdrawableEpochOnExit(exitQueueEpoch)
 	return state, nil
 }
 
@@ -191,7 +191,7 @@ func ExitedValidatorIndices(epoch uint64, validators []*ethpb.Validator, activeV
 	if churn < uint64(exitQueueChurn) {
 		exitQueueEpoch++
 	}
-	withdrawableEpoch := exitQueueEpoch + params.BeaconConfig().MinValidatorWithdrawabilityDelay
+	withdrawableEpoch := helpers.EstimateWithdrawableEpochOnExit(exitQueueEpoch)
 	for i, val := range validators {
 		if val.ExitEpoch == epoch && val.WithdrawableEpoch == withdrawableEpoch {
 			exited = append(exited, uint64(i))
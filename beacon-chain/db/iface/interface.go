@@ -91,4 +91,7 @@ type Database interface {
 	//Powchain operations
 	PowchainData(ctx context.Context) (*db.ETH1ChainData, error)
 	SavePowchainData(ctx context.Context, data *db.ETH1ChainData) error
+	// Initial sync progress operations.
+	InitialSyncProgress(ctx context.Context) ([]byte, error)
+	SaveInitialSyncProgress(ctx context.Context, progress []byte) error
 }
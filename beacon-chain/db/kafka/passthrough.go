@@ -305,3 +305,13 @@ func (e Exporter) PowchainData(ctx context.Context) (*db.ETH1ChainData, error) {
 func (e Exporter) SavePowchainData(ctx context.Context, data *db.ETH1ChainData) error {
 	return e.db.SavePowchainData(ctx, data)
 }
+
+// InitialSyncProgress -- passthrough.
+func (e Exporter) InitialSyncProgress(ctx context.Context) ([]byte, error) {
+	return e.db.InitialSyncProgress(ctx)
+}
+
+// SaveInitialSyncProgress -- passthrough.
+func (e Exporter) SaveInitialSyncProgress(ctx context.Context, progress []byte) error {
+	return e.db.SaveInitialSyncProgress(ctx, progress)
+}
@@ -149,6 +149,45 @@ func TestStore_BlocksBatchDelete(t *testing.T) {
 	}
 }
 
+func TestStore_SaveBlocks_SkipsExistingBlocks(t *testing.T) {
+	db := setupDB(t)
+	defer teardownDB(t, db)
+	ctx := context.Background()
+	blocks := make([]*ethpb.SignedBeaconBlock, 4)
+	for i := range blocks {
+		blocks[i] = &ethpb.SignedBeaconBlock{
+			Block: &ethpb.BeaconBlock{
+				Slot:       uint64(i + 1),
+				ParentRoot: []byte("parent"),
+			},
+		}
+	}
+	// The first block of the batch is already known, which must not stop the
+	// later blocks from being saved.
+	if err := db.SaveBlock(ctx, blocks[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveBlocks(ctx, blocks); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range blocks {
+		root, err := ssz.HashTreeRoot(b.Block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !db.HasBlock(ctx, root) {
+			t.Errorf("Expected block at slot %d to be saved", b.Block.Slot)
+		}
+	}
+	retrieved, err := db.Blocks(ctx, filters.NewFilter().SetParentRoot([]byte("parent")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retrieved) != len(blocks) {
+		t.Errorf("Received %d blocks, wanted %d", len(retrieved), len(blocks))
+	}
+}
+
 func TestStore_GenesisBlock(t *testing.T) {
 	db := setupDB(t)
 	defer teardownDB(t, db)
@@ -39,6 +39,7 @@ var (
 	justifiedCheckpointKey    = []byte("justified-checkpoint")
 	finalizedCheckpointKey    = []byte("finalized-checkpoint")
 	powchainDataKey           = []byte("powchain-data")
+	initialSyncProgressKey    = []byte("initial-sync-progress")
 
 	// Migration bucket.
 	migrationBucket = []byte("migrations")
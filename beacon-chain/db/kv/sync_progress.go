@@ -0,0 +1,38 @@
+package kv
+
+import (
+	"context"
+
+	"github.com/boltdb/bolt"
+	"go.opencensus.io/trace"
+)
+
+// InitialSyncProgress returns the encoded progress marker last saved by initial sync, or nil if
+// none was saved.
+func (k *Store) InitialSyncProgress(ctx context.Context) ([]byte, error) {
+	ctx, span := trace.StartSpan(ctx, "BeaconDB.InitialSyncProgress")
+	defer span.End()
+	var progress []byte
+	err := k.db.View(func(tx *bolt.Tx) error {
+		chainInfo := tx.Bucket(chainMetadataBucket)
+		enc := chainInfo.Get(initialSyncProgressKey)
+		if enc != nil {
+			progress = make([]byte, len(enc))
+			copy(progress, enc)
+		}
+		return nil
+	})
+	return progress, err
+}
+
+// SaveInitialSyncProgress saves the encoded progress marker of initial sync, replacing any
+// previously saved marker.
+func (k *Store) SaveInitialSyncProgress(ctx context.Context, progress []byte) error {
+	ctx, span := trace.StartSpan(ctx, "BeaconDB.SaveInitialSyncProgress")
+	defer span.End()
+
+	return k.db.Update(func(tx *bolt.Tx) error {
+		chainInfo := tx.Bucket(chainMetadataBucket)
+		return chainInfo.Put(initialSyncProgressKey, progress)
+	})
+}
@@ -0,0 +1,34 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestStore_InitialSyncProgress_CanSaveRetrieve(t *testing.T) {
+	db := setupDB(t)
+	defer teardownDB(t, db)
+	ctx := context.Background()
+
+	progress, err := db.InitialSyncProgress(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress != nil {
+		t.Errorf("Wanted no progress before it is saved, received %#x", progress)
+	}
+
+	for _, want := range [][]byte{[]byte("first"), []byte("second")} {
+		if err := db.SaveInitialSyncProgress(ctx, want); err != nil {
+			t.Fatal(err)
+		}
+		progress, err = db.InitialSyncProgress(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(progress, want) {
+			t.Errorf("Wanted %#x, received %#x", want, progress)
+		}
+	}
+}
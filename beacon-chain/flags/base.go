@@ -56,6 +56,148 @@ var (
 		Usage: "The required number of valid peers to connect with before syncing.",
 		Value: 3,
 	}
+	// InitSyncBatchSaveBlocks specifies the number of blocks processed during initial sync that are
+	// accumulated and saved to the db in a single write.
+	InitSyncBatchSaveBlocks = cli.IntFlag{
+		Name:  "init-sync-batch-save-blocks",
+		Usage: "The number of blocks to accumulate during initial sync before saving them to the db in a single write. Only applies when block contents are not verified during initial sync. Values of 0 or 1 disable batching.",
+		Value: 0,
+	}
+	// DisablePeerShuffleFlag disables the shuffling of peers during initial sync so that peers are
+	// always queried in a deterministic order. This reduces the resilience of sync against bad
+	// peers and is meant for debugging only.
+	DisablePeerShuffleFlag = cli.BoolFlag{
+		Name:  "sync-disable-peer-shuffle",
+		Usage: "Debugging only. Disables the shuffling of peers during initial sync to keep a deterministic peer order. This makes sync less resilient to bad peers.",
+	}
+	// MaxBlocksPerPeerFlag specifies the maximum number of blocks buffered from a single peer
+	// response during initial sync.
+	MaxBlocksPerPeerFlag = cli.IntFlag{
+		Name:  "sync-max-blocks-per-peer",
+		Usage: "The maximum number of blocks to buffer from a single peer response during initial sync. Further blocks sent by the peer are not read. A value of 0 disables the limit.",
+		Value: 1024,
+	}
+	// EnablePreferReliablePeersFlag biases the order in which peers are queried during initial sync
+	// towards peers that previously served blocks reliably and quickly.
+	EnablePreferReliablePeersFlag = cli.BoolFlag{
+		Name:  "sync-prefer-reliable-peers",
+		Usage: "Prefer peers that previously served blocks reliably and quickly when requesting blocks during initial sync.",
+	}
+	// EnableBatchPrefetchFlag enables requesting the next batch of blocks from peers while the
+	// current batch is being processed during initial sync.
+	EnableBatchPrefetchFlag = cli.BoolFlag{
+		Name:  "sync-prefetch-next-batch",
+		Usage: "Request the next batch of blocks from peers while the current batch is being processed during initial sync, overlapping network and processing time.",
+	}
+	// VerifyFinalizedRootFlag enables verifying that the finalized checkpoint block advertised by
+	// peers is in the db once initial sync reaches the finalized epoch.
+	VerifyFinalizedRootFlag = cli.BoolFlag{
+		Name:  "sync-verify-finalized-root",
+		Usage: "Verify that the finalized block root advertised by peers was synced once initial sync reaches the finalized epoch, failing sync otherwise.",
+	}
+	// MaxFailoverDepthFlag specifies how many times a failed block request may be split across the
+	// remaining peers during initial sync.
+	MaxFailoverDepthFlag = cli.IntFlag{
+		Name:  "sync-max-failover-depth",
+		Usage: "The maximum number of times a failed block request is split across the remaining peers during initial sync before giving up. A value of 0 allows splitting until no peers are left.",
+		Value: 0,
+	}
+	// HeadSyncSlotToleranceFlag specifies how many slots behind the current slot the node may be to
+	// consider initial sync to the chain head complete.
+	HeadSyncSlotToleranceFlag = cli.Uint64Flag{
+		Name:  "sync-head-slot-tolerance",
+		Usage: "The number of slots behind the current slot at which initial sync is considered synced to the chain head and hands off to regular sync.",
+		Value: 2,
+	}
+	// SyncFinalityOnlyFlag stops initial sync once the node is synced to the finalized epoch.
+	SyncFinalityOnlyFlag = cli.BoolFlag{
+		Name:  "sync-finality-only",
+		Usage: "Only sync up to the finalized epoch, without syncing to the current chain head. For nodes serving finalized data only.",
+	}
+	// SyncCheckpointIntervalFlag specifies how often initial sync saves its progress to the db.
+	SyncCheckpointIntervalFlag = cli.IntFlag{
+		Name:  "sync-checkpoint-interval",
+		Usage: "The number of block batches after which initial sync saves its progress to the db, so that it resumes from there after a restart. A value of 0 disables saving progress.",
+		Value: 1,
+	}
+	// SyncMaxBufferedBlocksFlag specifies the maximum number of blocks initial sync holds in memory at once.
+	SyncMaxBufferedBlocksFlag = cli.IntFlag{
+		Name:  "sync-max-buffered-blocks",
+		Usage: "The maximum number of blocks requested from all peers in a single batch of initial sync. Lower values reduce memory usage at the cost of sync speed. A value of 0 disables the limit.",
+		Value: 4096,
+	}
+	// SyncMaxDistinctPeersFlag specifies the maximum number of distinct peers initial sync requests blocks from.
+	SyncMaxDistinctPeersFlag = cli.IntFlag{
+		Name:  "sync-max-distinct-peers",
+		Usage: "The maximum number of distinct peers initial sync requests blocks from. Once reached, only the peers already contacted are queried. A value of 0 disables the limit.",
+	}
+	// SyncLogStructuredFlag logs initial sync progress with structured fields only.
+	SyncLogStructuredFlag = cli.BoolFlag{
+		Name:  "sync-log-structured",
+		Usage: "Logs initial sync progress with a static message and structured fields only, for log aggregation pipelines. The etaSeconds field is -1 while the time remaining is being estimated.",
+	}
+	// MinPeerReliabilityFlag specifies the minimum score of peers requested blocks from during initial sync.
+	MinPeerReliabilityFlag = cli.Float64Flag{
+		Name:  "sync-min-peer-reliability",
+		Usage: "The minimum reliability score between 0 and 1 of a peer to request blocks from during initial sync, once enough requests to the peer were scored. A value of 0 disables the threshold.",
+	}
+	// SyncVerifySampleRateFlag specifies the fraction of blocks synced without verification which are verified after initial sync.
+	SyncVerifySampleRateFlag = cli.Float64Flag{
+		Name:  "sync-verify-sample-rate",
+		Usage: "The fraction between 0 and 1 of the blocks synced without verifying their contents which are randomly sampled and fully verified after initial sync, failing sync if any does not verify. A value of 0 disables the check.",
+	}
+	// SyncLogSummaryFlag logs a summary of initial sync once it completes.
+	SyncLogSummaryFlag = cli.BoolFlag{
+		Name:  "sync-log-summary",
+		Usage: "Logs a single line summarizing initial sync once it completes, with the number of blocks synced, the elapsed time, the average rate, the number of distinct peers used and the time spent in each phase.",
+	}
+	// SyncMaxMisbehaviorRateFlag specifies the fraction of recent peer interactions flagging misbehavior at which initial sync halts.
+	SyncMaxMisbehaviorRateFlag = cli.Float64Flag{
+		Name:  "sync-max-misbehavior-rate",
+		Usage: "The fraction between 0 and 1 of recent peer interactions during initial sync which may flag misbehavior, such as invalid responses or impossible head slots, before sync halts for the operator to investigate a possible eclipse attack. A value of 0 disables the check.",
+	}
+	// SyncVerifyContiguityFlag enables checking that the blocks synced to the finalized epoch form a contiguous chain.
+	SyncVerifyContiguityFlag = cli.BoolFlag{
+		Name:  "sync-verify-contiguity",
+		Usage: "Walk the blocks synced to the finalized epoch in the db once initial sync reaches it, failing sync if a block does not build on the previous one. This costs a walk over the synced blocks.",
+	}
+	// SyncHeadForkchoiceFlag makes initial sync process blocks after the finalized epoch with the full block receiving path.
+	SyncHeadForkchoiceFlag = cli.BoolFlag{
+		Name:  "sync-head-forkchoice",
+		Usage: "Process blocks synced after the finalized epoch with the full block receiving path, which updates fork choice and pubsub for every block, so that head queries can be served sooner. This slows down syncing to the chain head.",
+	}
+	// HeadSyncOverlapFlag specifies how many slots before the head each request for blocks up to the
+	// chain head starts.
+	HeadSyncOverlapFlag = cli.Uint64Flag{
+		Name:  "sync-head-overlap",
+		Usage: "The number of slots before the head from which initial sync requests blocks when syncing to the chain head, so that a reorg of the head up to this depth is reconciled by receiving the reorged blocks. Blocks already in the db are not received again. A value of 0 requests blocks from the slot after the head.",
+		Value: 0,
+	}
+	// SyncLatestHeadRootFlag makes initial sync request blocks up to the finalized epoch with the root
+	// of the latest processed block as the head block root.
+	SyncLatestHeadRootFlag = cli.BoolFlag{
+		Name:  "sync-latest-head-root",
+		Usage: "Set the head block root of the requests for blocks up to the finalized epoch to the root of the latest processed block instead of the finalized root, for peers validating the head block root strictly.",
+	}
+	// HeadSyncMaxRequestsFlag specifies how many requests initial sync sends when syncing to the chain
+	// head before handing off to regular sync.
+	HeadSyncMaxRequestsFlag = cli.IntFlag{
+		Name:  "sync-head-max-requests",
+		Usage: "The maximum number of requests initial sync sends when syncing to the chain head, after which it considers itself synced on a best effort basis and hands off to regular sync, so that a node slower than the chain does not chase the head indefinitely. A value of 0 does not cap the requests.",
+		Value: 0,
+	}
+	// SyncMaxProcessRateFlag specifies the maximum number of blocks per second initial sync processes.
+	SyncMaxProcessRateFlag = cli.IntFlag{
+		Name:  "sync-max-process-rate",
+		Usage: "The maximum number of blocks per second initial sync processes, trading sync speed for a lower CPU footprint on shared hosts. Unlike request rate limits, this throttles the processing of blocks which have already been received. A value of 0 does not limit the rate.",
+		Value: 0,
+	}
+	// SyncCaptureDirFlag specifies a directory initial sync writes the blocks of each batch to.
+	SyncCaptureDirFlag = cli.StringFlag{
+		Name:  "sync-capture-dir",
+		Usage: "Debugging aid writing the sorted blocks of each batch received by initial sync, before they are processed, to numbered SSZ files in the directory for offline analysis. The total size of the captured batches is capped at 1GB. Batches are not captured when unset.",
+		Value: "",
+	}
 	// SlasherCertFlag defines a flag for the slasher TLS certificate.
 	SlasherCertFlag = cli.StringFlag{
 		Name:  "slasher-tls-cert",
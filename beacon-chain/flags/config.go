@@ -14,6 +14,30 @@ type GlobalFlags struct {
 	EnableArchivedBlocks              bool
 	EnableArchivedAttestations        bool
 	MinimumSyncPeers                  int
+	InitSyncBatchSaveBlocks           int
+	DisablePeerShuffle                bool
+	MaxBlocksPerPeer                  int
+	PreferReliablePeers               bool
+	PrefetchNextBatch                 bool
+	VerifyFinalizedRoot               bool
+	MaxFailoverDepth                  int
+	HeadSyncSlotTolerance             uint64
+	SyncFinalityOnly                  bool
+	SyncCheckpointInterval            int
+	SyncMaxBufferedBlocks             int
+	SyncMaxDistinctPeers              int
+	SyncLogStructured                 bool
+	MinPeerReliability                float64
+	SyncVerifySampleRate              float64
+	SyncLogSummary                    bool
+	SyncMaxMisbehaviorRate            float64
+	SyncVerifyContiguity              bool
+	SyncHeadForkchoice                bool
+	HeadSyncOverlap                   uint64
+	SyncLatestHeadRoot                bool
+	HeadSyncMaxRequests               int
+	SyncMaxProcessRate                int
+	SyncCaptureDir                    string
 }
 
 var globalConfig *GlobalFlags
@@ -47,6 +71,42 @@ func ConfigureGlobalFlags(ctx *cli.Context) {
 	if ctx.GlobalBool(ArchiveAttestationsFlag.Name) {
 		cfg.EnableArchivedAttestations = true
 	}
+	if ctx.GlobalBool(DisablePeerShuffleFlag.Name) {
+		log.Warn("Disabled peer shuffling during initial sync. This should be used for debugging only.")
+		cfg.DisablePeerShuffle = true
+	}
+	cfg.InitSyncBatchSaveBlocks = ctx.GlobalInt(InitSyncBatchSaveBlocks.Name)
+	cfg.MaxBlocksPerPeer = ctx.GlobalInt(MaxBlocksPerPeerFlag.Name)
+	if ctx.GlobalBool(EnablePreferReliablePeersFlag.Name) {
+		cfg.PreferReliablePeers = true
+	}
+	if ctx.GlobalBool(EnableBatchPrefetchFlag.Name) {
+		cfg.PrefetchNextBatch = true
+	}
+	if ctx.GlobalBool(VerifyFinalizedRootFlag.Name) {
+		cfg.VerifyFinalizedRoot = true
+	}
+	cfg.MaxFailoverDepth = ctx.GlobalInt(MaxFailoverDepthFlag.Name)
+	cfg.HeadSyncSlotTolerance = ctx.GlobalUint64(HeadSyncSlotToleranceFlag.Name)
+	if ctx.GlobalBool(SyncFinalityOnlyFlag.Name) {
+		log.Warn("Only syncing to the finalized epoch")
+		cfg.SyncFinalityOnly = true
+	}
+	cfg.SyncCheckpointInterval = ctx.GlobalInt(SyncCheckpointIntervalFlag.Name)
+	cfg.SyncMaxBufferedBlocks = ctx.GlobalInt(SyncMaxBufferedBlocksFlag.Name)
+	cfg.SyncMaxDistinctPeers = ctx.GlobalInt(SyncMaxDistinctPeersFlag.Name)
+	cfg.SyncLogStructured = ctx.GlobalBool(SyncLogStructuredFlag.Name)
+	cfg.MinPeerReliability = ctx.GlobalFloat64(MinPeerReliabilityFlag.Name)
+	cfg.SyncVerifySampleRate = ctx.GlobalFloat64(SyncVerifySampleRateFlag.Name)
+	cfg.SyncLogSummary = ctx.GlobalBool(SyncLogSummaryFlag.Name)
+	cfg.SyncMaxMisbehaviorRate = ctx.GlobalFloat64(SyncMaxMisbehaviorRateFlag.Name)
+	cfg.SyncVerifyContiguity = ctx.GlobalBool(SyncVerifyContiguityFlag.Name)
+	cfg.SyncHeadForkchoice = ctx.GlobalBool(SyncHeadForkchoiceFlag.Name)
+	cfg.HeadSyncOverlap = ctx.GlobalUint64(HeadSyncOverlapFlag.Name)
+	cfg.SyncLatestHeadRoot = ctx.GlobalBool(SyncLatestHeadRootFlag.Name)
+	cfg.HeadSyncMaxRequests = ctx.GlobalInt(HeadSyncMaxRequestsFlag.Name)
+	cfg.SyncMaxProcessRate = ctx.GlobalInt(SyncMaxProcessRateFlag.Name)
+	cfg.SyncCaptureDir = ctx.GlobalString(SyncCaptureDirFlag.Name)
 	configureMinimumPeers(ctx, cfg)
 
 	Init(cfg)
@@ -33,6 +33,30 @@ var appFlags = []cli.Flag{
 	flags.KeyFlag,
 	flags.GRPCGatewayPort,
 	flags.MinSyncPeers,
+	flags.InitSyncBatchSaveBlocks,
+	flags.DisablePeerShuffleFlag,
+	flags.MaxBlocksPerPeerFlag,
+	flags.EnablePreferReliablePeersFlag,
+	flags.EnableBatchPrefetchFlag,
+	flags.VerifyFinalizedRootFlag,
+	flags.MaxFailoverDepthFlag,
+	flags.HeadSyncSlotToleranceFlag,
+	flags.SyncFinalityOnlyFlag,
+	flags.SyncCheckpointIntervalFlag,
+	flags.SyncMaxBufferedBlocksFlag,
+	flags.SyncMaxDistinctPeersFlag,
+	flags.SyncLogStructuredFlag,
+	flags.MinPeerReliabilityFlag,
+	flags.SyncVerifySampleRateFlag,
+	flags.SyncLogSummaryFlag,
+	flags.SyncMaxMisbehaviorRateFlag,
+	flags.SyncVerifyContiguityFlag,
+	flags.SyncHeadForkchoiceFlag,
+	flags.HeadSyncOverlapFlag,
+	flags.SyncLatestHeadRootFlag,
+	flags.HeadSyncMaxRequestsFlag,
+	flags.SyncMaxProcessRateFlag,
+	flags.SyncCaptureDirFlag,
 	flags.InteropMockEth1DataVotesFlag,
 	flags.InteropGenesisStateFlag,
 	flags.InteropNumValidatorsFlag,
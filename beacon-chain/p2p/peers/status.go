@@ -182,6 +182,21 @@ func (p *Status) ChainStateLastUpdated(pid peer.ID) (time.Time, error) {
 	return roughtime.Now(), ErrPeerUnknown
 }
 
+// ConnectedChainStates returns the chain states of all connected peers, taken as a single
+// consistent snapshot. Connected peers which have not reported a chain state yet are included
+// with a nil chain state.
+func (p *Status) ConnectedChainStates() map[peer.ID]*pb.Status {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	chainStates := make(map[peer.ID]*pb.Status)
+	for pid, status := range p.status {
+		if status.peerState == PeerConnected {
+			chainStates[pid] = status.chainState
+		}
+	}
+	return chainStates
+}
+
 // IncrementBadResponses increments the number of bad responses we have received from the given remote peer.
 func (p *Status) IncrementBadResponses(pid peer.ID) {
 	p.lock.Lock()
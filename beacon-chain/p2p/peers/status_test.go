@@ -182,6 +182,31 @@ func TestPeerChainState(t *testing.T) {
 	}
 }
 
+func TestConnectedChainStates(t *testing.T) {
+	maxBadResponses := 2
+	p := peers.NewStatus(maxBadResponses)
+
+	pid1 := addPeer(t, p, peers.PeerConnected)
+	p.SetChainState(pid1, &pb.Status{HeadSlot: 10, FinalizedEpoch: 1})
+	pid2 := addPeer(t, p, peers.PeerConnected)
+	pid3 := addPeer(t, p, peers.PeerDisconnected)
+	p.SetChainState(pid3, &pb.Status{HeadSlot: 20, FinalizedEpoch: 2})
+
+	chainStates := p.ConnectedChainStates()
+	if len(chainStates) != 2 {
+		t.Fatalf("Unexpected number of chain states: expected 2, received %d", len(chainStates))
+	}
+	if chainStates[pid1] == nil || chainStates[pid1].HeadSlot != 10 {
+		t.Errorf("Unexpected chain state for connected peer: %v", chainStates[pid1])
+	}
+	if chainState, ok := chainStates[pid2]; !ok || chainState != nil {
+		t.Errorf("Expected connected peer without chain state to be present with a nil chain state")
+	}
+	if _, ok := chainStates[pid3]; ok {
+		t.Error("Expected disconnected peer to be excluded")
+	}
+}
+
 func TestPeerBadResponses(t *testing.T) {
 	maxBadResponses := 2
 	p := peers.NewStatus(maxBadResponses)
@@ -519,7 +519,7 @@ func (bs *Server) GetValidatorQueue(
 	}
 
 	// We use the exit queue churn to determine if we have passed a churn limit.
-	minEpoch := exitQueueEpoch + params.BeaconConfig().MinValidatorWithdrawabilityDelay
+	minEpoch := helpers.EstimateWithdrawableEpochOnExit(exitQueueEpoch)
 	exitQueueIndices := make([]uint64, 0)
 	for _, valIdx := range awaitingExit {
 		if headState.Validators[valIdx].WithdrawableEpoch < minEpoch {
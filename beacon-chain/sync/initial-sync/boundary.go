@@ -0,0 +1,82 @@
+package initialsync
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/paulbellamy/ratecounter"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/beacon-chain/core/helpers"
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/mathutil"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/sirupsen/logrus"
+)
+
+// syncToFinalizedBoundary requests the slots between the start slot of the session and the last
+// slot of the finalized epoch precisely, with a step of 1, so that Step 1 ends with the head at the
+// finalized boundary. The skipped slots math of the Step 1 loop may otherwise stop a few slots
+// short of it. The start slot and the anchor root, if any, are those the Step 1 loop requests
+// from. The remaining slots are requested from each peer in turn until one serves them, with peers
+// filtered the same way as in the Step 1 loop.
+func (s *Service) syncToFinalizedBoundary(ctx context.Context, genesis time.Time, startSlot uint64, anchorRoot []byte, counter *ratecounter.RateCounter) error {
+	root, finalizedEpoch, peers := s.p2p.Peers().BestFinalized(params.BeaconConfig().MaxPeersToSync, helpers.SlotToEpoch(s.chain.HeadSlot()))
+	boundary := helpers.StartSlot(finalizedEpoch+1) - 1
+	peers = s.admitPeers(s.reliablePeers(s.peerCooldowns.filter(peers, time.Now())))
+	for start := startSlot; finalizedEpoch > 0 && start <= boundary; {
+		count := mathutil.Min(boundary-start+1, params.BeaconConfig().MaxRequestBlocks)
+		headRoot := root
+		if anchorRoot != nil {
+			headRoot = anchorRoot
+		}
+		req := &p2ppb.BeaconBlocksByRangeRequest{
+			HeadBlockRoot: s.finalizedSyncHeadRoot(headRoot),
+			StartSlot:     start,
+			Count:         count,
+			Step:          1,
+		}
+		log.WithFields(logrus.Fields{
+			"start":    start,
+			"count":    count,
+			"boundary": boundary,
+		}).Debug("Requesting remaining blocks up to the finalized boundary")
+		blocks, pid, err := s.requestFromAny(ctx, req, peers)
+		if err != nil {
+			return errors.Wrapf(err, "could not sync to the finalized boundary slot %d", boundary)
+		}
+		sort.Slice(blocks, func(i, j int) bool {
+			return blocks[i].Block.Slot < blocks[j].Block.Slot
+		})
+		if err := s.processBlocks(ctx, genesis, blocks, []peer.ID{pid}, counter); err != nil {
+			return err
+		}
+		// Later requests follow the processed blocks rather than the anchor.
+		anchorRoot = nil
+		start += count
+	}
+	return nil
+}
+
+// requestFromAny sends the request to each of the peers in turn, returning the blocks served by
+// the first peer to respond successfully along with that peer. Peers which fail the request are
+// cooling down afterwards.
+func (s *Service) requestFromAny(ctx context.Context, req *p2ppb.BeaconBlocksByRangeRequest, peers []peer.ID) ([]*eth.SignedBeaconBlock, peer.ID, error) {
+	if len(peers) == 0 {
+		return nil, "", errors.WithStack(ErrNoPeers)
+	}
+	var err error
+	for _, pid := range peers {
+		var blocks []*eth.SignedBeaconBlock
+		if blocks, err = s.requestBlocks(ctx, req, pid); err == nil {
+			return blocks, pid, nil
+		}
+		if peerFault(ctx, err) {
+			s.peerCooldowns.start(pid, time.Now())
+		}
+		log.WithError(err).WithField("peer", pid.Pretty()).Debug("Request failed, trying the next peer")
+	}
+	return nil, "", err
+}
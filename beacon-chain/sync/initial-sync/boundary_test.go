@@ -0,0 +1,156 @@
+package initialsync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/paulbellamy/ratecounter"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/beacon-chain/core/helpers"
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	logTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestSyncToFinalizedBoundary_RequestsRemainingSlots(t *testing.T) {
+	currentSlot := uint64(160)
+	finalizedEpoch := uint64(2)
+	// Whichever peer is requested first, the remaining slots are served by the healthy peer.
+	peers := []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: finalizedEpoch,
+			headSlot:       currentSlot,
+			failureSlots:   makeSequence(1, currentSlot),
+		},
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: finalizedEpoch,
+			headSlot:       currentSlot,
+		},
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+
+	// The head is left short of the finalized boundary, as if the Step 1 loop ended early.
+	counter := ratecounter.NewRateCounter(counterSeconds * time.Second)
+	if err := h.service.syncToFinalizedBoundary(context.Background(), makeGenesisTime(currentSlot), h.chain.HeadSlot()+1, nil /* anchorRoot */, counter); err != nil {
+		t.Fatal(err)
+	}
+	boundary := helpers.StartSlot(finalizedEpoch+1) - 1
+	if h.chain.HeadSlot() != boundary {
+		t.Errorf("Head slot (%d) is not the finalized boundary slot (%d)", h.chain.HeadSlot(), boundary)
+	}
+	if len(h.chain.BlocksReceived) != int(boundary) {
+		t.Errorf("Wanted %d blocks up to the finalized boundary to be received, received %d", boundary, len(h.chain.BlocksReceived))
+	}
+}
+
+func TestSyncToFinalizedBoundary_StartsFromAnchor(t *testing.T) {
+	currentSlot := uint64(160)
+	finalizedEpoch := uint64(2)
+	h, teardown := newSyncTestHarness(t, currentSlot, []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: finalizedEpoch,
+			headSlot:       currentSlot,
+		},
+	})
+	defer teardown()
+
+	// The anchor block is in the db while the chain head is still at genesis.
+	anchorSlot := uint64(40)
+	parentRoot := rootCache[parentSlotCache[anchorSlot]]
+	if err := h.db.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot:       anchorSlot,
+			ParentRoot: parentRoot[:],
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	anchorRoot := rootCache[anchorSlot]
+	h.chain.Root = anchorRoot[:]
+
+	counter := ratecounter.NewRateCounter(counterSeconds * time.Second)
+	if err := h.service.syncToFinalizedBoundary(context.Background(), makeGenesisTime(currentSlot), anchorSlot+1, anchorRoot[:], counter); err != nil {
+		t.Fatal(err)
+	}
+	boundary := helpers.StartSlot(finalizedEpoch+1) - 1
+	if h.chain.HeadSlot() != boundary {
+		t.Errorf("Head slot (%d) is not the finalized boundary slot (%d)", h.chain.HeadSlot(), boundary)
+	}
+	if len(h.chain.BlocksReceived) != int(boundary-anchorSlot) {
+		t.Fatalf("Wanted the %d blocks after the anchor to be received, received %d", boundary-anchorSlot, len(h.chain.BlocksReceived))
+	}
+	if first := h.chain.BlocksReceived[0].Block.Slot; first != anchorSlot+1 {
+		t.Errorf("Wanted blocks to be received from the slot after the anchor (%d), received slot %d first", anchorSlot+1, first)
+	}
+}
+
+func TestSyncToFinalizedBoundary_SkipsCoolingDownPeers(t *testing.T) {
+	hook := logTest.NewGlobal()
+	currentSlot := uint64(160)
+	finalizedEpoch := uint64(2)
+	var peers []*peerData
+	for i := 0; i < 2; i++ {
+		peers = append(peers, &peerData{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: finalizedEpoch,
+			headSlot:       currentSlot,
+		})
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+	cooling := h.p2p.Peers().Connected()[0]
+	h.service.peerCooldowns.start(cooling, time.Now())
+
+	counter := ratecounter.NewRateCounter(counterSeconds * time.Second)
+	if err := h.service.syncToFinalizedBoundary(context.Background(), makeGenesisTime(currentSlot), h.chain.HeadSlot()+1, nil /* anchorRoot */, counter); err != nil {
+		t.Fatal(err)
+	}
+	if boundary := helpers.StartSlot(finalizedEpoch+1) - 1; h.chain.HeadSlot() != boundary {
+		t.Errorf("Head slot (%d) is not the finalized boundary slot (%d)", h.chain.HeadSlot(), boundary)
+	}
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Requesting blocks" && entry.Data["peer"] == cooling {
+			t.Errorf("Requested blocks from peer %s, which is cooling down", cooling.Pretty())
+		}
+	}
+}
+
+func TestRoundRobinSync_HeadAtFinalizedBoundaryBeforeStep2(t *testing.T) {
+	hook := logTest.NewGlobal()
+	currentSlot := uint64(160)
+	finalizedEpoch := uint64(2)
+	var peers []*peerData
+	for i := 0; i < 3; i++ {
+		peers = append(peers, &peerData{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: finalizedEpoch,
+			headSlot:       currentSlot,
+		})
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	// Step 2 requests blocks from the slot after the head, so its first request reveals the head
+	// slot at the transition.
+	boundary := helpers.StartSlot(finalizedEpoch+1) - 1
+	for _, entry := range hook.AllEntries() {
+		if entry.Message != "Sending batch block request" {
+			continue
+		}
+		req, ok := entry.Data["req"].(*p2ppb.BeaconBlocksByRangeRequest)
+		if !ok {
+			t.Fatalf("Wanted the request to be logged, received %v", entry.Data["req"])
+		}
+		if req.StartSlot != boundary+1 {
+			t.Errorf("Head slot at the transition to Step 2 (%d) is not the finalized boundary slot (%d)", req.StartSlot-1, boundary)
+		}
+		return
+	}
+	t.Error("Wanted blocks after the finalized epoch to be requested in Step 2")
+}
@@ -0,0 +1,92 @@
+package initialsync
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/sirupsen/logrus"
+)
+
+// maxCaptureBytes caps the total size of the batches captured to disk, so that a capture left
+// enabled does not fill the disk.
+const maxCaptureBytes = 1 << 30
+
+// batchCapture writes the blocks of each batch handed to processing to numbered files for offline
+// analysis, e.g. of a sync which landed on the wrong chain. The zero value is ready to use.
+type batchCapture struct {
+	batches uint64
+	bytes   uint64
+	capped  bool
+	seeded  bool
+}
+
+// seed continues the numbering and the total size of the batches captured to the directory before,
+// e.g. by an earlier run of the node, so that their files are not overwritten.
+func (c *batchCapture) seed(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "batch_*.ssz"))
+	if err != nil {
+		return errors.Wrap(err, "could not list captured batches")
+	}
+	for _, fp := range files {
+		var n uint64
+		if _, err := fmt.Sscanf(filepath.Base(fp), "batch_%d.ssz", &n); err != nil {
+			continue
+		}
+		info, err := os.Stat(fp)
+		if err != nil {
+			return errors.Wrap(err, "could not read captured batch")
+		}
+		if n > c.batches {
+			c.batches = n
+		}
+		c.bytes += uint64(info.Size())
+	}
+	c.seeded = true
+	return nil
+}
+
+// write the sorted blocks of a batch to the next numbered file in the directory, as an SSZ encoded
+// list of signed blocks. Once the total size of the batches in the directory would exceed the max
+// bytes, no further batches are written.
+func (c *batchCapture) write(dir string, maxBytes uint64, blocks []*eth.SignedBeaconBlock) error {
+	if c.capped || len(blocks) == 0 {
+		return nil
+	}
+	if !c.seeded {
+		if err := c.seed(dir); err != nil {
+			return err
+		}
+	}
+	enc, err := ssz.Marshal(blocks)
+	if err != nil {
+		return errors.Wrap(err, "could not encode batch blocks")
+	}
+	if c.bytes+uint64(len(enc)) > maxBytes {
+		c.capped = true
+		log.WithFields(logrus.Fields{
+			"dir":      dir,
+			"batches":  c.batches,
+			"maxBytes": maxBytes,
+		}).Warn("Reached the size cap of captured batches, no longer capturing batches")
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "could not create capture directory")
+	}
+	fp := filepath.Join(dir, fmt.Sprintf("batch_%06d.ssz", c.batches+1))
+	if err := ioutil.WriteFile(fp, enc, 0600); err != nil {
+		return errors.Wrap(err, "could not write batch blocks")
+	}
+	c.batches++
+	c.bytes += uint64(len(enc))
+	log.WithFields(logrus.Fields{
+		"file":   fp,
+		"blocks": len(blocks),
+	}).Debug("Captured batch blocks")
+	return nil
+}
@@ -0,0 +1,182 @@
+package initialsync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+// captureTestDir returns an empty directory for captured batches, removed by the returned func.
+func captureTestDir(t *testing.T, name string) (string, func()) {
+	dir := filepath.Join(testutil.TempDir(), name)
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("Failed to remove directory: %v", err)
+	}
+	return dir, func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatalf("Failed to remove directory: %v", err)
+		}
+	}
+}
+
+// readCapturedBatches decodes the captured batches in the directory, in the order they were written.
+func readCapturedBatches(t *testing.T, dir string) [][]*eth.SignedBeaconBlock {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batches := make([][]*eth.SignedBeaconBlock, 0, len(files))
+	for _, f := range files {
+		enc, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var blocks []*eth.SignedBeaconBlock
+		if err := ssz.Unmarshal(enc, &blocks); err != nil {
+			t.Fatalf("Could not decode captured batch %s: %v", f.Name(), err)
+		}
+		batches = append(batches, blocks)
+	}
+	return batches
+}
+
+func testCaptureBlocks(start uint64, count uint64) []*eth.SignedBeaconBlock {
+	blocks := make([]*eth.SignedBeaconBlock, count)
+	for i := range blocks {
+		blocks[i] = &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: start + uint64(i)}}
+	}
+	return blocks
+}
+
+func TestBatchCapture_WritesNumberedBatches(t *testing.T) {
+	dir, cleanup := captureTestDir(t, "capture-numbered")
+	defer cleanup()
+
+	c := &batchCapture{}
+	for _, blocks := range [][]*eth.SignedBeaconBlock{
+		testCaptureBlocks(1, 5),
+		nil, // Empty batches are not captured.
+		testCaptureBlocks(6, 3),
+	} {
+		if err := c.write(dir, maxCaptureBytes, blocks); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"batch_000001.ssz", "batch_000002.ssz"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Wanted captured batch file %s: %v", name, err)
+		}
+	}
+	batches := readCapturedBatches(t, dir)
+	if len(batches) != 2 || len(batches[0]) != 5 || len(batches[1]) != 3 {
+		t.Fatalf("Wanted batches of 5 and 3 blocks, received %d batches", len(batches))
+	}
+	if batches[1][0].Block.Slot != 6 {
+		t.Errorf("Wanted the second batch to start at slot 6, received %d", batches[1][0].Block.Slot)
+	}
+}
+
+func TestBatchCapture_ContinuesNumberingOfEarlierCaptures(t *testing.T) {
+	dir, cleanup := captureTestDir(t, "capture-continued")
+	defer cleanup()
+
+	first := &batchCapture{}
+	for _, blocks := range [][]*eth.SignedBeaconBlock{testCaptureBlocks(1, 5), testCaptureBlocks(6, 3)} {
+		if err := first.write(dir, maxCaptureBytes, blocks); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A capture of a later run of the node adds to the batches captured before.
+	second := &batchCapture{}
+	if err := second.write(dir, maxCaptureBytes, testCaptureBlocks(20, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "batch_000003.ssz")); err != nil {
+		t.Errorf("Wanted the batch of the later run numbered after the earlier batches: %v", err)
+	}
+	batches := readCapturedBatches(t, dir)
+	if len(batches) != 3 || len(batches[0]) != 5 || len(batches[1]) != 3 || len(batches[2]) != 2 {
+		t.Fatalf("Wanted batches of 5, 3 and 2 blocks, received %d batches", len(batches))
+	}
+	enc, err := ssz.Marshal(batches[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.bytes != first.bytes+uint64(len(enc)) {
+		t.Errorf("Wanted the size of the earlier batches counted towards the cap, received %d bytes", second.bytes)
+	}
+}
+
+func TestBatchCapture_SizeCap(t *testing.T) {
+	dir, cleanup := captureTestDir(t, "capture-size-cap")
+	defer cleanup()
+
+	enc, err := ssz.Marshal(testCaptureBlocks(1, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The cap fits a single batch of 4 blocks.
+	maxBytes := uint64(len(enc)) + 1
+	c := &batchCapture{}
+	for i := uint64(0); i < 3; i++ {
+		if err := c.write(dir, maxBytes, testCaptureBlocks(1+4*i, 4)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if batches := readCapturedBatches(t, dir); len(batches) != 1 {
+		t.Errorf("Wanted a single batch captured within the size cap, received %d", len(batches))
+	}
+	if !c.capped {
+		t.Error("Wanted capturing to stop once the size cap was reached")
+	}
+}
+
+func TestRoundRobinSync_CaptureDir(t *testing.T) {
+	dir, cleanup := captureTestDir(t, "capture-sync")
+	defer cleanup()
+	flags.Init(&flags.GlobalFlags{SyncCaptureDir: dir})
+	defer flags.Init(nil)
+
+	// A single peer serves Step 1 in batches of 64 blocks up to the finalized boundary at slot 96.
+	currentSlot := uint64(160)
+	peers := []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+		},
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	batches := readCapturedBatches(t, dir)
+	if len(batches) < 2 {
+		t.Fatalf("Wanted several batches captured, received %d", len(batches))
+	}
+	if len(batches[0]) != blockBatchSize {
+		t.Errorf("Wanted the first batch to hold %d blocks, received %d", blockBatchSize, len(batches[0]))
+	}
+	// The captured batches hold every block synced to the finalized boundary, in slot order.
+	next := uint64(1)
+	for i, blocks := range batches {
+		for _, blk := range blocks {
+			if blk.Block.Slot != next {
+				t.Fatalf("Wanted slot %d next in captured batch %d, received %d", next, i+1, blk.Block.Slot)
+			}
+			next++
+		}
+	}
+	if boundary := uint64(96); next != boundary {
+		t.Errorf("Wanted captured batches up to the finalized boundary at slot %d, last slot was %d", boundary, next-1)
+	}
+}
@@ -0,0 +1,84 @@
+package initialsync
+
+import (
+	"sort"
+	"time"
+
+	"github.com/prysmaticlabs/prysm/beacon-chain/core/helpers"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/sirupsen/logrus"
+)
+
+// ClockSkew compares the slot of the local wall clock with the median head slot reported by the
+// connected peers. Peers can't be ahead of the actual current slot, so peers far ahead of the local
+// slot suggest that the local clock is wrong.
+type ClockSkew struct {
+	// LocalSlot is the current slot according to the local wall clock.
+	LocalSlot uint64
+	// PeerHeadSlot is the median head slot reported by the peers.
+	PeerHeadSlot uint64
+	// Peers is the number of peers which reported a head slot.
+	Peers int
+	// Slots is the number of slots the local slot is ahead of the peer head slot, negative if it
+	// is behind.
+	Slots int64
+	// Measured is the time of the measurement.
+	Measured time.Time
+}
+
+// Suspect returns true if the local slot is behind the peer head slot by more than an epoch. A
+// local slot ahead of the peer head slot is not suspect, as it can't be told apart from a chain
+// which skipped slots. There is nothing to compare against without peers.
+func (c ClockSkew) Suspect() bool {
+	if c.Peers == 0 {
+		return false
+	}
+	return c.Slots < -int64(params.BeaconConfig().SlotsPerEpoch)
+}
+
+// measureClockSkew compares the local wall clock slot with the median head slot of the connected
+// peers, logging a warning once the local clock may be wrong. The measurement is kept for
+// ClockSkew.
+func (s *Service) measureClockSkew(genesis time.Time) ClockSkew {
+	var headSlots []uint64
+	for _, chainState := range s.p2p.Peers().ConnectedChainStates() {
+		if chainState != nil {
+			headSlots = append(headSlots, chainState.HeadSlot)
+		}
+	}
+	skew := ClockSkew{
+		LocalSlot: helpers.SlotsSince(genesis),
+		Peers:     len(headSlots),
+		Measured:  time.Now(),
+	}
+	if len(headSlots) > 0 {
+		sort.Slice(headSlots, func(i, j int) bool {
+			return headSlots[i] < headSlots[j]
+		})
+		skew.PeerHeadSlot = headSlots[len(headSlots)/2]
+		skew.Slots = int64(skew.LocalSlot) - int64(skew.PeerHeadSlot)
+	}
+
+	s.clockSkewLock.Lock()
+	wasSuspect := s.clockSkew.Suspect()
+	s.clockSkew = skew
+	s.clockSkewLock.Unlock()
+
+	if skew.Suspect() && !wasSuspect {
+		log.WithFields(logrus.Fields{
+			"localSlot":    skew.LocalSlot,
+			"peerHeadSlot": skew.PeerHeadSlot,
+			"peers":        skew.Peers,
+			"skewSlots":    skew.Slots,
+		}).Warn("Local clock may be wrong: the wall clock slot is far from the median head slot of peers, check that the system time is synchronized")
+	}
+	return skew
+}
+
+// ClockSkew returns the last clock skew measured while syncing. It is reported separately from
+// Status, which only reflects whether the node is still syncing.
+func (s *Service) ClockSkew() ClockSkew {
+	s.clockSkewLock.RLock()
+	defer s.clockSkewLock.RUnlock()
+	return s.clockSkew
+}
@@ -0,0 +1,148 @@
+package initialsync
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	logTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestMeasureClockSkew(t *testing.T) {
+	currentSlot := uint64(160)
+	tests := []struct {
+		name      string
+		headSlots []uint64
+		skewSlots int64
+		suspect   bool
+	}{
+		{
+			name:      "peers at the local slot",
+			headSlots: []uint64{currentSlot, currentSlot - 1, currentSlot},
+			skewSlots: 0,
+		},
+		{
+			name:      "outlier peer ignored",
+			headSlots: []uint64{currentSlot - 10, currentSlot, 5000},
+			skewSlots: 0,
+		},
+		{
+			// Peers behind the local slot may as well follow a chain which skipped slots.
+			name:      "local clock ahead of peers",
+			headSlots: []uint64{10, 12, 11},
+			skewSlots: int64(currentSlot) - 11,
+		},
+		{
+			name:      "local clock behind peers",
+			headSlots: []uint64{400, 401, 402},
+			skewSlots: int64(currentSlot) - 401,
+			suspect:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := logTest.NewGlobal()
+			var peers []*peerData
+			for _, headSlot := range tt.headSlots {
+				peers = append(peers, &peerData{
+					blocks:   makeSequence(1, currentSlot),
+					headSlot: headSlot,
+				})
+			}
+			h, teardown := newSyncTestHarness(t, currentSlot, peers)
+			defer teardown()
+			h.service.synced = true
+
+			skew := h.service.measureClockSkew(makeGenesisTime(currentSlot))
+			if skew.LocalSlot != currentSlot || skew.Peers != len(peers) {
+				t.Errorf("Wanted local slot %d compared with %d peers, received %+v", currentSlot, len(peers), skew)
+			}
+			if skew.Slots != tt.skewSlots || skew.Suspect() != tt.suspect {
+				t.Errorf("Wanted a skew of %d slots, suspect %v, received %+v", tt.skewSlots, tt.suspect, skew)
+			}
+			if h.service.ClockSkew() != skew {
+				t.Errorf("Wanted the measured skew %+v to be kept, received %+v", skew, h.service.ClockSkew())
+			}
+
+			var warned bool
+			for _, entry := range hook.AllEntries() {
+				if strings.HasPrefix(entry.Message, "Local clock may be wrong") {
+					warned = true
+				}
+			}
+			if warned != tt.suspect {
+				t.Errorf("Wanted the clock skew warning logged %v, logged %v", tt.suspect, warned)
+			}
+			// The status only reflects the sync state of the synced node.
+			if err := h.service.Status(); err != nil {
+				t.Errorf("Wanted no status error, received %v", err)
+			}
+		})
+	}
+}
+
+func TestClockSkew_NoPeers(t *testing.T) {
+	skew := ClockSkew{LocalSlot: 1000}
+	if skew.Suspect() {
+		t.Error("Wanted no suspected clock skew without peers to compare against")
+	}
+}
+
+func TestMeasureClockSkew_RemeasuredSkewClearsSuspicion(t *testing.T) {
+	currentSlot := uint64(160)
+	hook := logTest.NewGlobal()
+	h, teardown := newSyncTestHarness(t, currentSlot, []*peerData{
+		{blocks: makeSequence(1, currentSlot), headSlot: 400},
+		{blocks: makeSequence(1, currentSlot), headSlot: 401},
+	})
+	defer teardown()
+	h.service.synced = true
+	genesis := makeGenesisTime(currentSlot)
+
+	h.service.measureClockSkew(genesis)
+	h.service.measureClockSkew(genesis)
+	var warnings int
+	for _, entry := range hook.AllEntries() {
+		if strings.HasPrefix(entry.Message, "Local clock may be wrong") {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("Wanted the clock skew warning logged once while the skew stays suspect, logged %d times", warnings)
+	}
+	if !h.service.ClockSkew().Suspect() {
+		t.Fatal("Wanted the kept clock skew to be suspect")
+	}
+
+	for _, pid := range h.p2p.Peers().Connected() {
+		h.p2p.Peers().SetChainState(pid, &p2ppb.Status{HeadSlot: currentSlot})
+	}
+	if skew := h.service.measureClockSkew(genesis); skew.Suspect() {
+		t.Errorf("Wanted no suspected clock skew once peers are at the local slot, received %+v", skew)
+	}
+	if h.service.ClockSkew().Suspect() {
+		t.Errorf("Wanted the kept clock skew to be cleared after measuring it again, received %+v", h.service.ClockSkew())
+	}
+}
+
+func TestStatus_IgnoresClockSkew(t *testing.T) {
+	s := &Service{
+		synced:       true,
+		chainStarted: true,
+		clockSkew: ClockSkew{
+			LocalSlot:    100,
+			PeerHeadSlot: 400,
+			Peers:        3,
+			Slots:        -300,
+			Measured:     time.Now(),
+		},
+	}
+	if err := s.Status(); err != nil {
+		t.Errorf("Wanted the status of a synced node to ignore the clock skew, received %v", err)
+	}
+	s.synced = false
+	if err := s.Status(); err == nil || err.Error() != "syncing" {
+		t.Errorf("Wanted the status to report syncing, received %v", err)
+	}
+}
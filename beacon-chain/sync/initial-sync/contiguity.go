@@ -0,0 +1,38 @@
+package initialsync
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prysmaticlabs/prysm/shared/bytesutil"
+)
+
+// ErrSyncedChainGap is returned when the blocks synced to the finalized epoch do not form a
+// contiguous chain.
+var ErrSyncedChainGap = errors.New("synced chain is not contiguous")
+
+// verifyContiguity walks back from the head block through the parent of each block, checking that
+// every ancestor down to the first block before the start slot is in the db. Blocks of other forks
+// in the synced range are not part of the walk. The first missing ancestor is returned as an error.
+func (s *Service) verifyContiguity(ctx context.Context, startSlot uint64, headRoot []byte) error {
+	root := bytesutil.ToBytes32(headRoot)
+	head, err := s.db.Block(ctx, root)
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve head block")
+	}
+	if head == nil || head.Block == nil {
+		return errors.Wrapf(ErrSyncedChainGap, "head block %#x is not in the db", root)
+	}
+	for blk := head.Block; blk.Slot >= startSlot; {
+		parentRoot := bytesutil.ToBytes32(blk.ParentRoot)
+		parent, err := s.db.Block(ctx, parentRoot)
+		if err != nil {
+			return errors.Wrapf(err, "could not retrieve parent of block at slot %d", blk.Slot)
+		}
+		if parent == nil || parent.Block == nil {
+			return errors.Wrapf(ErrSyncedChainGap, "parent %#x of block at slot %d is not in the db", parentRoot, blk.Slot)
+		}
+		blk = parent.Block
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package initialsync
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/prysm/beacon-chain/db"
+	dbtest "github.com/prysmaticlabs/prysm/beacon-chain/db/testing"
+)
+
+// saveChain saves a chain of blocks at the slots to the db, each block building on the previous
+// one, starting from a genesis block. It returns the root of the last block.
+func saveChain(t *testing.T, beaconDB db.Database, slots []uint64) [32]byte {
+	ctx := context.Background()
+	parent := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: 0}}
+	if err := beaconDB.SaveBlock(ctx, parent); err != nil {
+		t.Fatal(err)
+	}
+	parentRoot, err := ssz.HashTreeRoot(parent.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, slot := range slots {
+		blk := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: slot, ParentRoot: parentRoot[:]}}
+		if err := beaconDB.SaveBlock(ctx, blk); err != nil {
+			t.Fatal(err)
+		}
+		parentRoot, err = ssz.HashTreeRoot(blk.Block)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return parentRoot
+}
+
+func TestVerifyContiguity_SkippedSlots(t *testing.T) {
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	headRoot := saveChain(t, beaconDB, []uint64{1, 2, 3, 4, 6, 7, 10})
+	s := &Service{db: beaconDB}
+
+	if err := s.verifyContiguity(context.Background(), 1, headRoot[:]); err != nil {
+		t.Errorf("Wanted a contiguous chain, received %v", err)
+	}
+}
+
+func TestVerifyContiguity_ForkBlockInRange(t *testing.T) {
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	ctx := context.Background()
+	headRoot := saveChain(t, beaconDB, []uint64{1, 2, 3, 4, 6, 7, 10})
+	// A block of another fork sits between blocks of the synced chain when sorted by slot.
+	fork := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: 5, ParentRoot: []byte("other fork")}}
+	if err := beaconDB.SaveBlock(ctx, fork); err != nil {
+		t.Fatal(err)
+	}
+	s := &Service{db: beaconDB}
+
+	if err := s.verifyContiguity(ctx, 1, headRoot[:]); err != nil {
+		t.Errorf("Wanted the fork block to be ignored, received %v", err)
+	}
+}
+
+func TestVerifyContiguity_ReportsFirstGap(t *testing.T) {
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	ctx := context.Background()
+	saveChain(t, beaconDB, []uint64{1, 2, 3, 4, 6})
+	// Blocks after the gap build on a block at slot 5 which was never synced.
+	missing := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: 5, ParentRoot: []byte("missing")}}
+	missingRoot, err := ssz.HashTreeRoot(missing.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blk := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: 8, ParentRoot: missingRoot[:]}}
+	if err := beaconDB.SaveBlock(ctx, blk); err != nil {
+		t.Fatal(err)
+	}
+	blkRoot, err := ssz.HashTreeRoot(blk.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: 9, ParentRoot: blkRoot[:]}}
+	if err := beaconDB.SaveBlock(ctx, head); err != nil {
+		t.Fatal(err)
+	}
+	headRoot, err := ssz.HashTreeRoot(head.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Service{db: beaconDB}
+
+	err = s.verifyContiguity(ctx, 1, headRoot[:])
+	if errors.Cause(err) != ErrSyncedChainGap {
+		t.Fatalf("Wanted error %v, received %v", ErrSyncedChainGap, err)
+	}
+	if !strings.Contains(err.Error(), "block at slot 8") {
+		t.Errorf("Wanted the gap before slot 8 to be reported, received %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package initialsync
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// failedPeerCooldown is how long a peer which failed a block request is not requested blocks from
+// in the following batches of initial sync.
+const failedPeerCooldown = 30 * time.Second
+
+// peerCooldowns keeps the time until which peers which failed a block request are cooling down.
+// The zero value is ready to use.
+type peerCooldowns struct {
+	sync.Mutex
+	until map[peer.ID]time.Time
+}
+
+// start the cooldown of the peer which failed a request at now.
+func (pc *peerCooldowns) start(pid peer.ID, now time.Time) {
+	pc.Lock()
+	defer pc.Unlock()
+	if pc.until == nil {
+		pc.until = make(map[peer.ID]time.Time)
+	}
+	pc.until[pid] = now.Add(failedPeerCooldown)
+}
+
+// filter the peers to those which are not cooling down at now. Peers whose cooldown is over are
+// requeued. If all peers are cooling down, they are all returned so that sync still progresses.
+func (pc *peerCooldowns) filter(peers []peer.ID, now time.Time) []peer.ID {
+	pc.Lock()
+	defer pc.Unlock()
+	available := make([]peer.ID, 0, len(peers))
+	for _, pid := range peers {
+		until, ok := pc.until[pid]
+		if ok && now.Before(until) {
+			continue
+		}
+		delete(pc.until, pid)
+		available = append(available, pid)
+	}
+	if len(available) == 0 {
+		return peers
+	}
+	return available
+}
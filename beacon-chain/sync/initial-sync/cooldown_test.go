@@ -0,0 +1,54 @@
+package initialsync
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestPeerCooldowns_RequeuesAfterCooldown(t *testing.T) {
+	pc := &peerCooldowns{}
+	peers := []peer.ID{"a", "b", "c"}
+	now := time.Now()
+	pc.start("b", now)
+
+	if got := pc.filter(peers, now.Add(failedPeerCooldown/2)); !reflect.DeepEqual(got, []peer.ID{"a", "c"}) {
+		t.Errorf("Wanted the failed peer to be cooling down, received peers %v", got)
+	}
+	if got := pc.filter(peers, now.Add(failedPeerCooldown)); !reflect.DeepEqual(got, peers) {
+		t.Errorf("Wanted the failed peer to be requeued after its cooldown, received peers %v", got)
+	}
+}
+
+func TestPeerCooldowns_AllCoolingDown(t *testing.T) {
+	pc := &peerCooldowns{}
+	peers := []peer.ID{"a", "b"}
+	now := time.Now()
+	for _, pid := range peers {
+		pc.start(pid, now)
+	}
+	if got := pc.filter(peers, now); !reflect.DeepEqual(got, peers) {
+		t.Errorf("Wanted all peers when all of them are cooling down, received %v", got)
+	}
+}
+
+func TestPeersExcept_DoesNotModifyPeers(t *testing.T) {
+	peers := []peer.ID{"a", "b", "c", "d"}
+	// Removing a peer in place with append(peers[:i], peers[i+1:]...) shifts the following peers
+	// into the shared backing array, corrupting the slice other goroutines are iterating.
+	aliased := append([]peer.ID{}, peers...)
+	_ = append(aliased[:1], aliased[2:]...)
+	if reflect.DeepEqual(aliased, peers) {
+		t.Fatal("Expected the in place removal to corrupt the original slice")
+	}
+
+	ps := peersExcept(peers, 1)
+	if !reflect.DeepEqual(ps, []peer.ID{"a", "c", "d"}) {
+		t.Errorf("Wanted peers [a c d], received %v", ps)
+	}
+	if !reflect.DeepEqual(peers, []peer.ID{"a", "b", "c", "d"}) {
+		t.Errorf("Expected the peers not to be modified, received %v", peers)
+	}
+}
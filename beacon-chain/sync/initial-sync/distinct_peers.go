@@ -0,0 +1,75 @@
+package initialsync
+
+import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+)
+
+// distinctPeers keeps the set of distinct peers queried during a sync session. The zero value is
+// ready to use.
+type distinctPeers struct {
+	sync.Mutex
+	queried map[peer.ID]bool
+}
+
+// admit filters the peers to those which may be queried without the number of distinct peers
+// exceeding max. Peers which were queried before are always admitted, new peers only while there
+// is room left under max. A max of 0 admits all peers. Admitted peers only count as queried once
+// a request is sent to them.
+func (dp *distinctPeers) admit(peers []peer.ID, max int) []peer.ID {
+	dp.Lock()
+	defer dp.Unlock()
+	admitted := make([]peer.ID, 0, len(peers))
+	for _, pid := range peers {
+		if dp.queried[pid] {
+			admitted = append(admitted, pid)
+		}
+	}
+	room := max - len(dp.queried)
+	for _, pid := range peers {
+		if dp.queried[pid] || (max > 0 && room <= 0) {
+			continue
+		}
+		room--
+		admitted = append(admitted, pid)
+	}
+	return admitted
+}
+
+// record the peer as queried.
+func (dp *distinctPeers) record(pid peer.ID) {
+	dp.Lock()
+	defer dp.Unlock()
+	if dp.queried == nil {
+		dp.queried = make(map[peer.ID]bool)
+	}
+	dp.queried[pid] = true
+}
+
+// reset the peers queried, starting a new session.
+func (dp *distinctPeers) reset() {
+	dp.Lock()
+	defer dp.Unlock()
+	dp.queried = nil
+}
+
+// count of the distinct peers queried.
+func (dp *distinctPeers) count() int {
+	dp.Lock()
+	defer dp.Unlock()
+	return len(dp.queried)
+}
+
+// DistinctPeersQueried returns the number of distinct peers blocks have been requested from since
+// the current sync session started.
+func (s *Service) DistinctPeersQueried() int {
+	return s.distinctPeers.count()
+}
+
+// admitPeers filters the peers to those which may be queried under the configured maximum number
+// of distinct peers, preferring peers which were already queried.
+func (s *Service) admitPeers(peers []peer.ID) []peer.ID {
+	return s.distinctPeers.admit(peers, flags.Get().SyncMaxDistinctPeers)
+}
@@ -0,0 +1,55 @@
+package initialsync
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestDistinctPeers_Admit(t *testing.T) {
+	dp := &distinctPeers{}
+	admitted := dp.admit([]peer.ID{"a", "b", "c"}, 2 /* max */)
+	if len(admitted) != 2 || admitted[0] != "a" || admitted[1] != "b" {
+		t.Errorf("Wanted peers [a b] to be admitted, received %v", admitted)
+	}
+	if dp.count() != 0 {
+		t.Errorf("Wanted admitted peers not to count as queried before a request, received %d", dp.count())
+	}
+	for _, pid := range admitted {
+		dp.record(pid)
+	}
+
+	// Queried peers are preferred over new ones, and no new peers are admitted at the maximum.
+	admitted = dp.admit([]peer.ID{"c", "d", "b"}, 2 /* max */)
+	if len(admitted) != 1 || admitted[0] != "b" {
+		t.Errorf("Wanted only the queried peer b to be admitted, received %v", admitted)
+	}
+	if len(dp.admit([]peer.ID{"c", "d"}, 2 /* max */)) != 0 {
+		t.Error("Expected no new peers to be admitted at the maximum")
+	}
+	if dp.count() != 2 {
+		t.Errorf("Wanted 2 distinct peers queried, received %d", dp.count())
+	}
+
+	if admitted := dp.admit([]peer.ID{"c", "d"}, 0 /* max */); len(admitted) != 2 {
+		t.Errorf("Wanted all peers to be admitted without a maximum, received %v", admitted)
+	}
+}
+
+func TestDistinctPeers_Reset(t *testing.T) {
+	dp := &distinctPeers{}
+	dp.record("a")
+	dp.record("b")
+	if len(dp.admit([]peer.ID{"c"}, 2 /* max */)) != 0 {
+		t.Fatal("Expected no new peers to be admitted at the maximum")
+	}
+
+	// Peers queried in a previous session don't count against the maximum of a new one.
+	dp.reset()
+	if dp.count() != 0 {
+		t.Errorf("Wanted no distinct peers queried after a reset, received %d", dp.count())
+	}
+	if admitted := dp.admit([]peer.ID{"c", "d"}, 2 /* max */); len(admitted) != 2 {
+		t.Errorf("Wanted new peers to be admitted after a reset, received %v", admitted)
+	}
+}
@@ -0,0 +1,344 @@
+package initialsync
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	mock "github.com/prysmaticlabs/prysm/beacon-chain/blockchain/testing"
+	"github.com/prysmaticlabs/prysm/beacon-chain/core/helpers"
+	"github.com/prysmaticlabs/prysm/beacon-chain/db"
+	dbtest "github.com/prysmaticlabs/prysm/beacon-chain/db/testing"
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+	p2pt "github.com/prysmaticlabs/prysm/beacon-chain/p2p/testing"
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/featureconfig"
+	logTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// syncTestHarness is an initial sync service wired up to run round robin sync end-to-end against
+// mock peers serving a canned chain. Received blocks are recorded by the mock chain service.
+type syncTestHarness struct {
+	service *Service
+	chain   *mock.ChainService
+	p2p     *p2pt.TestP2P
+	db      db.Database
+}
+
+// newSyncTestHarness connects the peers, which serve blocks of the canned chain up to the current
+// slot, to a service whose chain and db hold only the genesis block. Call teardown once done.
+func newSyncTestHarness(t *testing.T, currentSlot uint64, peers []*peerData) (h *syncTestHarness, teardown func()) {
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	connectPeers(t, p, peers, p.Peers())
+
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  genesisRoot[:],
+		DB:    beaconDB,
+	}
+	h = &syncTestHarness{
+		service: &Service{
+			chain:         mc,
+			p2p:           p,
+			db:            beaconDB,
+			chainStarted:  true,
+			stateNotifier: mc.StateNotifier(),
+		},
+		chain: mc,
+		p2p:   p,
+		db:    beaconDB,
+	}
+	return h, func() {
+		dbtest.TeardownDB(t, beaconDB)
+	}
+}
+
+// receivedSlots returns the slots of the blocks received by the chain service, in order.
+func (h *syncTestHarness) receivedSlots() []uint64 {
+	slots := make([]uint64, len(h.chain.BlocksReceived))
+	for i, blk := range h.chain.BlocksReceived {
+		slots[i] = blk.Block.Slot
+	}
+	return slots
+}
+
+func TestRoundRobinSync_EndToEnd(t *testing.T) {
+	hook := logTest.NewGlobal()
+	currentSlot := uint64(160)
+	// The peers have finalized epoch 2, so blocks up to the end of epoch 2 are synced in Step 1 and
+	// the remaining blocks up to the current slot in Step 2.
+	var peers []*peerData
+	for i := 0; i < 3; i++ {
+		peers = append(peers, &peerData{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+		})
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if h.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", h.chain.HeadSlot(), currentSlot)
+	}
+	slots := h.receivedSlots()
+	if len(slots) != int(currentSlot) {
+		t.Fatalf("Wanted %d blocks to be received, received %d", currentSlot, len(slots))
+	}
+	for i, slot := range slots {
+		if slot != uint64(i+1) {
+			t.Fatalf("Wanted every block received once in slot order, received slot %d at position %d", slot, i)
+		}
+	}
+	var headSyncRequests int
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Sending batch block request" {
+			headSyncRequests++
+		}
+	}
+	if headSyncRequests == 0 {
+		t.Error("Expected blocks after the finalized epoch to be requested when syncing to head")
+	}
+}
+
+// receiveMethodRecorder counts the blocks received through each chain service method used by
+// initial sync, recording the blocks in the wrapped mock chain service.
+type receiveMethodRecorder struct {
+	*mock.ChainService
+	full         int
+	noForkchoice int
+}
+
+func (r *receiveMethodRecorder) ReceiveBlock(ctx context.Context, blk *eth.SignedBeaconBlock) error {
+	r.full++
+	return r.ChainService.ReceiveBlockNoPubsubForkchoice(ctx, blk)
+}
+
+func (r *receiveMethodRecorder) ReceiveBlockNoPubsubForkchoice(ctx context.Context, blk *eth.SignedBeaconBlock) error {
+	r.noForkchoice++
+	return r.ChainService.ReceiveBlockNoPubsubForkchoice(ctx, blk)
+}
+
+func TestRoundRobinSync_HeadSyncReceiveMethod(t *testing.T) {
+	currentSlot := uint64(160)
+	for _, fullReceive := range []bool{false, true} {
+		flags.Init(&flags.GlobalFlags{SyncHeadForkchoice: fullReceive})
+		peers := []*peerData{
+			{
+				blocks:         makeSequence(1, currentSlot),
+				finalizedEpoch: 2,
+				headSlot:       currentSlot,
+			},
+		}
+		h, teardown := newSyncTestHarness(t, currentSlot, peers)
+		recorder := &receiveMethodRecorder{ChainService: h.chain}
+		h.service.chain = recorder
+
+		if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+			t.Fatal(err)
+		}
+		teardown()
+
+		if total := recorder.full + recorder.noForkchoice; total != int(currentSlot) {
+			t.Errorf("Full receive %v: wanted %d blocks received, received %d", fullReceive, currentSlot, total)
+		}
+		// Blocks before the finalized epoch are always received without fork choice in Step 1.
+		if recorder.noForkchoice == 0 {
+			t.Errorf("Full receive %v: wanted blocks synced to the finalized epoch to skip fork choice", fullReceive)
+		}
+		if fullReceive && recorder.full == 0 {
+			t.Error("Wanted blocks synced to the chain head to be received with ReceiveBlock")
+		}
+		if !fullReceive && recorder.full != 0 {
+			t.Errorf("Wanted no blocks received with ReceiveBlock, received %d", recorder.full)
+		}
+	}
+	flags.Init(nil)
+}
+
+// batchRecorder records the sizes of the block batches received by the wrapped mock chain service.
+type batchRecorder struct {
+	*mock.ChainService
+	batchSizes []int
+}
+
+func (r *batchRecorder) ReceiveBlockBatch(ctx context.Context, blks []*eth.SignedBeaconBlock) error {
+	r.batchSizes = append(r.batchSizes, len(blks))
+	return r.ChainService.ReceiveBlockBatch(ctx, blks)
+}
+
+func TestRoundRobinSync_BatchSaveBlocks(t *testing.T) {
+	featureconfig.Init(&featureconfig.Flags{InitSyncNoVerify: true})
+	defer featureconfig.Init(nil)
+	batchSize := 16
+	flags.Init(&flags.GlobalFlags{InitSyncBatchSaveBlocks: batchSize})
+	defer flags.Init(nil)
+
+	currentSlot := uint64(160)
+	peers := []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+		},
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+	recorder := &batchRecorder{ChainService: h.chain}
+	h.service.chain = recorder
+
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if h.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", h.chain.HeadSlot(), currentSlot)
+	}
+	slots := h.receivedSlots()
+	if len(slots) != int(currentSlot) {
+		t.Fatalf("Wanted %d blocks to be received, received %d", currentSlot, len(slots))
+	}
+	for i, slot := range slots {
+		if slot != uint64(i+1) {
+			t.Fatalf("Wanted every block received once in slot order, received slot %d at position %d", slot, i)
+		}
+	}
+	if len(recorder.batchSizes) == 0 {
+		t.Fatal("Expected blocks to be received in batches")
+	}
+	for _, size := range recorder.batchSizes {
+		if size > batchSize {
+			t.Errorf("Wanted batches of at most %d blocks, received %d", batchSize, size)
+		}
+	}
+}
+
+func TestRoundRobinSync_HeadSyncRetriesNextBestPeer(t *testing.T) {
+	currentSlot := uint64(160)
+	tests := []struct {
+		name          string
+		secondaryFail bool
+	}{
+		{
+			name: "secondary peer completes head sync",
+		},
+		{
+			name:          "all candidate peers fail",
+			secondaryFail: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Both peers serve the finalized epochs, but the best peer, reporting the highest
+			// head slot, fails every request after the finalized epoch.
+			secondary := &peerData{
+				blocks:         makeSequence(1, currentSlot),
+				finalizedEpoch: 2,
+				headSlot:       currentSlot - 1,
+			}
+			if tt.secondaryFail {
+				secondary.failureSlots = makeSequence(100, currentSlot)
+			}
+			peers := []*peerData{
+				{
+					blocks:         makeSequence(1, currentSlot),
+					failureSlots:   makeSequence(100, currentSlot),
+					finalizedEpoch: 2,
+					headSlot:       currentSlot,
+				},
+				secondary,
+			}
+			h, teardown := newSyncTestHarness(t, currentSlot, peers)
+			defer teardown()
+
+			err := h.service.roundRobinSync(makeGenesisTime(currentSlot))
+			if tt.secondaryFail {
+				if err == nil || !strings.Contains(err.Error(), "all 2 candidate peers failed") {
+					t.Fatalf("Wanted an error once all candidate peers failed, received %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.chain.HeadSlot() != currentSlot {
+				t.Errorf("Head slot (%d) is not currentSlot (%d)", h.chain.HeadSlot(), currentSlot)
+			}
+		})
+	}
+}
+
+func TestRoundRobinSync_HeadSyncForgetsFailedPeersAfterSuccess(t *testing.T) {
+	currentSlot := uint64(440)
+	// The first request after the finalized epoch fails on the best peer and is served by
+	// the secondary peer, which in turn fails the second request. The best peer must be a
+	// candidate again for the second request, as it only failed the first one.
+	peers := []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			failureSlots:   makeSequence(100, 300),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+		},
+		{
+			blocks:         makeSequence(1, currentSlot),
+			failureSlots:   makeSequence(380, 390),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot - 1,
+		},
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if h.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", h.chain.HeadSlot(), currentSlot)
+	}
+}
+
+func TestRoundRobinSync_HeadSyncFollowsCurrentSlot(t *testing.T) {
+	currentSlot := uint64(100)
+	// The current slot advances during the first request after the finalized epoch, and the
+	// block of the new slot is only available to the request following it.
+	genesis := makeGenesisTime(currentSlot + 1).Add(time.Second)
+	peer := &peerData{
+		blocks:         makeSequence(1, currentSlot),
+		finalizedEpoch: 2,
+		headSlot:       currentSlot,
+	}
+	peer.onRequest = func(req *p2ppb.BeaconBlocksByRangeRequest) {
+		if req.StartSlot > helpers.StartSlot(3) && req.StartSlot <= currentSlot {
+			for helpers.SlotsSince(genesis) <= currentSlot {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		if req.StartSlot == currentSlot+1 {
+			peer.blocks = append(peer.blocks, currentSlot+1)
+		}
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot+1, []*peerData{peer})
+	defer teardown()
+
+	if err := h.service.roundRobinSync(genesis); err != nil {
+		t.Fatal(err)
+	}
+	if h.chain.HeadSlot() != currentSlot+1 {
+		t.Errorf("Head slot (%d) is not the advanced current slot (%d)", h.chain.HeadSlot(), currentSlot+1)
+	}
+}
@@ -0,0 +1,74 @@
+package initialsync
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// misbehaviorWindow is the number of most recent peer interactions the misbehavior rate is
+	// computed over.
+	misbehaviorWindow = 100
+	// minMisbehaviorSamples is the number of peer interactions required before the misbehavior rate
+	// is considered meaningful.
+	minMisbehaviorSamples = 20
+)
+
+// ErrPeerMisbehaviorThreshold is returned when initial sync halts because too many of the recent
+// peer interactions flagged misbehavior, which may indicate an eclipse attack or a network-wide
+// problem.
+var ErrPeerMisbehaviorThreshold = errors.New("peer misbehavior rate exceeded threshold")
+
+// misbehaviorBreaker tracks whether the most recent peer interactions flagged misbehavior. The
+// zero value is ready to use.
+type misbehaviorBreaker struct {
+	sync.Mutex
+	outcomes    [misbehaviorWindow]bool
+	next        int
+	count       int
+	misbehaving int
+}
+
+// record the outcome of a peer interaction, evicting the oldest outcome once the window is full.
+func (mb *misbehaviorBreaker) record(misbehaved bool) {
+	mb.Lock()
+	defer mb.Unlock()
+	if mb.count == misbehaviorWindow {
+		if mb.outcomes[mb.next] {
+			mb.misbehaving--
+		}
+	} else {
+		mb.count++
+	}
+	mb.outcomes[mb.next] = misbehaved
+	if misbehaved {
+		mb.misbehaving++
+	}
+	mb.next = (mb.next + 1) % misbehaviorWindow
+}
+
+// rate returns the fraction of the recorded peer interactions which flagged misbehavior, along
+// with the number of recorded interactions.
+func (mb *misbehaviorBreaker) rate() (float64, int) {
+	mb.Lock()
+	defer mb.Unlock()
+	if mb.count == 0 {
+		return 0, 0
+	}
+	return float64(mb.misbehaving) / float64(mb.count), mb.count
+}
+
+// check returns ErrPeerMisbehaviorThreshold if the misbehavior rate exceeds the threshold, once
+// enough peer interactions were recorded for the rate to be meaningful. A threshold of 0 disables
+// the check.
+func (mb *misbehaviorBreaker) check(threshold float64) error {
+	if threshold <= 0 {
+		return nil
+	}
+	rate, samples := mb.rate()
+	if samples >= minMisbehaviorSamples && rate > threshold {
+		return errors.Wrapf(ErrPeerMisbehaviorThreshold, "%.2f of the last %d peer interactions flagged misbehavior, threshold is %.2f", rate, samples, threshold)
+	}
+	return nil
+}
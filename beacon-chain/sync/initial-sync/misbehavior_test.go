@@ -0,0 +1,87 @@
+package initialsync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	mock "github.com/prysmaticlabs/prysm/beacon-chain/blockchain/testing"
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+	p2pt "github.com/prysmaticlabs/prysm/beacon-chain/p2p/testing"
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	logTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestMisbehaviorBreaker_RollingWindow(t *testing.T) {
+	mb := &misbehaviorBreaker{}
+	for i := 0; i < minMisbehaviorSamples-1; i++ {
+		mb.record(true /* misbehaved */)
+	}
+	if err := mb.check(0.5); err != nil {
+		t.Errorf("Wanted no error before enough interactions were recorded, received %v", err)
+	}
+	mb.record(true /* misbehaved */)
+	if err := mb.check(0.5); errors.Cause(err) != ErrPeerMisbehaviorThreshold {
+		t.Errorf("Wanted error %v, received %v", ErrPeerMisbehaviorThreshold, err)
+	}
+	if err := mb.check(0); err != nil {
+		t.Errorf("Wanted no error with the check disabled, received %v", err)
+	}
+
+	// Misbehavior falls out of the window as interactions are recorded.
+	for i := 0; i < misbehaviorWindow; i++ {
+		mb.record(false /* misbehaved */)
+	}
+	if rate, samples := mb.rate(); rate != 0 || samples != misbehaviorWindow {
+		t.Errorf("Wanted a rate of 0 over %d interactions, received %.2f over %d", misbehaviorWindow, rate, samples)
+	}
+	if err := mb.check(0.5); err != nil {
+		t.Errorf("Wanted no error once misbehavior left the window, received %v", err)
+	}
+}
+
+func TestRoundRobinSync_HaltsOnPeerMisbehavior(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{SyncMaxMisbehaviorRate: 0.5})
+	defer flags.Init(nil)
+	hook := logTest.NewGlobal()
+
+	initializeRootCache(makeSequence(1, 128), t)
+	p := p2pt.NewTestP2P(t)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:           makeSequence(1, 128),
+			finalizedEpoch:   3,
+			headSlot:         128,
+			outOfRangeBlocks: true,
+		},
+	}, p.Peers())
+	s := &Service{
+		chain:        &mock.ChainService{State: &p2ppb.BeaconState{}},
+		p2p:          p,
+		chainStarted: true,
+	}
+
+	// Drive the misbehavior rate above the threshold with responses of a misbehaving peer.
+	req := &p2ppb.BeaconBlocksByRangeRequest{
+		HeadBlockRoot: []byte("head_root"),
+		StartSlot:     1,
+		Count:         32,
+		Step:          1,
+	}
+	pid := p.Peers().Connected()[0]
+	for i := 0; i < minMisbehaviorSamples; i++ {
+		if _, err := s.requestBlocks(context.Background(), req, pid); errors.Cause(err) != ErrPeerMisbehavior {
+			t.Fatalf("Wanted error caused by %v, received %v", ErrPeerMisbehavior, err)
+		}
+	}
+	hook.Reset()
+
+	if err := s.roundRobinSync(makeGenesisTime(128)); errors.Cause(err) != ErrPeerMisbehaviorThreshold {
+		t.Fatalf("Wanted error %v, received %v", ErrPeerMisbehaviorThreshold, err)
+	}
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Requesting blocks" {
+			t.Fatal("Expected no blocks to be requested once the breaker tripped")
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package initialsync
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+)
+
+// scoreJitter is the maximum random amount added to a peer's score when ordering peers, so that
+// peers with similar scores, including peers without any history yet, are still mixed up.
+const scoreJitter = 0.1
+
+// minReliabilitySamples is the number of requests to a peer which are scored before the peer may
+// be excluded for falling below the minimum reliability. Peers with fewer samples are in a grace
+// period.
+const minReliabilitySamples = 5
+
+// outOfOrderPenalty is the fraction of a failure an out of order response counts as in a peer's
+// score. Such responses are still usable as blocks are sorted before processing.
+const outOfOrderPenalty = 0.5
+
+// PeerScore tracks how reliably and quickly a peer has served block requests during initial sync.
+type PeerScore struct {
+	Requests       uint64
+	Failures       uint64
+	AverageLatency time.Duration
+	// OutOfOrderResponses counts the successful responses in which blocks were not in ascending
+	// slot order.
+	OutOfOrderResponses uint64
+	// EmptyResponses counts the valid responses without any blocks. These are not failures, as the
+	// requested slots may have been skipped, but aren't successes either.
+	EmptyResponses uint64
+}
+
+// successes is the number of responses which served blocks.
+func (p PeerScore) successes() uint64 {
+	return p.Requests - p.Failures - p.EmptyResponses
+}
+
+// Score of the peer between 0 and 1. It is the smoothed rate of responses which served blocks,
+// reduced by out of order responses and the average response latency. Peers without any history
+// score 0.5.
+func (p PeerScore) Score() float64 {
+	successes := float64(p.successes()) - outOfOrderPenalty*float64(p.OutOfOrderResponses)
+	successRate := (successes + 1) / float64(p.Requests+2)
+	return successRate / (1 + p.AverageLatency.Seconds())
+}
+
+// peerScorer keeps the scores of the peers blocks were requested from. The zero value is ready to
+// use.
+type peerScorer struct {
+	sync.RWMutex
+	scores map[peer.ID]*PeerScore
+}
+
+// record the outcome of a block request to the peer. The latency is only averaged over the
+// responses which served blocks.
+func (ps *peerScorer) record(pid peer.ID, latency time.Duration, failed bool) {
+	ps.Lock()
+	defer ps.Unlock()
+	score := ps.scoreOf(pid)
+	score.Requests++
+	if failed {
+		score.Failures++
+		return
+	}
+	score.AverageLatency += (latency - score.AverageLatency) / time.Duration(score.successes())
+}
+
+// recordEmpty records a valid response of the peer without any blocks.
+func (ps *peerScorer) recordEmpty(pid peer.ID) {
+	ps.Lock()
+	defer ps.Unlock()
+	score := ps.scoreOf(pid)
+	score.Requests++
+	score.EmptyResponses++
+}
+
+// scoreOf returns the score of the peer to update, adding it if the peer has no score yet. The
+// caller must hold the lock.
+func (ps *peerScorer) scoreOf(pid peer.ID) *PeerScore {
+	if ps.scores == nil {
+		ps.scores = make(map[peer.ID]*PeerScore)
+	}
+	score, ok := ps.scores[pid]
+	if !ok {
+		score = &PeerScore{}
+		ps.scores[pid] = score
+	}
+	return score
+}
+
+// recordOutOfOrder notes that the last successful response of the peer had blocks out of slot
+// order.
+func (ps *peerScorer) recordOutOfOrder(pid peer.ID) {
+	ps.Lock()
+	defer ps.Unlock()
+	if score, ok := ps.scores[pid]; ok {
+		score.OutOfOrderResponses++
+	}
+}
+
+// score of the peer, or the score of a peer without history if the peer is unknown.
+func (ps *peerScorer) score(pid peer.ID) float64 {
+	ps.RLock()
+	defer ps.RUnlock()
+	if score, ok := ps.scores[pid]; ok {
+		return score.Score()
+	}
+	return PeerScore{}.Score()
+}
+
+// reliable returns true if the peer's score is at least min, or if too few requests to the peer
+// were scored to tell.
+func (ps *peerScorer) reliable(pid peer.ID, min float64) bool {
+	ps.RLock()
+	defer ps.RUnlock()
+	score, ok := ps.scores[pid]
+	if !ok || score.Requests < minReliabilitySamples {
+		return true
+	}
+	return score.Score() >= min
+}
+
+// snapshot of all the recorded peer scores.
+func (ps *peerScorer) snapshot() map[peer.ID]PeerScore {
+	ps.RLock()
+	defer ps.RUnlock()
+	scores := make(map[peer.ID]PeerScore, len(ps.scores))
+	for pid, score := range ps.scores {
+		scores[pid] = *score
+	}
+	return scores
+}
+
+// PeerScores returns a snapshot of the reliability scores of the peers blocks have been requested
+// from, for debugging.
+func (s *Service) PeerScores() map[peer.ID]PeerScore {
+	return s.peerScores.snapshot()
+}
+
+// orderPeers shuffles the peers and, when preferring reliable peers, sorts them so that peers with
+// a higher score are queried first. A small random jitter keeps new peers from being starved.
+// Otherwise, the peers are rotated so that each of them is queried first in turn.
+func (s *Service) orderPeers(randGenerator *rand.Rand, peers []peer.ID) {
+	shufflePeers(randGenerator, peers)
+	if !flags.Get().PreferReliablePeers {
+		s.rotatePeers(peers)
+		return
+	}
+	keys := make(map[peer.ID]float64, len(peers))
+	for _, pid := range peers {
+		keys[pid] = s.peerScores.score(pid) + randGenerator.Float64()*scoreJitter
+	}
+	sort.SliceStable(peers, func(i, j int) bool {
+		return keys[peers[i]] > keys[peers[j]]
+	})
+}
+
+// reliablePeers filters the peers to those meeting the configured minimum reliability.
+func (s *Service) reliablePeers(peers []peer.ID) []peer.ID {
+	min := flags.Get().MinPeerReliability
+	if min <= 0 {
+		return peers
+	}
+	reliable := make([]peer.ID, 0, len(peers))
+	for _, pid := range peers {
+		if s.peerScores.reliable(pid, min) {
+			reliable = append(reliable, pid)
+		}
+	}
+	return reliable
+}
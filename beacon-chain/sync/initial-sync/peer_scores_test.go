@@ -0,0 +1,202 @@
+package initialsync
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+	"github.com/prysmaticlabs/prysm/beacon-chain/p2p/peers"
+	p2pt "github.com/prysmaticlabs/prysm/beacon-chain/p2p/testing"
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+)
+
+func TestPeerScorer_Record(t *testing.T) {
+	s := &Service{}
+	pid := peer.ID("a")
+	s.peerScores.record(pid, 10*time.Millisecond, false /* failed */)
+	s.peerScores.record(pid, 30*time.Millisecond, false /* failed */)
+	s.peerScores.record(pid, time.Second, true /* failed */)
+
+	score, ok := s.PeerScores()[pid]
+	if !ok {
+		t.Fatal("Expected a score for the peer")
+	}
+	if score.Requests != 3 || score.Failures != 1 {
+		t.Errorf("Wanted 3 requests and 1 failure, received %d requests and %d failures", score.Requests, score.Failures)
+	}
+	if score.AverageLatency != 20*time.Millisecond {
+		t.Errorf("Wanted average latency of %v, received %v", 20*time.Millisecond, score.AverageLatency)
+	}
+	if s.peerScores.score("unknown") != (PeerScore{}).Score() {
+		t.Error("Expected an unknown peer to have the score of a peer without history")
+	}
+}
+
+func TestOrderPeers_PrefersReliablePeer(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{PreferReliablePeers: true})
+	defer flags.Init(nil)
+
+	s := &Service{}
+	reliable := peer.ID("reliable")
+	unreliable := []peer.ID{"b", "c", "d"}
+	// Earlier batches: the reliable peer answers every request quickly, while the other peers are
+	// slow and fail half of the time.
+	for i := 0; i < 20; i++ {
+		s.peerScores.record(reliable, 10*time.Millisecond, false /* failed */)
+		for _, pid := range unreliable {
+			s.peerScores.record(pid, 500*time.Millisecond, i%2 == 0 /* failed */)
+		}
+	}
+
+	for seed := int64(0); seed < 50; seed++ {
+		peers := append([]peer.ID{}, unreliable...)
+		peers = append(peers, reliable)
+		s.orderPeers(rand.New(rand.NewSource(seed)), peers)
+		if peers[0] != reliable {
+			t.Errorf("Seed %d: wanted reliable peer to be queried first, received order %v", seed, peers)
+		}
+	}
+}
+
+func TestOrderPeers_EmptyRespondingPeerMovesDown(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{PreferReliablePeers: true})
+	defer flags.Init(nil)
+
+	initializeRootCache(makeSequence(1, 64), t)
+	p := p2pt.NewTestP2P(t)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         makeSequence(1, 64),
+			finalizedEpoch: 1,
+			headSlot:       64,
+		},
+		{
+			finalizedEpoch: 1,
+			headSlot:       64,
+		},
+	}, p.Peers())
+	s := &Service{p2p: p}
+
+	req := &p2ppb.BeaconBlocksByRangeRequest{
+		HeadBlockRoot: []byte("head_root"),
+		StartSlot:     1,
+		Count:         64,
+		Step:          1,
+	}
+	var empty peer.ID
+	for i := 0; i < minReliabilitySamples; i++ {
+		for _, pid := range p.Peers().Connected() {
+			resp, err := s.requestBlocks(context.Background(), req, pid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp) == 0 {
+				empty = pid
+			}
+		}
+	}
+	if score := s.PeerScores()[empty]; score.EmptyResponses != minReliabilitySamples || score.Failures != 0 {
+		t.Errorf("Wanted %d empty responses and no failures, received %+v", minReliabilitySamples, score)
+	}
+
+	for seed := int64(0); seed < 50; seed++ {
+		peers := p.Peers().Connected()
+		s.orderPeers(rand.New(rand.NewSource(seed)), peers)
+		if peers[len(peers)-1] != empty {
+			t.Errorf("Seed %d: wanted the peer responding without blocks to be queried last, received order %v", seed, peers)
+		}
+	}
+}
+
+func TestOrderPeers_DisabledKeepsShuffle(t *testing.T) {
+	s := &Service{}
+	reliable := peer.ID("reliable")
+	for i := 0; i < 20; i++ {
+		s.peerScores.record(reliable, 10*time.Millisecond, false /* failed */)
+	}
+	peers := []peer.ID{"b", "c", "d", reliable}
+	want := append([]peer.ID{}, peers...)
+	shufflePeers(rand.New(rand.NewSource(1)), want)
+
+	s.orderPeers(rand.New(rand.NewSource(1)), peers)
+	for i := range peers {
+		if peers[i] != want[i] {
+			t.Fatalf("Wanted shuffled order %v, received %v", want, peers)
+		}
+	}
+}
+
+func TestOrderPeers_RotatesFirstPeer(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{DisablePeerShuffle: true})
+	defer flags.Init(nil)
+
+	s := &Service{}
+	peers := []peer.ID{"a", "b", "c"}
+	firstCounts := make(map[peer.ID]int)
+	batches := 30
+	for i := 0; i < batches; i++ {
+		ordered := append([]peer.ID{}, peers...)
+		s.orderPeers(rand.New(rand.NewSource(int64(i))), ordered)
+		firstCounts[ordered[0]]++
+	}
+	for _, pid := range peers {
+		if firstCounts[pid] != batches/len(peers) {
+			t.Errorf("Wanted peer %s to be queried first in %d batches, received %d", pid, batches/len(peers), firstCounts[pid])
+		}
+	}
+}
+
+func TestReliablePeers_ExcludesUnreliablePeer(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{MinPeerReliability: 0.3})
+	defer flags.Init(nil)
+
+	s := &Service{}
+	peers := []peer.ID{"good", "bad", "new"}
+	for i := 0; i < minReliabilitySamples-1; i++ {
+		s.peerScores.record("good", 10*time.Millisecond, false /* failed */)
+		s.peerScores.record("bad", 10*time.Millisecond, true /* failed */)
+	}
+	// The failing peer is still in its grace period.
+	if got := s.reliablePeers(peers); !reflect.DeepEqual(got, peers) {
+		t.Errorf("Wanted all peers during the grace period, received %v", got)
+	}
+
+	s.peerScores.record("good", 10*time.Millisecond, false /* failed */)
+	s.peerScores.record("bad", 10*time.Millisecond, true /* failed */)
+	if got := s.reliablePeers(peers); !reflect.DeepEqual(got, []peer.ID{"good", "new"}) {
+		t.Errorf("Wanted the unreliable peer to be excluded, received %v", got)
+	}
+}
+
+func TestBestPeer_ExcludesUnreliablePeer(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{MinPeerReliability: 0.3})
+	defer flags.Init(nil)
+
+	currentSlot := uint64(100)
+	p := p2pt.NewTestP2P(t)
+	chainStates := map[peer.ID]uint64{
+		"reliable":   currentSlot - 1,
+		"unreliable": currentSlot,
+	}
+	for pid, headSlot := range chainStates {
+		p.Peers().Add(pid, nil, network.DirOutbound)
+		p.Peers().SetConnectionState(pid, peers.PeerConnected)
+		p.Peers().SetChainState(pid, &p2ppb.Status{HeadSlot: headSlot})
+	}
+	s := &Service{p2p: p}
+	if best := s.bestPeer(makeGenesisTime(currentSlot)); best != "unreliable" {
+		t.Fatalf("Wanted the peer with the highest head slot to be the best peer, received %s", best)
+	}
+
+	for i := 0; i < minReliabilitySamples; i++ {
+		s.peerScores.record("unreliable", time.Second, true /* failed */)
+	}
+	if best := s.bestPeer(makeGenesisTime(currentSlot)); best != "reliable" {
+		t.Errorf("Wanted the unreliable peer to be excluded, received best peer %s", best)
+	}
+}
@@ -0,0 +1,98 @@
+package initialsync
+
+import (
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/sirupsen/logrus"
+)
+
+// The totals of each peer are only kept in memory, as peer IDs are unbounded as metric labels.
+var (
+	blocksServedCounter = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "initial_sync_blocks_served_total",
+			Help: "Number of blocks served by peers during initial sync.",
+		},
+	)
+	bytesServedCounter = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "initial_sync_bytes_served_total",
+			Help: "Estimated number of bytes of blocks served by peers during initial sync.",
+		},
+	)
+)
+
+// PeerServed is the amount of blocks a peer served during an initial sync session.
+type PeerServed struct {
+	Blocks uint64
+	// Bytes is estimated from the encoded size of the served blocks.
+	Bytes uint64
+}
+
+// servedTracker accumulates the blocks served by each peer during an initial sync session. The
+// zero value is ready to use.
+type servedTracker struct {
+	sync.RWMutex
+	served map[peer.ID]*PeerServed
+}
+
+// record the blocks served by the peer in response to a request.
+func (st *servedTracker) record(pid peer.ID, blocks []*eth.SignedBeaconBlock) {
+	st.Lock()
+	defer st.Unlock()
+	if st.served == nil {
+		st.served = make(map[peer.ID]*PeerServed)
+	}
+	served, ok := st.served[pid]
+	if !ok {
+		served = &PeerServed{}
+		st.served[pid] = served
+	}
+	for _, blk := range blocks {
+		size := uint64(proto.Size(blk))
+		served.Blocks++
+		served.Bytes += size
+		blocksServedCounter.Inc()
+		bytesServedCounter.Add(float64(size))
+	}
+}
+
+// reset the totals, starting a new session.
+func (st *servedTracker) reset() {
+	st.Lock()
+	defer st.Unlock()
+	st.served = nil
+}
+
+// snapshot of the totals of all the peers which served blocks.
+func (st *servedTracker) snapshot() map[peer.ID]PeerServed {
+	st.RLock()
+	defer st.RUnlock()
+	served := make(map[peer.ID]PeerServed, len(st.served))
+	for pid, s := range st.served {
+		served[pid] = *s
+	}
+	return served
+}
+
+// PeersServed returns the blocks served by each peer during the current, or last, initial sync
+// session.
+func (s *Service) PeersServed() map[peer.ID]PeerServed {
+	return s.served.snapshot()
+}
+
+// logPeersServed logs the blocks served by each peer during the initial sync session.
+func (s *Service) logPeersServed() {
+	for pid, served := range s.served.snapshot() {
+		log.WithFields(logrus.Fields{
+			"peer":   pid.Pretty(),
+			"blocks": served.Blocks,
+			"bytes":  served.Bytes,
+		}).Debug("Blocks served by peer during initial sync")
+	}
+}
@@ -0,0 +1,67 @@
+package initialsync
+
+import (
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+)
+
+func TestRoundRobinSync_PeersServed(t *testing.T) {
+	currentSlot := uint64(160)
+	// Blocks are missing at some slots, so that only the blocks served are counted rather than the
+	// slots requested.
+	blocks := append(makeSequence(1, 40), makeSequence(50, currentSlot)...)
+	served := make([]uint64, 3)
+	var peers []*peerData
+	for i := range served {
+		counter := &served[i]
+		peers = append(peers, &peerData{
+			blocks:         blocks,
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+			onRequest: func(req *p2ppb.BeaconBlocksByRangeRequest) {
+				lastSlot := req.StartSlot + req.Step*(req.Count-1)
+				var count uint64
+				for _, slot := range blocks {
+					if slot >= req.StartSlot && slot <= lastSlot && (slot-req.StartSlot)%req.Step == 0 {
+						count++
+					}
+				}
+				atomic.AddUint64(counter, count)
+			},
+		})
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotBlocks []uint64
+	for pid, total := range h.service.PeersServed() {
+		if total.Bytes < total.Blocks {
+			t.Errorf("Peer %s: wanted an estimate of the bytes served, received %d bytes for %d blocks", pid, total.Bytes, total.Blocks)
+		}
+		gotBlocks = append(gotBlocks, total.Blocks)
+	}
+	var wantBlocks []uint64
+	for _, count := range served {
+		if count > 0 {
+			wantBlocks = append(wantBlocks, count)
+		}
+	}
+	sort.Slice(gotBlocks, func(i, j int) bool { return gotBlocks[i] < gotBlocks[j] })
+	sort.Slice(wantBlocks, func(i, j int) bool { return wantBlocks[i] < wantBlocks[j] })
+	if !reflect.DeepEqual(gotBlocks, wantBlocks) {
+		t.Errorf("Wanted peers to have served %v blocks, received %v", wantBlocks, gotBlocks)
+	}
+
+	h.service.served.reset()
+	if served := h.service.PeersServed(); len(served) != 0 {
+		t.Errorf("Wanted no blocks served after resetting the session, received %v", served)
+	}
+}
@@ -0,0 +1,75 @@
+package initialsync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/sirupsen/logrus"
+)
+
+// syncProgress is the marker initial sync saves to the db after processing batches of blocks, so
+// that it can resume from the last processed block after a restart.
+type syncProgress struct {
+	HeadSlot       uint64
+	HeadRoot       [32]byte
+	FinalizedEpoch uint64
+}
+
+// saveSyncProgress saves the current chain head and the finalized epoch synced towards.
+func (s *Service) saveSyncProgress(ctx context.Context, finalizedEpoch uint64) error {
+	progress := &syncProgress{
+		HeadSlot:       s.chain.HeadSlot(),
+		FinalizedEpoch: finalizedEpoch,
+	}
+	copy(progress.HeadRoot[:], s.chain.HeadRoot())
+	enc, err := ssz.Marshal(progress)
+	if err != nil {
+		return errors.Wrap(err, "could not encode sync progress")
+	}
+	return s.db.SaveInitialSyncProgress(ctx, enc)
+}
+
+// resumeAnchor returns the block sync last saved its progress at as the anchor to sync from, if
+// that block is ahead of the chain head and the db holds both the block at the saved slot and its
+// state. Otherwise sync proceeds from the chain head.
+func (s *Service) resumeAnchor(ctx context.Context) *syncAnchor {
+	enc, err := s.db.InitialSyncProgress(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Could not read initial sync progress")
+		return nil
+	}
+	if enc == nil {
+		return nil
+	}
+	progress := &syncProgress{}
+	if err := ssz.Unmarshal(enc, progress); err != nil {
+		log.WithError(err).Warn("Could not decode initial sync progress")
+		return nil
+	}
+	if progress.HeadSlot <= s.chain.HeadSlot() {
+		return nil
+	}
+	blk, err := s.db.Block(ctx, progress.HeadRoot)
+	if err != nil {
+		log.WithError(err).Warn("Could not retrieve the block of the initial sync progress")
+		return nil
+	}
+	if blk == nil || blk.Block == nil || blk.Block.Slot != progress.HeadSlot || !s.db.HasState(ctx, progress.HeadRoot) {
+		log.WithFields(logrus.Fields{
+			"slot": progress.HeadSlot,
+			"root": fmt.Sprintf("%#x", progress.HeadRoot),
+		}).Warn("Saved initial sync progress does not match the db, syncing from the chain head")
+		return nil
+	}
+	log.WithFields(logrus.Fields{
+		"slot":           progress.HeadSlot,
+		"root":           fmt.Sprintf("%#x", progress.HeadRoot),
+		"finalizedEpoch": progress.FinalizedEpoch,
+	}).Info("Resuming initial sync from saved progress")
+	return &syncAnchor{
+		root: progress.HeadRoot[:],
+		slot: progress.HeadSlot,
+	}
+}
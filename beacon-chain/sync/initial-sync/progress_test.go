@@ -0,0 +1,215 @@
+package initialsync
+
+import (
+	"context"
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
+	mock "github.com/prysmaticlabs/prysm/beacon-chain/blockchain/testing"
+	dbtest "github.com/prysmaticlabs/prysm/beacon-chain/db/testing"
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+	p2pt "github.com/prysmaticlabs/prysm/beacon-chain/p2p/testing"
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/bytesutil"
+)
+
+func TestRoundRobinSync_ResumesFromSavedProgress(t *testing.T) {
+	currentSlot := uint64(160)
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+		},
+	}, p.Peers())
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	genesis := makeGenesisTime(currentSlot)
+
+	// The first run stops after syncing to the finalized epoch, as if the node was restarted.
+	flags.Init(&flags.GlobalFlags{SyncCheckpointInterval: 1, SyncFinalityOnly: true})
+	defer flags.Init(nil)
+	first := &Service{
+		chain: &mock.ChainService{
+			State: &p2ppb.BeaconState{},
+			Root:  genesisRoot[:],
+			DB:    beaconDB,
+		},
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	if err := first.roundRobinSync(genesis); err != nil {
+		t.Fatal(err)
+	}
+	savedSlot := first.chain.HeadSlot()
+	if savedSlot == 0 {
+		t.Fatal("Expected the first run to sync blocks")
+	}
+
+	// After the restart, the chain head is back at genesis while the synced blocks, the state of
+	// the last one and the sync progress are in the db.
+	if err := beaconDB.SaveState(context.Background(), &p2ppb.BeaconState{Slot: savedSlot}, bytesutil.ToBytes32(first.chain.HeadRoot())); err != nil {
+		t.Fatal(err)
+	}
+	flags.Init(&flags.GlobalFlags{SyncCheckpointInterval: 1})
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  first.chain.HeadRoot(),
+		DB:    beaconDB,
+	}
+	second := &Service{
+		chain:        mc,
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	anchor := second.resumeAnchor(context.Background())
+	if anchor == nil {
+		t.Fatal("Expected sync to resume from the saved progress")
+	}
+	if anchor.slot != savedSlot {
+		t.Errorf("Wanted to resume from slot %d, resuming from slot %d", savedSlot, anchor.slot)
+	}
+	if err := second.roundRobinSyncFrom(genesis, anchor); err != nil {
+		t.Fatal(err)
+	}
+	if second.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", second.chain.HeadSlot(), currentSlot)
+	}
+	for _, blk := range mc.BlocksReceived {
+		if blk.Block.Slot <= savedSlot {
+			t.Errorf("Received block at slot %d, which was synced before the restart at slot %d", blk.Block.Slot, savedSlot)
+		}
+	}
+}
+
+func TestResync_ResumesFromSavedProgress(t *testing.T) {
+	currentSlot := uint64(160)
+	h, teardown := newSyncTestHarness(t, currentSlot, []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+		},
+	})
+	defer teardown()
+	genesis := makeGenesisTime(currentSlot)
+
+	flags.Init(&flags.GlobalFlags{SyncCheckpointInterval: 1, SyncFinalityOnly: true, MinimumSyncPeers: 1})
+	defer flags.Init(nil)
+	if err := h.service.roundRobinSync(genesis); err != nil {
+		t.Fatal(err)
+	}
+	savedSlot := h.chain.HeadSlot()
+	if err := h.db.SaveState(context.Background(), &p2ppb.BeaconState{Slot: savedSlot}, bytesutil.ToBytes32(h.chain.HeadRoot())); err != nil {
+		t.Fatal(err)
+	}
+
+	// The chain head falls back behind the saved progress before resyncing.
+	flags.Init(&flags.GlobalFlags{SyncCheckpointInterval: 1, MinimumSyncPeers: 1})
+	h.chain.State = &p2ppb.BeaconState{GenesisTime: uint64(genesis.Unix())}
+	h.chain.BlocksReceived = nil
+	h.service.syncComplete = make(chan struct{})
+	if err := h.service.Resync(); err != nil {
+		t.Fatal(err)
+	}
+	if h.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", h.chain.HeadSlot(), currentSlot)
+	}
+	for _, blk := range h.chain.BlocksReceived {
+		if blk.Block.Slot <= savedSlot {
+			t.Errorf("Received block at slot %d, which was synced before the resync at slot %d", blk.Block.Slot, savedSlot)
+		}
+	}
+}
+
+func TestResumeAnchor_IgnoresStaleProgress(t *testing.T) {
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	ctx := context.Background()
+	blk := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: 64}}
+	if err := beaconDB.SaveBlock(ctx, blk); err != nil {
+		t.Fatal(err)
+	}
+	root, err := ssz.HashTreeRoot(blk.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The block ahead of the chain head is in the db, but its state is not.
+	aheadBlk := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: 128}}
+	if err := beaconDB.SaveBlock(ctx, aheadBlk); err != nil {
+		t.Fatal(err)
+	}
+	aheadRoot, err := ssz.HashTreeRoot(aheadBlk.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The block ahead of the chain head is in the db with its state, at another slot than saved.
+	otherBlk := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: 130}}
+	if err := beaconDB.SaveBlock(ctx, otherBlk); err != nil {
+		t.Fatal(err)
+	}
+	otherRoot, err := ssz.HashTreeRoot(otherBlk.Block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := beaconDB.SaveState(ctx, &p2ppb.BeaconState{Slot: 130}, otherRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Service{
+		chain: &mock.ChainService{State: &p2ppb.BeaconState{Slot: 100}},
+		db:    beaconDB,
+	}
+	if anchor := s.resumeAnchor(ctx); anchor != nil {
+		t.Errorf("Wanted no anchor without saved progress, received %v", anchor)
+	}
+
+	tests := []struct {
+		name     string
+		progress *syncProgress
+	}{
+		{
+			name:     "progress behind the chain head",
+			progress: &syncProgress{HeadSlot: 64, HeadRoot: root},
+		},
+		{
+			name:     "progress block not in the db",
+			progress: &syncProgress{HeadSlot: 128, HeadRoot: [32]byte{'a'}},
+		},
+		{
+			name:     "progress block state not in the db",
+			progress: &syncProgress{HeadSlot: 128, HeadRoot: aheadRoot},
+		},
+		{
+			name:     "progress slot does not match the block",
+			progress: &syncProgress{HeadSlot: 140, HeadRoot: otherRoot},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := ssz.Marshal(tt.progress)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := beaconDB.SaveInitialSyncProgress(ctx, enc); err != nil {
+				t.Fatal(err)
+			}
+			if anchor := s.resumeAnchor(ctx); anchor != nil {
+				t.Errorf("Wanted no anchor, received %v", anchor)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package initialsync
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
 	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -13,7 +16,9 @@ import (
 	"github.com/paulbellamy/ratecounter"
 	"github.com/pkg/errors"
 	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
 	"github.com/prysmaticlabs/prysm/beacon-chain/core/helpers"
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
 	prysmsync "github.com/prysmaticlabs/prysm/beacon-chain/sync"
 	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
 	"github.com/prysmaticlabs/prysm/shared/bytesutil"
@@ -25,7 +30,55 @@ import (
 
 const blockBatchSize = 64
 const counterSeconds = 20
+const minRateSamples = 10
 const refreshTime = 6 * time.Second
+const staleFinalizedTime = 30 * time.Second
+const headSlotTolerance = 2
+
+// Errors returned by initial sync, wrapped with further context. The cause of a returned error can
+// be compared against them with errors.Cause to decide whether to retry sync.
+var (
+	// ErrNoPeers is returned when there are no peers left to request blocks from.
+	ErrNoPeers = errors.New("no peers left to request blocks")
+	// ErrRetryBudgetExhausted is returned when a failed block request was split across the
+	// remaining peers more times than the maximum failover depth allows.
+	ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+	// ErrFinalizedRootMismatch is returned when the finalized block root advertised by peers was
+	// not synced by the time initial sync reached the finalized epoch.
+	ErrFinalizedRootMismatch = errors.New("synced chain does not contain the finalized root advertised by peers")
+	// ErrPeerMisbehavior is returned when a peer responds with blocks that it was not asked for.
+	ErrPeerMisbehavior = errors.New("peer misbehavior")
+	// ErrInvalidRangeRequest is returned when a blocks by range request planned by initial sync
+	// breaks the invariants of such requests, so it is not sent.
+	ErrInvalidRangeRequest = errors.New("invalid blocks by range request")
+)
+
+// bestFinalizedCache holds the best finalized root and epoch reported by peers for a batch of
+// block requests. A batch may take long enough, e.g. when failing over to other peers, for these
+// values to go stale, so they are re-read once they are older than staleFinalizedTime.
+type bestFinalizedCache struct {
+	sync.Mutex
+	s       *Service
+	root    []byte
+	epoch   uint64
+	fetched time.Time
+}
+
+// get returns the best finalized root and epoch, refreshing them first if they are stale. The
+// finalized boundary never moves backwards within a batch.
+func (c *bestFinalizedCache) get() ([]byte, uint64) {
+	c.Lock()
+	defer c.Unlock()
+	if time.Since(c.fetched) > staleFinalizedTime {
+		root, epoch, _ := c.s.p2p.Peers().BestFinalized(params.BeaconConfig().MaxPeersToSync, helpers.SlotToEpoch(c.s.chain.HeadSlot()))
+		if epoch >= c.epoch {
+			c.root = root
+			c.epoch = epoch
+		}
+		c.fetched = time.Now()
+	}
+	return c.root, c.epoch
+}
 
 // Round Robin sync looks at the latest peer statuses and syncs with the highest
 // finalized peer.
@@ -38,26 +91,125 @@ const refreshTime = 6 * time.Second
 // after the finalized epoch, request blocks to head from some subset of peers
 // where step = 1.
 func (s *Service) roundRobinSync(genesis time.Time) error {
+	return s.roundRobinSyncFrom(genesis, nil /* anchor */)
+}
+
+// syncAnchor is a block from which sync proceeds forward rather than from the chain head.
+type syncAnchor struct {
+	root []byte
+	slot uint64
+}
+
+// roundRobinSyncFrom runs round robin sync, starting from the anchor block if one is provided.
+func (s *Service) roundRobinSyncFrom(genesis time.Time, anchor *syncAnchor) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Slots since genesis are meaningless before genesis, so there is nothing to sync towards yet.
+	if err := s.waitForGenesis(ctx, genesis); err != nil {
+		return err
+	}
+
+	s.stats = newSyncStats(time.Now())
+	s.served.reset()
+	s.distinctPeers.reset()
+	s.measureClockSkew(genesis)
 	counter := ratecounter.NewRateCounter(counterSeconds * time.Second)
 	randGenerator := rand.New(rand.NewSource(time.Now().Unix()))
 	var lastEmptyRequests int
+	var span requestSpan
+	var prefetched *prefetchedBatch
+	var processedBatches int
+	// The finalized checkpoint synced towards, along with the peers which served it.
+	var syncedFinalizedRoot []byte
+	var syncedFinalizedEpoch uint64
+	var syncedFinalizedPeers []peer.ID
+	// The first slot synced, from which synced blocks are verified once the finalized epoch is reached.
+	firstSlot := s.chain.HeadSlot() + 1
+	// The block sync started from precedes every block synced towards a finalized checkpoint, so
+	// sync restarts from it when the checkpoint turns out to be on a different chain.
+	origin := anchor
+	if origin == nil {
+		origin = &syncAnchor{root: s.chain.HeadRoot(), slot: s.chain.HeadSlot()}
+	}
+	var restartFrom *syncAnchor
+	restart := func(root []byte, finalizedEpoch uint64) {
+		log.WithFields(logrus.Fields{
+			"epoch":       finalizedEpoch,
+			"oldEpoch":    syncedFinalizedEpoch,
+			"oldRoot":     fmt.Sprintf("%#x", syncedFinalizedRoot),
+			"newRoot":     fmt.Sprintf("%#x", root),
+			"headSlot":    s.chain.HeadSlot(),
+			"restartSlot": origin.slot + 1,
+		}).Warn("Best finalized checkpoint is not on the synced chain, restarting sync to the new finalized checkpoint")
+		prefetched.discard()
+		prefetched = nil
+		lastEmptyRequests = 0
+		span = requestSpan{}
+		syncedFinalizedRoot, syncedFinalizedEpoch, syncedFinalizedPeers = nil, 0, nil
+		restartFrom = origin
+	}
+	// The slot Step 1 requests blocks from, along with the root of the anchor block it follows, if
+	// it still starts from the anchor.
+	sessionStart := func() (uint64, []byte) {
+		if restartFrom != nil {
+			return restartFrom.slot + 1, nil
+		}
+		return s.syncStart(anchor)
+	}
 	// Step 1 - Sync to end of finalized epoch.
 	for s.chain.HeadSlot() < helpers.StartSlot(s.highestFinalizedEpoch()+1) {
+		// The clock skew is measured again for every batch, so its status follows changes of the
+		// peers or of the local clock.
+		s.measureClockSkew(genesis)
+		// Syncing on while most peers misbehave risks syncing a chain controlled by an attacker.
+		if err := s.misbehavior.check(flags.Get().SyncMaxMisbehaviorRate); err != nil {
+			return err
+		}
 		root, finalizedEpoch, peers := s.p2p.Peers().BestFinalized(params.BeaconConfig().MaxPeersToSync, helpers.SlotToEpoch(s.chain.HeadSlot()))
 		if len(peers) == 0 {
 			log.Warn("No peers; waiting for reconnect")
 			time.Sleep(refreshTime)
 			continue
 		}
+		peers = s.peerCooldowns.filter(peers, time.Now())
+		if peers = s.reliablePeers(peers); len(peers) == 0 {
+			log.WithField("minPeerReliability", flags.Get().MinPeerReliability).Warn(
+				"No peers meet the minimum reliability; waiting for reliable peers",
+			)
+			time.Sleep(refreshTime)
+			continue
+		}
+		if peers = s.admitPeers(peers); len(peers) == 0 {
+			log.WithField("maxDistinctPeers", flags.Get().SyncMaxDistinctPeers).Warn(
+				"Reached the maximum number of distinct peers to sync with; waiting for a queried peer to become available",
+			)
+			time.Sleep(refreshTime)
+			continue
+		}
+		// Peers converging on a different root for the finalized epoch synced towards means the
+		// chain being synced is not the finalized chain. A different root for a later epoch may
+		// just be the chain finalizing further, so it is only known to be on a different chain
+		// once its blocks don't build on the synced chain.
+		var checkLinks bool
+		if syncedFinalizedRoot != nil && !bytes.Equal(root, syncedFinalizedRoot) {
+			if finalizedEpoch == syncedFinalizedEpoch {
+				restart(root, finalizedEpoch)
+			} else {
+				checkLinks = finalizedEpoch > syncedFinalizedEpoch
+			}
+		}
+		finalized := &bestFinalizedCache{
+			s:       s,
+			root:    root,
+			epoch:   finalizedEpoch,
+			fetched: time.Now(),
+		}
+		startSlot, anchorRoot := sessionStart()
+		batchSize := bufferedBatchSize(len(peers))
+		spanCount := span.count(batchSize, len(peers))
 
-		// shuffle peers to prevent a bad peer from
-		// stalling sync with invalid blocks
-		randGenerator.Shuffle(len(peers), func(i, j int) {
-			peers[i], peers[j] = peers[j], peers[i]
-		})
+		s.orderPeers(randGenerator, peers)
 
 		// request a range of blocks to be requested from multiple peers.
 		// Example:
@@ -66,51 +218,83 @@ func (s *Service) roundRobinSync(genesis time.Time) error {
 		//   Four requests will be spread across the peers using step argument to distribute the load
 		//   i.e. the first peer is asked for block 64, 68, 72... while the second peer is asked for
 		//   65, 69, 73... and so on for other peers.
-		var request func(start uint64, step uint64, count uint64, peers []peer.ID, remainder int) ([]*eth.SignedBeaconBlock, error)
-		request = func(start uint64, step uint64, count uint64, peers []peer.ID, remainder int) ([]*eth.SignedBeaconBlock, error) {
+		// The number of preceding empty request ranges is passed in, as a prefetched request runs
+		// concurrently with the loop updating lastEmptyRequests. The context is passed in too, so
+		// that a discarded prefetched request is cancelled. The depth is the number of failed
+		// requests this range was split from.
+		var request func(ctx context.Context, start uint64, step uint64, count uint64, peers []peer.ID, remainder int, emptyRequests int, depth int) ([]*eth.SignedBeaconBlock, error)
+		request = func(ctx context.Context, start uint64, step uint64, count uint64, peers []peer.ID, remainder int, emptyRequests int, depth int) ([]*eth.SignedBeaconBlock, error) {
 			if len(peers) == 0 {
-				return nil, errors.WithStack(errors.New("no peers left to request blocks"))
+				return nil, errors.WithStack(ErrNoPeers)
 			}
+			root, finalizedEpoch := finalized.get()
+			if anchorRoot != nil {
+				root = anchorRoot
+			}
+			root = s.finalizedSyncHeadRoot(root)
 			var p2pRequestCount int32
 			errChan := make(chan error)
 			blocksChan := make(chan []*eth.SignedBeaconBlock)
 
 			// Handle block large block ranges of skipped slots.
-			start += count * uint64(lastEmptyRequests*len(peers))
-			if count <= 1 {
-				step = 1
-			}
+			start += count * uint64(emptyRequests*len(peers))
 
 			// Short circuit start far exceeding the highest finalized epoch in some infinite loop.
 			if start > helpers.StartSlot(s.highestFinalizedEpoch()+1) {
 				return nil, errors.Errorf("attempted to ask for a start slot of %d which is greater than the next highest epoch of %d", start, s.highestFinalizedEpoch()+1)
 			}
 
-			atomic.AddInt32(&p2pRequestCount, int32(len(peers)))
+			// Plan the ranges of all peers before fanning out, so the whole plan is logged at once.
+			peerStep := step * uint64(len(peers))
+			reqs := make([]*p2ppb.BeaconBlocksByRangeRequest, len(peers))
+			plan := make([]plannedRequest, len(peers))
 			for i, pid := range peers {
-				if ctx.Err() != nil {
-					return nil, ctx.Err()
-				}
 				start := start + uint64(i)*step
-				step := step * uint64(len(peers))
-				count := mathutil.Min(count, (helpers.StartSlot(finalizedEpoch+1)-start)/step)
+				step := peerStep
+				count := count
 				// If the count was divided by an odd number of peers, there will be some blocks
 				// missing from the first requests so we accommodate that scenario.
 				if i < remainder {
 					count++
 				}
-				// asking for no blocks may cause the client to hang. This should never happen and
-				// the peer may return an error anyway, but we'll ask for at least one block.
-				if count == 0 {
-					count = 1
+				// Don't request slots past the finalized boundary. The division rounds up, as the
+				// last slot of the peer's range may be less than step slots before the boundary.
+				if boundary := helpers.StartSlot(finalizedEpoch + 1); start < boundary {
+					count = mathutil.Min(count, (boundary-start+step-1)/step)
+				} else {
+					count = 0
 				}
-				req := &p2ppb.BeaconBlocksByRangeRequest{
+				// The step of a single slot range doesn't matter. It is only kept above so that a
+				// failed over range still interleaves with the ranges of the other peers.
+				reqStep := step
+				if count <= 1 {
+					reqStep = 1
+				}
+				reqs[i] = &p2ppb.BeaconBlocksByRangeRequest{
 					HeadBlockRoot: root,
 					StartSlot:     start,
 					Count:         count,
-					Step:          step,
+					Step:          reqStep,
+				}
+				plan[i] = plannedRequest{
+					Peer:      pid.Pretty(),
+					StartSlot: start,
+					Step:      reqStep,
+					Count:     count,
 				}
+			}
+			log.WithFields(logrus.Fields{
+				"peers": len(peers),
+				"depth": depth,
+				"plan":  plan,
+			}).Debug("Planned batch block requests")
 
+			atomic.AddInt32(&p2pRequestCount, int32(len(peers)))
+			for i, pid := range peers {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				req := reqs[i]
 				go func(i int, pid peer.ID) {
 					defer func() {
 						zeroIfIAmTheLast := atomic.AddInt32(&p2pRequestCount, -1)
@@ -118,11 +302,21 @@ func (s *Service) roundRobinSync(genesis time.Time) error {
 							close(blocksChan)
 						}
 					}()
+					// No slots of the range fall to this peer, e.g. as they are past the finalized
+					// boundary.
+					if req.Count == 0 {
+						return
+					}
 
 					resp, err := s.requestBlocks(ctx, req, pid)
+					if err != nil && !peerFault(ctx, err) {
+						errChan <- err
+						return
+					}
 					if err != nil {
+						s.peerCooldowns.start(pid, time.Now())
 						// fail over to other peers by splitting this requests evenly across them.
-						ps := append(peers[:i], peers[i+1:]...)
+						ps := peersExcept(peers, i)
 						log.WithError(err).WithField(
 							"remaining peers",
 							len(ps),
@@ -131,10 +325,15 @@ func (s *Service) roundRobinSync(genesis time.Time) error {
 							pid.Pretty(),
 						).Debug("Request failed, trying to round robin with other peers")
 						if len(ps) == 0 {
-							errChan <- errors.WithStack(errors.New("no peers left to request blocks"))
+							errChan <- errors.WithStack(ErrNoPeers)
+							return
+						}
+						if maxDepth := flags.Get().MaxFailoverDepth; maxDepth > 0 && depth >= maxDepth {
+							errChan <- errors.Wrapf(ErrRetryBudgetExhausted, "exceeded maximum failover depth of %d, last error: %v", maxDepth, err)
 							return
 						}
-						resp, err = request(start, step, count/uint64(len(ps)) /*count*/, ps, int(count)%len(ps) /*remainder*/)
+						// The start slot of the failed request already skips the empty request ranges.
+						resp, err = request(ctx, req.StartSlot, peerStep, req.Count/uint64(len(ps)) /*count*/, ps, int(req.Count)%len(ps) /*remainder*/, 0 /*emptyRequests*/, depth+1)
 						if err != nil {
 							errChan <- err
 							return
@@ -162,22 +361,57 @@ func (s *Service) roundRobinSync(genesis time.Time) error {
 				}
 			}
 		}
-		startBlock := s.chain.HeadSlot() + 1
-		skippedBlocks := blockBatchSize * uint64(lastEmptyRequests*len(peers))
-		if startBlock+skippedBlocks > helpers.StartSlot(finalizedEpoch+1) {
+		skippedBlocks := spanCount * uint64(lastEmptyRequests*len(peers))
+		if startSlot+skippedBlocks > helpers.StartSlot(finalizedEpoch+1) {
 			log.WithField("finalizedEpoch", finalizedEpoch).Debug("Requested block range is greater than the finalized epoch")
 			break
 		}
 
-		blocks, err := request(
-			s.chain.HeadSlot()+1, // start
-			1,                    // step
-			blockBatchSize,       // count
-			peers,                // peers
-			0,                    // remainder
-		)
-		if err != nil {
-			return err
+		blocks, ok := awaitPrefetched(prefetched, startSlot)
+		prefetched = nil
+		if !ok {
+			var err error
+			blocks, err = request(
+				ctx,               // ctx
+				startSlot,         // start
+				1,                 // step
+				spanCount,         // count
+				peers,             // peers
+				0,                 // remainder
+				lastEmptyRequests, // emptyRequests
+				0,                 // depth
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Peers may only serve a prefix of their ranges, e.g. when they reach the maximum number of
+		// blocks per peer, so the missing suffix is requested right away rather than in the next
+		// iteration of the loop.
+		batchStart := startSlot + skippedBlocks
+		batchEnd := mathutil.Min(batchStart+spanCount*uint64(len(peers)), helpers.StartSlot(finalizedEpoch+1)) - 1
+		if next, ok := partialBatchSuffix(blocks, batchStart, batchEnd); ok {
+			count := batchEnd - next + 1
+			log.WithFields(logrus.Fields{
+				"start": next,
+				"count": count,
+			}).Debug("Requesting missing suffix of partially served batch")
+			suffix, err := request(
+				ctx,                      // ctx
+				next,                     // start
+				1,                        // step
+				count/uint64(len(peers)), // count
+				peers,                    // peers
+				int(count)%len(peers),    // remainder
+				0,                        // emptyRequests
+				0,                        // depth
+			)
+			if err != nil {
+				log.WithError(err).Debug("Could not request missing suffix of batch, processing the partial batch")
+			} else {
+				blocks = append(blocks, suffix...)
+			}
 		}
 
 		// Since the block responses were appended to the list, we must sort them in order to
@@ -186,35 +420,102 @@ func (s *Service) roundRobinSync(genesis time.Time) error {
 		sort.Slice(blocks, func(i, j int) bool {
 			return blocks[i].Block.Slot < blocks[j].Block.Slot
 		})
+		if checkLinks && len(blocks) > 0 && !s.db.HasBlock(ctx, bytesutil.ToBytes32(blocks[0].Block.ParentRoot)) {
+			restart(root, finalizedEpoch)
+			continue
+		}
 
-		for _, blk := range blocks {
-			s.logSyncStatus(genesis, blk.Block, peers, counter)
-			if !s.db.HasBlock(ctx, bytesutil.ToBytes32(blk.Block.ParentRoot)) {
-				log.Debugf("Beacon node doesn't have a block in db with root %#x", blk.Block.ParentRoot)
-				continue
+		var requestedSlots uint64
+		if batchEnd >= batchStart {
+			requestedSlots = batchEnd - batchStart + 1
+		}
+		widened := span.observe(requestedSlots, uint64(len(blocks)), batchSize, len(peers))
+		spanCount = span.count(batchSize, len(peers))
+
+		// Request the range following this batch while it is being processed. The range assumes
+		// that the head advances to the last block of this batch, otherwise it is discarded.
+		if flags.Get().PrefetchNextBatch && len(blocks) > 0 {
+			nextStart := blocks[len(blocks)-1].Block.Slot + 1
+			if nextStart < helpers.StartSlot(finalizedEpoch+1) {
+				prefetchPeers := append([]peer.ID{}, peers...)
+				prefetched = prefetchBatch(ctx, nextStart, func(ctx context.Context) ([]*eth.SignedBeaconBlock, error) {
+					return request(ctx, nextStart, 1 /*step*/, spanCount /*count*/, prefetchPeers, 0 /*remainder*/, 0 /*emptyRequests*/, 0 /*depth*/)
+				})
 			}
-			if featureconfig.Get().InitSyncNoVerify {
-				if err := s.chain.ReceiveBlockNoVerify(ctx, blk); err != nil {
-					return err
-				}
-			} else {
-				if err := s.chain.ReceiveBlockNoPubsubForkchoice(ctx, blk); err != nil {
-					return err
+		}
+
+		if err := s.processBlocks(ctx, genesis, blocks, peers, counter); err != nil {
+			return err
+		}
+		if len(blocks) > 0 {
+			restartFrom = nil
+		}
+		syncedFinalizedRoot, syncedFinalizedEpoch = finalized.get()
+		syncedFinalizedPeers = peers
+		if interval := flags.Get().SyncCheckpointInterval; interval > 0 && len(blocks) > 0 {
+			processedBatches++
+			if processedBatches%interval == 0 {
+				if err := s.saveSyncProgress(ctx, syncedFinalizedEpoch); err != nil {
+					log.WithError(err).Warn("Could not save initial sync progress")
 				}
 			}
 		}
-		// If there were no blocks in the last request range, increment the counter so the same
-		// range isn't requested again on the next loop as the headSlot didn't change.
-		if len(blocks) == 0 {
-			lastEmptyRequests++
-		} else {
+		// If there were no blocks in the last request range and the span can't be widened any
+		// further, increment the counter so the same range isn't requested again on the next loop
+		// as the headSlot didn't change.
+		if len(blocks) > 0 {
 			lastEmptyRequests = 0
+		} else if !widened {
+			lastEmptyRequests++
+		}
+	}
+
+	// A batch prefetched past the end of Step 1 is never used.
+	prefetched.discard()
+
+	boundaryStart, boundaryAnchorRoot := sessionStart()
+	if err := s.syncToFinalizedBoundary(ctx, genesis, boundaryStart, boundaryAnchorRoot, counter); err != nil {
+		return err
+	}
+	s.stats.endPhase("finalized", time.Now())
+
+	// Blocks whose parent is not in the db are skipped when processing, which could leave gaps in
+	// the synced chain.
+	if flags.Get().SyncVerifyContiguity {
+		if err := s.verifyContiguity(ctx, firstSlot, s.chain.HeadRoot()); err != nil {
+			return err
+		}
+	}
+
+	// Blocks synced to the finalized epoch without verifying their contents are spot checked, to
+	// catch invalid blocks served by peers or a corrupt db before the node considers itself synced.
+	if featureconfig.Get().InitSyncNoVerify {
+		if err := s.verifySampledBlocks(ctx, randGenerator, firstSlot, flags.Get().SyncVerifySampleRate); err != nil {
+			return err
 		}
 	}
 
+	// When syncing from an anchor, blocks before the anchor, which may include the finalized
+	// checkpoint block, are not necessarily in the db. The genesis checkpoint is not verified.
+	if flags.Get().VerifyFinalizedRoot && anchor == nil && syncedFinalizedEpoch > 0 {
+		if err := s.verifyFinalizedRoot(ctx, syncedFinalizedRoot, syncedFinalizedEpoch, syncedFinalizedPeers); err != nil {
+			return err
+		}
+	}
+	s.stats.endPhase("verification", time.Now())
+
+	// Nodes serving finalized data only consider themselves synced once the finalized epoch is
+	// reached, without syncing the unfinalized blocks up to the chain head.
+	if flags.Get().SyncFinalityOnly {
+		log.WithField("headSlot", s.chain.HeadSlot()).Info("Synced to finalized epoch - not syncing to current head in finality only mode")
+		s.completeSync()
+		return nil
+	}
+
 	log.Debug("Synced to finalized epoch - now syncing blocks up to current head")
 
 	if s.chain.HeadSlot() == helpers.SlotsSince(genesis) {
+		s.completeSync()
 		return nil
 	}
 
@@ -224,20 +525,48 @@ func (s *Service) roundRobinSync(genesis time.Time) error {
 	// mitigation. We are already convinced that we are on the correct finalized chain. Any blocks
 	// we receive there after must build on the finalized chain or be considered invalid during
 	// fork choice resolution / block processing.
-	best := s.bestPeer()
+	best := s.admittedBestPeer(genesis)
 	root, _, _ := s.p2p.Peers().BestFinalized(params.BeaconConfig().MaxPeersToSync, helpers.SlotToEpoch(s.chain.HeadSlot()))
 
 	// if no best peer exists, retry until a new best peer is found.
 	for len(best) == 0 {
 		time.Sleep(refreshTime)
-		best = s.bestPeer()
+		best = s.admittedBestPeer(genesis)
 		root, _, _ = s.p2p.Peers().BestFinalized(params.BeaconConfig().MaxPeersToSync, helpers.SlotToEpoch(s.chain.HeadSlot()))
 	}
-	for head := helpers.SlotsSince(genesis); s.chain.HeadSlot() < head; {
+	// The current slot keeps advancing while blocks are processed, so sync is considered complete
+	// once the head is within the configured tolerance of it, leaving the remaining slots to
+	// regular sync.
+	tolerance := flags.Get().HeadSyncSlotTolerance
+	overlap := flags.Get().HeadSyncOverlap
+	var failed []peer.ID
+	var requests int
+	for s.chain.HeadSlot()+tolerance < helpers.SlotsSince(genesis) {
+		s.measureClockSkew(genesis)
+		if err := s.misbehavior.check(flags.Get().SyncMaxMisbehaviorRate); err != nil {
+			return err
+		}
+		// A node processing blocks slower than the chain produces them would never catch up, so
+		// sync to the head ends on a best effort basis after the maximum number of requests.
+		if maxRequests := flags.Get().HeadSyncMaxRequests; maxRequests > 0 && requests >= maxRequests {
+			log.WithFields(logrus.Fields{
+				"headSlot":    s.chain.HeadSlot(),
+				"currentSlot": helpers.SlotsSince(genesis),
+				"maxRequests": maxRequests,
+			}).Warn("Reached the maximum number of requests syncing to the chain head, handing off to regular sync on a best effort basis")
+			break
+		}
+		requests++
+		startSlot, anchorRoot := s.syncStart(anchor)
+		if anchorRoot != nil {
+			root = anchorRoot
+		} else {
+			startSlot = headSyncStart(startSlot, overlap)
+		}
 		req := &p2ppb.BeaconBlocksByRangeRequest{
 			HeadBlockRoot: root,
-			StartSlot:     s.chain.HeadSlot() + 1,
-			Count:         mathutil.Min(helpers.SlotsSince(genesis)-s.chain.HeadSlot()+1, 256),
+			StartSlot:     startSlot,
+			Count:         mathutil.Min(helpers.SlotsSince(genesis)-startSlot+2, 256),
 			Step:          1,
 		}
 
@@ -246,26 +575,461 @@ func (s *Service) roundRobinSync(genesis time.Time) error {
 		)
 
 		resp, err := s.requestBlocks(ctx, req, best)
-		if err != nil {
+		if err != nil && !peerFault(ctx, err) {
 			return err
 		}
+		if err != nil {
+			// Retry with the next best peer, only giving up once every candidate peer failed.
+			failed = append(failed, best)
+			next := s.admittedBestPeer(genesis, failed...)
+			log.WithError(err).WithFields(logrus.Fields{
+				"peer":        best.Pretty(),
+				"failedPeers": len(failed),
+			}).Debug("Request failed, trying the next best peer")
+			if len(next) == 0 {
+				return errors.Wrapf(err, "all %d candidate peers failed to serve blocks", len(failed))
+			}
+			best = next
+			continue
+		}
+		// Only peers which failed the current request are excluded from its retries.
+		failed = nil
 
+		var received int
 		for _, blk := range resp {
+			// Blocks overlapping the head are only received if they were reorged in.
+			if overlap > 0 {
+				blkRoot, err := ssz.HashTreeRoot(blk.Block)
+				if err != nil {
+					return errors.Wrap(err, "could not get block root")
+				}
+				if s.db.HasBlock(ctx, blkRoot) {
+					continue
+				}
+			}
+			if err := s.throttle.wait(ctx, flags.Get().SyncMaxProcessRate); err != nil {
+				return err
+			}
 			s.logSyncStatus(genesis, blk.Block, []peer.ID{best}, counter)
-			if err := s.chain.ReceiveBlockNoPubsubForkchoice(ctx, blk); err != nil {
+			if err := s.receiveHeadBlock(ctx, blk); err != nil {
 				return err
 			}
+			s.stats.blocks++
+			received++
 		}
-		if len(resp) == 0 {
+		if received == 0 {
 			break
 		}
 	}
+	s.stats.endPhase("head", time.Now())
 
+	// Range requests chase a moving target at the tip of the chain, so once the head is within
+	// the tolerance of the current slot regular sync takes over receiving blocks over gossip, which
+	// its validators accept as soon as the service is marked as synced.
+	s.completeSync()
 	return nil
 }
 
+// finalizedSyncHeadRoot returns the head block root of a request for blocks up to the finalized
+// epoch. This is the given root, which is the finalized or anchor root, unless the sync latest head
+// root flag is set, in which case it is the root of the latest processed block.
+func (s *Service) finalizedSyncHeadRoot(root []byte) []byte {
+	if !flags.Get().SyncLatestHeadRoot {
+		return root
+	}
+	if headRoot := s.chain.HeadRoot(); len(headRoot) > 0 {
+		return headRoot
+	}
+	return root
+}
+
+// headSyncStart moves the start slot of a request for blocks up to the chain head back by the
+// overlap, without going back past the first slot after genesis.
+func headSyncStart(startSlot uint64, overlap uint64) uint64 {
+	if startSlot <= overlap {
+		return 1
+	}
+	return startSlot - overlap
+}
+
+// receiveHeadBlock processes a block synced after the finalized epoch. By default fork choice and
+// pubsub are skipped, as in Step 1, which is faster but leaves the head unset until sync completes.
+// With the sync head forkchoice flag the full block receiving path is used to keep fork choice
+// current, at the cost of running fork choice for every block.
+func (s *Service) receiveHeadBlock(ctx context.Context, blk *eth.SignedBeaconBlock) error {
+	if flags.Get().SyncHeadForkchoice {
+		return s.chain.ReceiveBlock(ctx, blk)
+	}
+	return s.chain.ReceiveBlockNoPubsubForkchoice(ctx, blk)
+}
+
+// bufferedBatchSize returns the number of blocks to request from each of the peers for a batch.
+// The batch size is reduced so that the blocks requested from all peers together, which are held
+// in memory until processed, do not exceed the configured maximum number of buffered blocks. The
+// slots past the reduced batch are requested in the following batches instead.
+func bufferedBatchSize(peers int) uint64 {
+	maxBuffered := flags.Get().SyncMaxBufferedBlocks
+	if maxBuffered <= 0 || peers == 0 || blockBatchSize*peers <= maxBuffered {
+		return blockBatchSize
+	}
+	size := uint64(maxBuffered / peers)
+	if size == 0 {
+		size = 1
+	}
+	log.WithFields(logrus.Fields{
+		"maxBufferedBlocks": maxBuffered,
+		"peers":             peers,
+		"batchSize":         size,
+	}).Debug("Reducing batch size to stay within the maximum number of buffered blocks")
+	return size
+}
+
+// requestSpan adapts the number of slots requested from each peer in Step 1 to the density of
+// blocks in the last batch. Sparse regions, such as long periods without blocks, are crossed by
+// widening the span rather than by skipping whole batches, and dense regions narrow it back down to
+// the batch size. The zero value requests batches of the batch size.
+type requestSpan struct {
+	multiplier uint64
+}
+
+// count of slots to request from each peer for the batch size.
+func (r *requestSpan) count(batchSize uint64, peers int) uint64 {
+	count := batchSize * mathutil.Max(r.multiplier, 1)
+	return mathutil.Min(count, maxSpanCount(batchSize, peers))
+}
+
+// observe the number of blocks received for the number of slots requested in the last batch. The
+// span is doubled if less than a quarter of the slots had blocks and halved if more than half of
+// them did. It returns true if the span was widened.
+func (r *requestSpan) observe(requested uint64, received uint64, batchSize uint64, peers int) bool {
+	if r.multiplier == 0 {
+		r.multiplier = 1
+	}
+	switch {
+	case 4*received < requested:
+		if r.count(batchSize, peers) >= maxSpanCount(batchSize, peers) {
+			return false
+		}
+		r.multiplier *= 2
+		return true
+	case 2*received > requested && r.multiplier > 1:
+		r.multiplier /= 2
+	}
+	return false
+}
+
+// maxSpanCount is the most slots requested from each peer by a widened span. The span is not
+// widened past the maximum number of buffered blocks, as a wide span may end in a dense region.
+func maxSpanCount(batchSize uint64, peers int) uint64 {
+	max := params.BeaconConfig().MaxRequestBlocks
+	if maxBuffered := flags.Get().SyncMaxBufferedBlocks; maxBuffered > 0 && peers > 0 {
+		max = mathutil.Min(max, uint64(maxBuffered/peers))
+	}
+	return mathutil.Max(max, batchSize)
+}
+
+// partialBatchSuffix returns the first slot of the missing suffix of a batch of blocks requested
+// for the slots from start to end, if less than half of the range up to the highest received block
+// was served. Empty batches are not considered partial.
+func partialBatchSuffix(blocks []*eth.SignedBeaconBlock, start uint64, end uint64) (uint64, bool) {
+	if len(blocks) == 0 || end < start {
+		return 0, false
+	}
+	var highest uint64
+	for _, blk := range blocks {
+		if blk.Block.Slot > highest {
+			highest = blk.Block.Slot
+		}
+	}
+	if highest >= end || 2*(end-highest) <= end-start+1 {
+		return 0, false
+	}
+	return highest + 1, true
+}
+
+// verifyFinalizedRoot checks that the finalized checkpoint block with the root advertised by the
+// peers was synced. On mismatch, the peers sync was performed with are penalized, as they served a
+// chain which does not contain the finalized checkpoint they advertised.
+func (s *Service) verifyFinalizedRoot(ctx context.Context, root []byte, finalizedEpoch uint64, peers []peer.ID) error {
+	if s.db.HasBlock(ctx, bytesutil.ToBytes32(root)) {
+		return nil
+	}
+	for _, pid := range peers {
+		s.p2p.Peers().IncrementBadResponses(pid)
+		s.misbehavior.record(true /* misbehaved */)
+	}
+	log.WithFields(logrus.Fields{
+		"root":  fmt.Sprintf("%#x", root),
+		"epoch": finalizedEpoch,
+		"peers": len(peers),
+	}).Error("Synced chain does not contain the advertised finalized root")
+	return errors.Wrapf(ErrFinalizedRootMismatch, "finalized root %#x at epoch %d", root, finalizedEpoch)
+}
+
+// prefetchedBatch is a block request for the range starting at start, made ahead of time in the
+// background. The done channel is closed once the response is available.
+type prefetchedBatch struct {
+	start  uint64
+	cancel context.CancelFunc
+	done   chan struct{}
+	blocks []*eth.SignedBeaconBlock
+	err    error
+}
+
+// prefetchBatch requests the batch of blocks starting at the start slot in the background. The
+// request runs with a child context of ctx, which is cancelled once the batch is discarded or
+// its response is used, and along with ctx once sync returns.
+func prefetchBatch(ctx context.Context, start uint64, request func(ctx context.Context) ([]*eth.SignedBeaconBlock, error)) *prefetchedBatch {
+	ctx, cancel := context.WithCancel(ctx)
+	batch := &prefetchedBatch{
+		start:  start,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(batch.done)
+		batch.blocks, batch.err = request(ctx)
+	}()
+	return batch
+}
+
+// discard cancels the request of the batch if it is still running. A nil batch is ignored.
+func (b *prefetchedBatch) discard() {
+	if b != nil {
+		b.cancel()
+	}
+}
+
+// awaitPrefetched returns the blocks of the prefetched batch, if any, waiting for them to arrive.
+// The batch is only used if it starts at the start slot and was fetched successfully. Otherwise
+// the range has to be requested again, e.g. because the head did not advance as expected.
+func awaitPrefetched(batch *prefetchedBatch, start uint64) ([]*eth.SignedBeaconBlock, bool) {
+	if batch == nil {
+		return nil, false
+	}
+	defer batch.discard()
+	if batch.start != start {
+		log.WithFields(logrus.Fields{
+			"prefetchedStart": batch.start,
+			"start":           start,
+		}).Debug("Head did not advance as expected, discarding prefetched blocks")
+		return nil, false
+	}
+	<-batch.done
+	if batch.err != nil {
+		log.WithError(batch.err).Debug("Prefetched block request failed, requesting blocks again")
+		return nil, false
+	}
+	return batch.blocks, true
+}
+
+// shufflePeers to prevent a bad peer from stalling sync with invalid blocks. Shuffling is skipped
+// when peer shuffling is disabled for debugging, keeping the order reported by BestFinalized.
+func shufflePeers(randGenerator *rand.Rand, peers []peer.ID) {
+	if flags.Get().DisablePeerShuffle {
+		return
+	}
+	randGenerator.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+}
+
+// plannedRequest is the range planned to be requested from a peer in a batch, as logged before
+// the requests are sent.
+type plannedRequest struct {
+	Peer      string
+	StartSlot uint64
+	Step      uint64
+	Count     uint64
+}
+
+func (p plannedRequest) String() string {
+	return fmt.Sprintf("%s: start=%d step=%d count=%d", p.Peer, p.StartSlot, p.Step, p.Count)
+}
+
+// peersExcept returns a copy of the peers without the peer at index i. The peers are shared with
+// the other goroutines of a request, so the slice is not modified.
+func peersExcept(peers []peer.ID, i int) []peer.ID {
+	ps := make([]peer.ID, 0, len(peers)-1)
+	ps = append(ps, peers[:i]...)
+	return append(ps, peers[i+1:]...)
+}
+
+// containsPeer returns true if the peer is one of the peers.
+func containsPeer(peers []peer.ID, pid peer.ID) bool {
+	for _, p := range peers {
+		if p == pid {
+			return true
+		}
+	}
+	return false
+}
+
+// rotatePeers rotates the peers by one more position with every batch. The first peer of a batch
+// is requested the first range and, with the remainder of an uneven split, the largest one, so the
+// rotation spreads this load evenly across the peers even when they aren't shuffled.
+func (s *Service) rotatePeers(peers []peer.ID) {
+	if len(peers) == 0 {
+		return
+	}
+	offset := int(s.peerRotation % uint64(len(peers)))
+	s.peerRotation++
+	rotated := append(append(make([]peer.ID, 0, len(peers)), peers[offset:]...), peers[:offset]...)
+	copy(peers, rotated)
+}
+
+// processBlocks hands the sorted blocks of a request range to the chain service. Blocks whose
+// parent is not known are skipped. When block contents are not verified and batch saving is
+// enabled, blocks are accumulated and received in batches so their db writes are amortized.
+func (s *Service) processBlocks(ctx context.Context, genesis time.Time, blocks []*eth.SignedBeaconBlock, peers []peer.ID, counter *ratecounter.RateCounter) error {
+	if dir := flags.Get().SyncCaptureDir; dir != "" {
+		if err := s.capture.write(dir, maxCaptureBytes, blocks); err != nil {
+			log.WithError(err).Warn("Could not capture batch blocks")
+		}
+	}
+	batchSize := flags.Get().InitSyncBatchSaveBlocks
+	if !featureconfig.Get().InitSyncNoVerify || batchSize <= 1 {
+		for _, blk := range blocks {
+			if err := s.throttle.wait(ctx, flags.Get().SyncMaxProcessRate); err != nil {
+				return err
+			}
+			s.logSyncStatus(genesis, blk.Block, peers, counter)
+			if !s.db.HasBlock(ctx, bytesutil.ToBytes32(blk.Block.ParentRoot)) {
+				log.Debugf("Beacon node doesn't have a block in db with root %#x", blk.Block.ParentRoot)
+				continue
+			}
+			if featureconfig.Get().InitSyncNoVerify {
+				if err := s.chain.ReceiveBlockNoVerify(ctx, blk); err != nil {
+					return err
+				}
+			} else {
+				if err := s.chain.ReceiveBlockNoPubsubForkchoice(ctx, blk); err != nil {
+					return err
+				}
+			}
+			s.stats.blocks++
+		}
+		return nil
+	}
+
+	// Blocks in the pending batch are not in the db yet, so their roots are tracked
+	// separately to recognize children of pending blocks.
+	batch := make([]*eth.SignedBeaconBlock, 0, batchSize)
+	batchRoots := make(map[[32]byte]bool, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.chain.ReceiveBlockBatch(ctx, batch); err != nil {
+			return err
+		}
+		s.stats.blocks += uint64(len(batch))
+		batch = make([]*eth.SignedBeaconBlock, 0, batchSize)
+		batchRoots = make(map[[32]byte]bool, batchSize)
+		return nil
+	}
+	for _, blk := range blocks {
+		if err := s.throttle.wait(ctx, flags.Get().SyncMaxProcessRate); err != nil {
+			return err
+		}
+		s.logSyncStatus(genesis, blk.Block, peers, counter)
+		parentRoot := bytesutil.ToBytes32(blk.Block.ParentRoot)
+		if !batchRoots[parentRoot] && !s.db.HasBlock(ctx, parentRoot) {
+			log.Debugf("Beacon node doesn't have a block in db with root %#x", blk.Block.ParentRoot)
+			continue
+		}
+		root, err := ssz.HashTreeRoot(blk.Block)
+		if err != nil {
+			return errors.Wrap(err, "could not get signing root of block")
+		}
+		batch = append(batch, blk)
+		batchRoots[root] = true
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// syncStart returns the slot from which blocks should be requested next, along with the head block
+// root to request them with when syncing from an anchor. Sync proceeds from the anchor until a
+// block past the anchor has been processed.
+func (s *Service) syncStart(anchor *syncAnchor) (uint64, []byte) {
+	if anchor != nil && s.chain.HeadSlot() <= anchor.slot {
+		return anchor.slot + 1, anchor.root
+	}
+	return s.chain.HeadSlot() + 1, nil
+}
+
+// ValidateRangeRequest checks the invariants of a blocks by range request before it is sent. A
+// request must ask for at least one and at most MaxRequestBlocks blocks, use a step of at least
+// one, and the slots it spans must neither overflow nor exceed the widest range round robin sync
+// requests, which is MaxRequestBlocks slots for each of MaxPeersToSync peers.
+func ValidateRangeRequest(req *p2ppb.BeaconBlocksByRangeRequest) error {
+	if req.Count == 0 {
+		return errors.New("requested block count is 0")
+	}
+	if req.Step == 0 {
+		return errors.New("requested step is 0")
+	}
+	maxBlocks := params.BeaconConfig().MaxRequestBlocks
+	if req.Count > maxBlocks {
+		return errors.Errorf("requested block count %d is greater than the maximum of %d", req.Count, maxBlocks)
+	}
+	// The last requested slot is StartSlot + Step*(Count-1).
+	if req.Count > 1 && req.Step > (math.MaxUint64-req.StartSlot)/(req.Count-1) {
+		return errors.Errorf("requested range from slot %d with count %d and step %d overflows", req.StartSlot, req.Count, req.Step)
+	}
+	maxSpan := maxBlocks * uint64(params.BeaconConfig().MaxPeersToSync)
+	if span := req.Step*(req.Count-1) + 1; span > maxSpan {
+		return errors.Errorf("requested range from slot %d with count %d and step %d spans %d slots, more than the maximum of %d", req.StartSlot, req.Count, req.Step, span, maxSpan)
+	}
+	return nil
+}
+
+// validateRangeResponse checks that a peer only responded with blocks at the slots of the blocks by
+// range request.
+func validateRangeResponse(req *p2ppb.BeaconBlocksByRangeRequest, blocks []*eth.SignedBeaconBlock) error {
+	if uint64(len(blocks)) > req.Count {
+		return errors.Wrapf(ErrPeerMisbehavior, "received %d blocks when %d were requested", len(blocks), req.Count)
+	}
+	lastSlot := req.StartSlot + req.Step*(req.Count-1)
+	for _, blk := range blocks {
+		if blk == nil || blk.Block == nil {
+			return errors.Wrap(ErrPeerMisbehavior, "received nil block")
+		}
+		slot := blk.Block.Slot
+		if slot < req.StartSlot || slot > lastSlot || (slot-req.StartSlot)%req.Step != 0 {
+			return errors.Wrapf(ErrPeerMisbehavior, "received block at slot %d which was not requested", slot)
+		}
+	}
+	return nil
+}
+
+// inAscendingSlotOrder returns true if every block has a higher slot than the block before it.
+func inAscendingSlotOrder(blocks []*eth.SignedBeaconBlock) bool {
+	for i := 1; i < len(blocks); i++ {
+		if blocks[i].Block.Slot <= blocks[i-1].Block.Slot {
+			return false
+		}
+	}
+	return true
+}
+
+// peerFault returns true if a failed block request is attributed to the peer it was sent to,
+// rather than to an invalid request or to sync being cancelled. Only such failures count against
+// the peer.
+func peerFault(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && errors.Cause(err) != ErrInvalidRangeRequest
+}
+
 // requestBlocks by range to a specific peer.
 func (s *Service) requestBlocks(ctx context.Context, req *p2ppb.BeaconBlocksByRangeRequest, pid peer.ID) ([]*eth.SignedBeaconBlock, error) {
+	if err := ValidateRangeRequest(req); err != nil {
+		return nil, errors.Wrap(ErrInvalidRangeRequest, err.Error())
+	}
 	log.WithFields(logrus.Fields{
 		"peer":  pid,
 		"start": req.StartSlot,
@@ -273,25 +1037,112 @@ func (s *Service) requestBlocks(ctx context.Context, req *p2ppb.BeaconBlocksByRa
 		"step":  req.Step,
 		"head":  fmt.Sprintf("%#x", req.HeadBlockRoot),
 	}).Debug("Requesting blocks")
+	s.distinctPeers.record(pid)
+	start := time.Now()
 	stream, err := s.p2p.Send(ctx, req, pid)
 	if err != nil {
+		s.peerScores.record(pid, time.Since(start), true /* failed */)
 		return nil, errors.Wrap(err, "failed to send request to peer")
 	}
 	defer stream.Close()
 
-	resp := make([]*eth.SignedBeaconBlock, 0, req.Count)
+	// Stop reading from the stream once the peer has sent the maximum allowed number of blocks so
+	// that a single peer cannot dominate memory while other peers are still responding.
+	maxBlocks := uint64(flags.Get().MaxBlocksPerPeer)
+	resp, limited, err := readBlocks(func() (*eth.SignedBeaconBlock, error) {
+		return prysmsync.ReadChunkedBlock(stream, s.p2p)
+	}, maxBlocks, &blockSlicePool, req.Count)
+	if err != nil {
+		s.peerScores.record(pid, time.Since(start), true /* failed */)
+		return nil, errors.Wrap(err, "failed to read chunked block")
+	}
+	if limited {
+		log.WithFields(logrus.Fields{
+			"peer": pid,
+			"max":  maxBlocks,
+		}).Debug("Peer reached maximum blocks in flight, not reading further blocks")
+	}
+	if err := validateRangeResponse(req, resp); err != nil {
+		s.peerScores.record(pid, time.Since(start), true /* failed */)
+		s.p2p.Peers().IncrementBadResponses(pid)
+		s.misbehavior.record(true /* misbehaved */)
+		return nil, err
+	}
+	// Only responses which served blocks count as successes towards the peer's score.
+	if len(resp) == 0 {
+		s.peerScores.recordEmpty(pid)
+	} else {
+		s.peerScores.record(pid, time.Since(start), false /* failed */)
+	}
+	s.misbehavior.record(false /* misbehaved */)
+	s.served.record(pid, resp)
+	// Blocks are sorted before processing so the response is still used, but a peer sending
+	// blocks out of order is scored lower.
+	if !inAscendingSlotOrder(resp) {
+		log.WithField("peer", pid).Debug("Peer responded with blocks out of slot order")
+		s.peerScores.recordOutOfOrder(pid)
+	}
+
+	return resp, nil
+}
+
+// blockSlicePool holds the slices blocks are read into while a peer responds to a request, so
+// that concurrent requests don't allocate a full size slice each time.
+var blockSlicePool = sync.Pool{
+	New: func() interface{} {
+		blocks := make([]*eth.SignedBeaconBlock, 0, blockBatchSize)
+		return &blocks
+	},
+}
+
+// readBlocks reads blocks with next until it returns io.EOF or maxBlocks have been read, if
+// maxBlocks is non zero. The returned bool is true if reading stopped at maxBlocks.
+//
+// With a pool, blocks are read into a pooled slice and copied into an exactly sized slice owned by
+// the caller, so the pooled slice is never shared. Without a pool, a slice of sizeHint capacity is
+// allocated. Slices grown beyond MaxRequestBlocks are not returned to the pool to bound its memory.
+func readBlocks(next func() (*eth.SignedBeaconBlock, error), maxBlocks uint64, pool *sync.Pool, sizeHint uint64) ([]*eth.SignedBeaconBlock, bool, error) {
+	var buf []*eth.SignedBeaconBlock
+	if pool != nil {
+		pooled := pool.Get().(*[]*eth.SignedBeaconBlock)
+		buf = (*pooled)[:0]
+		defer func() {
+			if uint64(cap(buf)) > params.BeaconConfig().MaxRequestBlocks {
+				return
+			}
+			// Clear the references to the blocks so they can be garbage collected.
+			for i := range buf {
+				buf[i] = nil
+			}
+			buf = buf[:0]
+			*pooled = buf
+			pool.Put(pooled)
+		}()
+	} else {
+		buf = make([]*eth.SignedBeaconBlock, 0, sizeHint)
+	}
+
+	limited := false
 	for {
-		blk, err := prysmsync.ReadChunkedBlock(stream, s.p2p)
+		if maxBlocks > 0 && uint64(len(buf)) >= maxBlocks {
+			limited = true
+			break
+		}
+		blk, err := next()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to read chunked block")
+			return nil, false, err
 		}
-		resp = append(resp, blk)
+		buf = append(buf, blk)
 	}
-
-	return resp, nil
+	if pool == nil {
+		return buf, limited, nil
+	}
+	blocks := make([]*eth.SignedBeaconBlock, len(buf))
+	copy(blocks, buf)
+	return blocks, limited, nil
 }
 
 // highestFinalizedEpoch as reported by peers. This is the absolute highest finalized epoch as
@@ -301,12 +1152,29 @@ func (s *Service) highestFinalizedEpoch() uint64 {
 	return epoch
 }
 
-// bestPeer returns the peer ID of the peer reporting the highest head slot.
-func (s *Service) bestPeer() peer.ID {
+// bestPeer returns the peer ID of the peer reporting the highest head slot. Peers reporting a head
+// slot beyond the current slot, allowing for headSlotTolerance slots of clock disparity, can't be
+// on the canonical chain and are penalized rather than selected. Peers below the minimum
+// reliability are not selected either, nor are the excluded peers.
+func (s *Service) bestPeer(genesis time.Time, excluded ...peer.ID) peer.ID {
 	var best peer.ID
 	var bestSlot uint64
-	for _, k := range s.p2p.Peers().Connected() {
+	maxHeadSlot := helpers.SlotsSince(genesis) + headSlotTolerance
+	for _, k := range s.reliablePeers(s.p2p.Peers().Connected()) {
+		if containsPeer(excluded, k) {
+			continue
+		}
 		peerChainState, err := s.p2p.Peers().ChainState(k)
+		if err == nil && peerChainState != nil && peerChainState.HeadSlot > maxHeadSlot {
+			log.WithFields(logrus.Fields{
+				"peer":        k.Pretty(),
+				"headSlot":    peerChainState.HeadSlot,
+				"maxHeadSlot": maxHeadSlot,
+			}).Warn("Peer reported a head slot from the future, not selecting it for sync")
+			s.p2p.Peers().IncrementBadResponses(k)
+			s.misbehavior.record(true /* misbehaved */)
+			continue
+		}
 		if err == nil && peerChainState != nil && peerChainState.HeadSlot >= bestSlot {
 			bestSlot = peerChainState.HeadSlot
 			best = k
@@ -315,14 +1183,52 @@ func (s *Service) bestPeer() peer.ID {
 	return best
 }
 
+// admittedBestPeer returns the best peer, unless it may not be queried under the maximum number of
+// distinct peers, in which case there is no best peer until it is replaced or the limit is lifted.
+func (s *Service) admittedBestPeer(genesis time.Time, excluded ...peer.ID) peer.ID {
+	best := s.bestPeer(genesis, excluded...)
+	if len(best) == 0 {
+		return best
+	}
+	if len(s.admitPeers([]peer.ID{best})) == 0 {
+		log.WithFields(logrus.Fields{
+			"peer":             best.Pretty(),
+			"maxDistinctPeers": flags.Get().SyncMaxDistinctPeers,
+		}).Warn("Reached the maximum number of distinct peers to sync with; waiting for a queried peer to become the best peer")
+		return ""
+	}
+	return best
+}
+
 // logSyncStatus and increment block processing counter.
 func (s *Service) logSyncStatus(genesis time.Time, blk *eth.BeaconBlock, syncingPeers []peer.ID, counter *ratecounter.RateCounter) {
 	counter.Incr(1)
 	rate := float64(counter.Rate()) / counterSeconds
-	if rate == 0 {
-		rate = 1
+	currentSlot := helpers.SlotsSince(genesis)
+	estimate := estimatedTimeRemaining(currentSlot, blk.Slot, counter)
+	s.progressLock.Lock()
+	s.progress = SyncProgress{
+		CurrentSlot:            blk.Slot,
+		HighestSlot:            currentSlot,
+		Peers:                  len(syncingPeers),
+		EstimatedTimeRemaining: estimate,
+	}
+	s.progressLock.Unlock()
+	if flags.Get().SyncLogStructured {
+		etaSeconds := -1.0
+		if remaining, ok := timeRemaining(currentSlot, blk.Slot, counter); ok {
+			etaSeconds = remaining.Seconds()
+		}
+		log.WithFields(logrus.Fields{
+			"currentSlot":     blk.Slot,
+			"highestSlot":     currentSlot,
+			"peers":           len(syncingPeers),
+			"connectedPeers":  len(s.p2p.Peers().Connected()),
+			"blocksPerSecond": rate,
+			"etaSeconds":      etaSeconds,
+		}).Info("Processing block")
+		return
 	}
-	timeRemaining := time.Duration(float64(helpers.SlotsSince(genesis)-blk.Slot)/rate) * time.Second
 	log.WithField(
 		"peers",
 		fmt.Sprintf("%d/%d", len(syncingPeers), len(s.p2p.Peers().Connected())),
@@ -332,7 +1238,33 @@ func (s *Service) logSyncStatus(genesis time.Time, blk *eth.BeaconBlock, syncing
 	).Infof(
 		"Processing block %d/%d - estimated time remaining %s",
 		blk.Slot,
-		helpers.SlotsSince(genesis),
-		timeRemaining,
+		currentSlot,
+		estimate,
 	)
 }
+
+// estimatedTimeRemaining to process blocks up to the current slot at the rate measured by the
+// counter. While the counter holds too few samples for the rate to be meaningful, such as early
+// in sync, no duration is estimated.
+func estimatedTimeRemaining(currentSlot uint64, blockSlot uint64, counter *ratecounter.RateCounter) string {
+	remaining, ok := timeRemaining(currentSlot, blockSlot, counter)
+	if !ok {
+		return "estimating..."
+	}
+	return remaining.String()
+}
+
+// timeRemaining to process blocks up to the current slot, as estimated by
+// estimatedTimeRemaining. It returns false while no duration is estimated.
+func timeRemaining(currentSlot uint64, blockSlot uint64, counter *ratecounter.RateCounter) (time.Duration, bool) {
+	samples := counter.Rate()
+	if samples < minRateSamples {
+		return 0, false
+	}
+	rate := float64(samples) / counterSeconds
+	var slotsRemaining uint64
+	if currentSlot > blockSlot {
+		slotsRemaining = currentSlot - blockSlot
+	}
+	return time.Duration(float64(slotsRemaining)/rate) * time.Second, true
+}
@@ -1,38 +1,56 @@
 package initialsync
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
 	"reflect"
+	"strings"
+	gosync "sync"
 	"testing"
 	"time"
 
 	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/paulbellamy/ratecounter"
+	"github.com/pkg/errors"
 	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	"github.com/prysmaticlabs/go-ssz"
 	mock "github.com/prysmaticlabs/prysm/beacon-chain/blockchain/testing"
 	"github.com/prysmaticlabs/prysm/beacon-chain/core/helpers"
 	dbtest "github.com/prysmaticlabs/prysm/beacon-chain/db/testing"
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+	"github.com/prysmaticlabs/prysm/beacon-chain/p2p"
 	"github.com/prysmaticlabs/prysm/beacon-chain/p2p/peers"
 	p2pt "github.com/prysmaticlabs/prysm/beacon-chain/p2p/testing"
 	"github.com/prysmaticlabs/prysm/beacon-chain/sync"
 	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/bytesutil"
+	"github.com/prysmaticlabs/prysm/shared/featureconfig"
 	"github.com/prysmaticlabs/prysm/shared/hashutil"
 	"github.com/prysmaticlabs/prysm/shared/params"
 	"github.com/prysmaticlabs/prysm/shared/roughtime"
 	"github.com/prysmaticlabs/prysm/shared/sliceutil"
 	"github.com/sirupsen/logrus"
+	logTest "github.com/sirupsen/logrus/hooks/test"
 )
 
 var rootCache map[uint64][32]byte
 var parentSlotCache map[uint64]uint64
 
 type peerData struct {
-	blocks         []uint64 // slots that peer has blocks
-	finalizedEpoch uint64
-	headSlot       uint64
-	failureSlots   []uint64 // slots at which the peer will return an error
-	forkedPeer     bool
+	blocks           []uint64 // slots that peer has blocks
+	finalizedEpoch   uint64
+	headSlot         uint64
+	failureSlots     []uint64 // slots at which the peer will return an error
+	forkedPeer       bool
+	responseDelay    time.Duration                               // time the peer takes before responding to a request
+	outOfRangeBlocks bool                                        // whether the peer responds with blocks outside of the requested range
+	descendingOrder  bool                                        // whether the peer responds with blocks in descending slot order
+	onRequest        func(req *p2ppb.BeaconBlocksByRangeRequest) // called with every request the peer receives
 }
 
 func init() {
@@ -282,6 +300,219 @@ func TestRoundRobinSync(t *testing.T) {
 	}
 }
 
+func TestShufflePeers_DisabledPreservesOrder(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{DisablePeerShuffle: true})
+	defer flags.Init(nil)
+
+	pids := []peer.ID{"a", "b", "c", "d", "e", "f", "g", "h"}
+	want := make([]peer.ID, len(pids))
+	copy(want, pids)
+	shufflePeers(rand.New(rand.NewSource(1)), pids)
+	if !reflect.DeepEqual(pids, want) {
+		t.Errorf("Peer order changed with shuffling disabled. Wanted %v, got %v", want, pids)
+	}
+}
+
+func TestRequestBlocks_MaxBlocksPerPeer(t *testing.T) {
+	maxBlocks := 16
+	flags.Init(&flags.GlobalFlags{MaxBlocksPerPeer: maxBlocks})
+	defer flags.Init(nil)
+
+	initializeRootCache(makeSequence(1, 128), t)
+	p := p2pt.NewTestP2P(t)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         makeSequence(1, 128),
+			finalizedEpoch: 3,
+			headSlot:       128,
+		},
+		{
+			blocks:         makeSequence(1, 8),
+			finalizedEpoch: 3,
+			headSlot:       128,
+		},
+	}, p.Peers())
+	s := &Service{
+		chain: &mock.ChainService{State: &p2ppb.BeaconState{}},
+		p2p:   p,
+	}
+
+	req := &p2ppb.BeaconBlocksByRangeRequest{
+		HeadBlockRoot: []byte("head_root"),
+		StartSlot:     1,
+		Count:         128,
+		Step:          1,
+	}
+	for _, pid := range p.Peers().Connected() {
+		resp, err := s.requestBlocks(context.Background(), req, pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp) > maxBlocks {
+			t.Errorf("Peer %s buffered %d blocks, wanted at most %d", pid.Pretty(), len(resp), maxBlocks)
+		}
+	}
+}
+
+func TestBestFinalizedCache_RefreshesWhenStale(t *testing.T) {
+	p := p2pt.NewTestP2P(t)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         makeSequence(1, 64),
+			finalizedEpoch: 1,
+			headSlot:       64,
+		},
+	}, p.Peers())
+	s := &Service{
+		chain: &mock.ChainService{State: &p2ppb.BeaconState{}},
+		p2p:   p,
+	}
+	root, epoch, _ := p.Peers().BestFinalized(params.BeaconConfig().MaxPeersToSync, 0)
+	c := &bestFinalizedCache{
+		s:       s,
+		root:    root,
+		epoch:   epoch,
+		fetched: time.Now(),
+	}
+	advanceFinalizedEpoch(t, p.Peers(), 3)
+
+	// Until the values are stale, the cached root itself is returned rather than the root the
+	// peers now report.
+	cached, epoch := c.get()
+	if epoch != 1 || &cached[0] != &root[0] {
+		t.Errorf("Expected the cached finalized root %s at epoch %d, got %s at epoch %d", root, 1, cached, epoch)
+	}
+	c.fetched = time.Now().Add(-2 * staleFinalizedTime)
+	refreshed, epoch := c.get()
+	if epoch != 3 {
+		t.Errorf("Expected refreshed finalized epoch %d, got %d", 3, epoch)
+	}
+	if !bytes.Equal(refreshed, []byte("finalized_root 3")) {
+		t.Errorf("Expected refreshed finalized root, got %s", refreshed)
+	}
+
+	// The refreshed values are cached again.
+	advanceFinalizedEpoch(t, p.Peers(), 4)
+	if cached, epoch := c.get(); epoch != 3 || &cached[0] != &refreshed[0] {
+		t.Errorf("Expected the cached finalized root %s at epoch %d, got %s at epoch %d", refreshed, 3, cached, epoch)
+	}
+}
+
+// advancingFinalityChain advances the finalized epoch reported by all peers once the head reaches
+// a given slot, mimicking finality advancing on the network while syncing.
+type advancingFinalityChain struct {
+	*mock.ChainService
+	t              *testing.T
+	peerStatus     *peers.Status
+	advanceAtSlot  uint64
+	finalizedEpoch uint64
+	advanced       bool
+}
+
+func (c *advancingFinalityChain) ReceiveBlockNoPubsubForkchoice(ctx context.Context, blk *eth.SignedBeaconBlock) error {
+	if err := c.ChainService.ReceiveBlockNoPubsubForkchoice(ctx, blk); err != nil {
+		return err
+	}
+	if !c.advanced && blk.Block.Slot >= c.advanceAtSlot {
+		advanceFinalizedEpoch(c.t, c.peerStatus, c.finalizedEpoch)
+		c.advanced = true
+	}
+	return nil
+}
+
+func TestRoundRobinSync_FinalizedEpochAdvancesBetweenBatches(t *testing.T) {
+	hook := logTest.NewGlobal()
+	currentSlot := uint64(288)
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	data := make([]*peerData, 3)
+	for i := range data {
+		data[i] = &peerData{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+		}
+	}
+	connectPeers(t, p, data, p.Peers())
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  genesisRoot[:],
+		DB:    beaconDB,
+	}
+	s := &Service{
+		chain: &advancingFinalityChain{
+			ChainService:   mc,
+			t:              t,
+			peerStatus:     p.Peers(),
+			advanceAtSlot:  blockBatchSize,
+			finalizedEpoch: 8,
+		},
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if s.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", s.chain.HeadSlot(), currentSlot)
+	}
+	if len(mc.BlocksReceived) != int(currentSlot) {
+		t.Errorf("Processes wrong number of blocks. Wanted %d got %d", currentSlot, len(mc.BlocksReceived))
+	}
+	// Step 1 should have targeted the advanced finalized epoch, leaving nothing for Step 2.
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Sending batch block request" {
+			t.Fatal("Expected initial sync to reach the head without syncing from a single peer")
+		}
+	}
+}
+
+// advanceFinalizedEpoch updates the chain state of all connected peers to report the given
+// finalized epoch.
+func advanceFinalizedEpoch(t *testing.T, peerStatus *peers.Status, finalizedEpoch uint64) {
+	for _, pid := range peerStatus.Connected() {
+		cs, err := peerStatus.ChainState(pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		peerStatus.SetChainState(pid, &p2ppb.Status{
+			HeadForkVersion: cs.HeadForkVersion,
+			FinalizedRoot:   []byte(fmt.Sprintf("finalized_root %d", finalizedEpoch)),
+			FinalizedEpoch:  finalizedEpoch,
+			HeadRoot:        cs.HeadRoot,
+			HeadSlot:        cs.HeadSlot,
+		})
+	}
+}
+
+func TestEstimatedTimeRemaining(t *testing.T) {
+	unwarmed := ratecounter.NewRateCounter(counterSeconds * time.Second)
+	if got := estimatedTimeRemaining(100, 10, unwarmed); got != "estimating..." {
+		t.Errorf("Expected no estimate with an unwarmed rate counter, got %s", got)
+	}
+
+	warmed := ratecounter.NewRateCounter(counterSeconds * time.Second)
+	warmed.Incr(2 * counterSeconds) // 2 blocks per second.
+	if got := estimatedTimeRemaining(100, 10, warmed); got != (45 * time.Second).String() {
+		t.Errorf("Wanted estimate of %s, got %s", 45*time.Second, got)
+	}
+	if got := estimatedTimeRemaining(10, 100, warmed); got != (0 * time.Second).String() {
+		t.Errorf("Expected zero estimate for a block past the current slot, got %s", got)
+	}
+}
+
 // Connect peers with local host. This method sets up peer statuses and the appropriate handlers
 // for each test peer.
 func connectPeers(t *testing.T, host *p2pt.TestP2P, data []*peerData, peerStatus *peers.Status) {
@@ -300,6 +531,10 @@ func connectPeers(t *testing.T, host *p2pt.TestP2P, data []*peerData, peerStatus
 			if err := peer.Encoding().DecodeWithLength(stream, req); err != nil {
 				t.Error(err)
 			}
+			if datum.onRequest != nil {
+				datum.onRequest(req)
+			}
+			time.Sleep(datum.responseDelay)
 
 			requestedBlocks := makeSequence(req.StartSlot, req.StartSlot+(req.Count*req.Step))
 
@@ -318,8 +553,9 @@ func connectPeers(t *testing.T, host *p2pt.TestP2P, data []*peerData, peerStatus
 			blocks := sliceutil.IntersectionUint64(datum.blocks, requestedBlocks)
 
 			ret := make([]*eth.SignedBeaconBlock, 0)
+			lastSlot := req.StartSlot + req.Step*(req.Count-1)
 			for _, slot := range blocks {
-				if (slot-req.StartSlot)%req.Step != 0 {
+				if (slot-req.StartSlot)%req.Step != 0 || slot > lastSlot {
 					continue
 				}
 				parentRoot := rootCache[parentSlotCache[slot]]
@@ -329,6 +565,10 @@ func connectPeers(t *testing.T, host *p2pt.TestP2P, data []*peerData, peerStatus
 						ParentRoot: parentRoot[:],
 					},
 				}
+				// If the peer misbehaves, respond with a block after the requested range.
+				if datum.outOfRangeBlocks {
+					blk.Block.Slot = lastSlot + req.Step
+				}
 				// If forked peer, give a different parent root.
 				if datum.forkedPeer {
 					newRoot := hashutil.Hash(parentRoot[:])
@@ -342,6 +582,11 @@ func connectPeers(t *testing.T, host *p2pt.TestP2P, data []*peerData, peerStatus
 			if uint64(len(ret)) > req.Count {
 				ret = ret[:req.Count]
 			}
+			if datum.descendingOrder {
+				for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+					ret[i], ret[j] = ret[j], ret[i]
+				}
+			}
 
 			for i := 0; i < len(ret); i++ {
 				if err := sync.WriteChunk(stream, peer.Encoding(), ret[i]); err != nil {
@@ -426,3 +671,1820 @@ func TestMakeSequence(t *testing.T) {
 		t.Fatalf("Wanted %v, got %v", want, got)
 	}
 }
+
+// slowDBChainService simulates a chain service backed by a slow disk, where every db write
+// costs a fixed latency regardless of how many blocks are written.
+type slowDBChainService struct {
+	*mock.ChainService
+	writeLatency time.Duration
+}
+
+func (s *slowDBChainService) ReceiveBlockNoVerify(ctx context.Context, block *eth.SignedBeaconBlock) error {
+	time.Sleep(s.writeLatency)
+	return s.ChainService.ReceiveBlockNoPubsubForkchoice(ctx, block)
+}
+
+func (s *slowDBChainService) ReceiveBlockBatch(ctx context.Context, blocks []*eth.SignedBeaconBlock) error {
+	time.Sleep(s.writeLatency)
+	return s.ChainService.ReceiveBlockBatch(ctx, blocks)
+}
+
+// peersOnlyP2P satisfies p2p.P2P for code paths which only need the peer status.
+type peersOnlyP2P struct {
+	p2p.P2P
+	peers *peers.Status
+}
+
+func (p *peersOnlyP2P) Peers() *peers.Status {
+	return p.peers
+}
+
+func BenchmarkProcessBlocks_PerBlockWrites(b *testing.B) {
+	benchmarkProcessBlocks(b, 0 /* batchSize */)
+}
+
+func BenchmarkProcessBlocks_BatchedWrites(b *testing.B) {
+	benchmarkProcessBlocks(b, blockBatchSize)
+}
+
+func benchmarkProcessBlocks(b *testing.B, batchSize int) {
+	featureconfig.Init(&featureconfig.Flags{InitSyncNoVerify: true})
+	defer featureconfig.Init(nil)
+	flags.Init(&flags.GlobalFlags{InitSyncBatchSaveBlocks: batchSize})
+	defer flags.Init(nil)
+
+	beaconDB := dbtest.SetupDB(b)
+	defer dbtest.TeardownDB(b, beaconDB)
+	genesisBlock := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: 0}}
+	if err := beaconDB.SaveBlock(context.Background(), genesisBlock); err != nil {
+		b.Fatal(err)
+	}
+	genesisRoot, err := ssz.HashTreeRoot(genesisBlock.Block)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	blocks := make([]*eth.SignedBeaconBlock, blockBatchSize)
+	parentRoot := genesisRoot
+	for i := range blocks {
+		blocks[i] = &eth.SignedBeaconBlock{
+			Block: &eth.BeaconBlock{
+				Slot:       uint64(i + 1),
+				ParentRoot: parentRoot[:],
+			},
+		}
+		parentRoot, err = ssz.HashTreeRoot(blocks[i].Block)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	logrus.SetLevel(logrus.ErrorLevel)
+	defer logrus.SetLevel(logrus.DebugLevel)
+	genesis := makeGenesisTime(uint64(len(blocks)))
+	counter := ratecounter.NewRateCounter(counterSeconds * time.Second)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		s := &Service{
+			chain: &slowDBChainService{
+				ChainService: &mock.ChainService{
+					State: &p2ppb.BeaconState{},
+					Root:  genesisRoot[:],
+				},
+				writeLatency: time.Millisecond,
+			},
+			db:  beaconDB,
+			p2p: &peersOnlyP2P{peers: peers.NewStatus(5 /* maxBadResponses */)},
+		}
+		b.StartTimer()
+		if err := s.processBlocks(context.Background(), genesis, blocks, nil /* peers */, counter); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestValidateRangeRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *p2ppb.BeaconBlocksByRangeRequest
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			req:  &p2ppb.BeaconBlocksByRangeRequest{StartSlot: 64, Count: 64, Step: 1},
+		},
+		{
+			name: "valid at maximum count",
+			req:  &p2ppb.BeaconBlocksByRangeRequest{StartSlot: 64, Count: params.BeaconConfig().MaxRequestBlocks, Step: 4},
+		},
+		{
+			name: "valid single block at the last slot",
+			req:  &p2ppb.BeaconBlocksByRangeRequest{StartSlot: math.MaxUint64, Count: 1, Step: 1000},
+		},
+		{
+			name:    "zero count",
+			req:     &p2ppb.BeaconBlocksByRangeRequest{StartSlot: 64, Count: 0, Step: 1},
+			wantErr: true,
+		},
+		{
+			name:    "zero step",
+			req:     &p2ppb.BeaconBlocksByRangeRequest{StartSlot: 64, Count: 64, Step: 0},
+			wantErr: true,
+		},
+		{
+			name:    "count over maximum",
+			req:     &p2ppb.BeaconBlocksByRangeRequest{StartSlot: 64, Count: params.BeaconConfig().MaxRequestBlocks + 1, Step: 1},
+			wantErr: true,
+		},
+		{
+			name: "valid at maximum span",
+			req: &p2ppb.BeaconBlocksByRangeRequest{
+				StartSlot: 64,
+				Count:     params.BeaconConfig().MaxRequestBlocks,
+				Step:      uint64(params.BeaconConfig().MaxPeersToSync),
+			},
+		},
+		{
+			name: "span over maximum",
+			req: &p2ppb.BeaconBlocksByRangeRequest{
+				StartSlot: 64,
+				Count:     params.BeaconConfig().MaxRequestBlocks,
+				Step:      uint64(params.BeaconConfig().MaxPeersToSync) + 1,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "range overflows",
+			req:     &p2ppb.BeaconBlocksByRangeRequest{StartSlot: math.MaxUint64 - 10, Count: 12, Step: 1},
+			wantErr: true,
+		},
+		{
+			name:    "step overflows",
+			req:     &p2ppb.BeaconBlocksByRangeRequest{StartSlot: 1, Count: 3, Step: math.MaxUint64/2 + 1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRangeRequest(tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRangeRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequestBlocks_InvalidRequestNotAttributedToPeer(t *testing.T) {
+	p := p2pt.NewTestP2P(t)
+	s := &Service{p2p: p}
+	pid := peer.ID("peer")
+	req := &p2ppb.BeaconBlocksByRangeRequest{StartSlot: 64, Count: 0, Step: 1}
+
+	_, err := s.requestBlocks(context.Background(), req, pid)
+	if errors.Cause(err) != ErrInvalidRangeRequest {
+		t.Fatalf("Wanted an invalid request error, received %v", err)
+	}
+	if peerFault(context.Background(), err) {
+		t.Error("Wanted an invalid request not to be attributed to the peer")
+	}
+	if _, ok := s.PeerScores()[pid]; ok {
+		t.Error("Wanted no score recorded for the peer")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if peerFault(ctx, errors.New("stream reset")) {
+		t.Error("Wanted a failure after sync was cancelled not to be attributed to the peer")
+	}
+	if !peerFault(context.Background(), errors.New("stream reset")) {
+		t.Error("Wanted a failed response to be attributed to the peer")
+	}
+}
+
+// slowProcessingChainService simulates a chain service which takes a fixed time to process each
+// block. Like the real chain service, the head may be read while a block is being processed.
+type slowProcessingChainService struct {
+	*mock.ChainService
+	processLatency time.Duration
+	lock           gosync.RWMutex
+}
+
+func (s *slowProcessingChainService) ReceiveBlockNoPubsubForkchoice(ctx context.Context, block *eth.SignedBeaconBlock) error {
+	time.Sleep(s.processLatency)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.ChainService.ReceiveBlockNoPubsubForkchoice(ctx, block)
+}
+
+func (s *slowProcessingChainService) HeadSlot() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.ChainService.HeadSlot()
+}
+
+func TestRoundRobinSync_PrefetchOverlapsRequests(t *testing.T) {
+	currentSlot := uint64(320)
+	syncDuration := func(prefetch bool) time.Duration {
+		flags.Init(&flags.GlobalFlags{PrefetchNextBatch: prefetch})
+		defer flags.Init(nil)
+		initializeRootCache(makeSequence(1, currentSlot), t)
+
+		p := p2pt.NewTestP2P(t)
+		beaconDB := dbtest.SetupDB(t)
+		defer dbtest.TeardownDB(t, beaconDB)
+		var data []*peerData
+		for i := 0; i < 3; i++ {
+			data = append(data, &peerData{
+				blocks:         makeSequence(1, currentSlot),
+				finalizedEpoch: 8,
+				headSlot:       currentSlot,
+				responseDelay:  200 * time.Millisecond,
+			})
+		}
+		connectPeers(t, p, data, p.Peers())
+		genesisRoot := rootCache[0]
+		if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+			Block: &eth.BeaconBlock{
+				Slot: 0,
+			}}); err != nil {
+			t.Fatal(err)
+		}
+
+		mc := &mock.ChainService{
+			State: &p2ppb.BeaconState{},
+			Root:  genesisRoot[:],
+			DB:    beaconDB,
+		}
+		s := &Service{
+			chain: &slowProcessingChainService{
+				ChainService:   mc,
+				processLatency: 2 * time.Millisecond,
+			},
+			p2p:          p,
+			db:           beaconDB,
+			chainStarted: true,
+		}
+		start := time.Now()
+		if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+			t.Fatal(err)
+		}
+		elapsed := time.Since(start)
+		if s.chain.HeadSlot() != currentSlot {
+			t.Errorf("Head slot (%d) is not currentSlot (%d)", s.chain.HeadSlot(), currentSlot)
+		}
+		if len(mc.BlocksReceived) != int(currentSlot) {
+			t.Errorf("Processes wrong number of blocks. Wanted %d got %d", currentSlot, len(mc.BlocksReceived))
+		}
+		return elapsed
+	}
+
+	sequential := syncDuration(false)
+	overlapped := syncDuration(true)
+	t.Logf("Sync took %v without prefetching and %v with prefetching", sequential, overlapped)
+	if overlapped >= sequential {
+		t.Errorf("Expected prefetching to reduce sync time, took %v with and %v without", overlapped, sequential)
+	}
+}
+
+func TestAwaitPrefetched(t *testing.T) {
+	blocks := []*eth.SignedBeaconBlock{{Block: &eth.BeaconBlock{Slot: 65}}}
+	batch := prefetchBatch(context.Background(), 65, func(ctx context.Context) ([]*eth.SignedBeaconBlock, error) {
+		return blocks, nil
+	})
+	got, ok := awaitPrefetched(batch, 65)
+	if !ok || !reflect.DeepEqual(got, blocks) {
+		t.Errorf("Wanted prefetched blocks %v, received %v", blocks, got)
+	}
+
+	// The request of a discarded batch is cancelled rather than left running.
+	pending := prefetchBatch(context.Background(), 65, func(ctx context.Context) ([]*eth.SignedBeaconBlock, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if _, ok := awaitPrefetched(pending, 70); ok {
+		t.Error("Expected prefetched batch for a different start slot to be discarded")
+	}
+	select {
+	case <-pending.done:
+	case <-time.After(time.Second):
+		t.Error("Expected the request of the discarded batch to be cancelled")
+	}
+
+	failed := prefetchBatch(context.Background(), 65, func(ctx context.Context) ([]*eth.SignedBeaconBlock, error) {
+		return nil, errors.New("failed")
+	})
+	if _, ok := awaitPrefetched(failed, 65); ok {
+		t.Error("Expected failed prefetched batch to be discarded")
+	}
+	if _, ok := awaitPrefetched(nil, 65); ok {
+		t.Error("Expected no blocks without a prefetched batch")
+	}
+}
+
+func TestRoundRobinSync_VerifyFinalizedRoot(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{VerifyFinalizedRoot: true})
+	defer flags.Init(nil)
+
+	tests := []struct {
+		name      string
+		matching  bool
+		wantError error
+	}{
+		{
+			name:     "advertised finalized root was synced",
+			matching: true,
+		},
+		{
+			name:      "advertised finalized root was not synced",
+			matching:  false,
+			wantError: ErrFinalizedRootMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currentSlot := uint64(131)
+			initializeRootCache(makeSequence(1, currentSlot), t)
+
+			p := p2pt.NewTestP2P(t)
+			beaconDB := dbtest.SetupDB(t)
+			defer dbtest.TeardownDB(t, beaconDB)
+			var data []*peerData
+			for i := 0; i < 2; i++ {
+				data = append(data, &peerData{
+					blocks:         makeSequence(1, currentSlot),
+					finalizedEpoch: 1,
+					headSlot:       currentSlot,
+				})
+			}
+			connectPeers(t, p, data, p.Peers())
+			// The checkpoint block of epoch 1 is the block at its start slot.
+			finalizedRoot := rootCache[helpers.StartSlot(1)]
+			if !tt.matching {
+				finalizedRoot = hashutil.Hash([]byte("unknown finalized root"))
+			}
+			for _, pid := range p.Peers().Connected() {
+				chainState, err := p.Peers().ChainState(pid)
+				if err != nil {
+					t.Fatal(err)
+				}
+				chainState.FinalizedRoot = finalizedRoot[:]
+				p.Peers().SetChainState(pid, chainState)
+			}
+
+			genesisRoot := rootCache[0]
+			if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+				Block: &eth.BeaconBlock{
+					Slot: 0,
+				}}); err != nil {
+				t.Fatal(err)
+			}
+			mc := &mock.ChainService{
+				State: &p2ppb.BeaconState{},
+				Root:  genesisRoot[:],
+				DB:    beaconDB,
+			}
+			s := &Service{
+				chain:        mc,
+				p2p:          p,
+				db:           beaconDB,
+				chainStarted: true,
+			}
+			err := s.roundRobinSync(makeGenesisTime(currentSlot))
+			if errors.Cause(err) != tt.wantError {
+				t.Fatalf("Wanted error %v, received %v", tt.wantError, err)
+			}
+			for _, pid := range p.Peers().Connected() {
+				badResponses, err := p.Peers().BadResponses(pid)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.matching && badResponses != 0 {
+					t.Errorf("Expected no bad responses for peer %s, received %d", pid, badResponses)
+				}
+				if !tt.matching && badResponses == 0 {
+					t.Errorf("Expected peer %s to be penalized for the mismatching finalized root", pid)
+				}
+			}
+		})
+	}
+}
+
+// blockReader returns a function which yields the given blocks and then io.EOF.
+func blockReader(blocks []*eth.SignedBeaconBlock) func() (*eth.SignedBeaconBlock, error) {
+	i := 0
+	return func() (*eth.SignedBeaconBlock, error) {
+		if i >= len(blocks) {
+			return nil, io.EOF
+		}
+		i++
+		return blocks[i-1], nil
+	}
+}
+
+func TestReadBlocks_PooledSliceNotShared(t *testing.T) {
+	pool := &gosync.Pool{
+		New: func() interface{} {
+			blocks := make([]*eth.SignedBeaconBlock, 0, 4)
+			return &blocks
+		},
+	}
+	first := []*eth.SignedBeaconBlock{
+		{Block: &eth.BeaconBlock{Slot: 1}},
+		{Block: &eth.BeaconBlock{Slot: 2}},
+	}
+	second := []*eth.SignedBeaconBlock{
+		{Block: &eth.BeaconBlock{Slot: 3}},
+	}
+
+	got, limited, err := readBlocks(blockReader(first), 0 /* maxBlocks */, pool, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limited {
+		t.Error("Did not expect reading to be limited")
+	}
+	// Reading again reuses the pooled slice, which must not affect the blocks returned earlier.
+	if _, _, err := readBlocks(blockReader(second), 0 /* maxBlocks */, pool, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, first) {
+		t.Errorf("Wanted blocks %v, received %v", first, got)
+	}
+
+	got, limited, err = readBlocks(blockReader(first), 1 /* maxBlocks */, pool, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !limited || len(got) != 1 {
+		t.Errorf("Wanted 1 block and reading to be limited, received %d blocks and limited %v", len(got), limited)
+	}
+}
+
+func BenchmarkReadBlocks_Unpooled(b *testing.B) {
+	benchmarkReadBlocks(b, nil /* pool */)
+}
+
+func BenchmarkReadBlocks_Pooled(b *testing.B) {
+	benchmarkReadBlocks(b, &blockSlicePool)
+}
+
+func benchmarkReadBlocks(b *testing.B, pool *gosync.Pool) {
+	blocks := make([]*eth.SignedBeaconBlock, blockBatchSize/4)
+	for i := range blocks {
+		blocks[i] = &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: uint64(i)}}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			// Peers are asked for a full batch, but typically return fewer blocks.
+			if _, _, err := readBlocks(blockReader(blocks), 0 /* maxBlocks */, pool, params.BeaconConfig().MaxRequestBlocks); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestBestPeer_IgnoresFutureHeadSlot(t *testing.T) {
+	currentSlot := uint64(100)
+	p := p2pt.NewTestP2P(t)
+	chainStates := map[peer.ID]uint64{
+		"honest":        currentSlot,
+		"behind":        currentSlot - 10,
+		"withinSkew":    currentSlot + headSlotTolerance,
+		"fromTheFuture": currentSlot + 1000000,
+	}
+	for pid, headSlot := range chainStates {
+		p.Peers().Add(pid, nil, network.DirOutbound)
+		p.Peers().SetConnectionState(pid, peers.PeerConnected)
+		p.Peers().SetChainState(pid, &p2ppb.Status{HeadSlot: headSlot})
+	}
+	s := &Service{p2p: p}
+
+	if best := s.bestPeer(makeGenesisTime(currentSlot)); best != "withinSkew" {
+		t.Errorf("Wanted best peer %s, received %s", peer.ID("withinSkew"), best)
+	}
+	badResponses, err := p.Peers().BadResponses("fromTheFuture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if badResponses == 0 {
+		t.Error("Expected peer reporting a head slot from the future to be penalized")
+	}
+	badResponses, err = p.Peers().BadResponses("honest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if badResponses != 0 {
+		t.Errorf("Expected no bad responses for honest peer, received %d", badResponses)
+	}
+}
+
+func TestRoundRobinSync_MaxFailoverDepth(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxDepth  int
+		wantError string
+		wantCause error
+	}{
+		{
+			name:      "unlimited depth splits until no peers are left",
+			maxDepth:  0,
+			wantError: "no peers left to request blocks",
+			wantCause: ErrNoPeers,
+		},
+		{
+			name:      "depth capped",
+			maxDepth:  1,
+			wantError: "exceeded maximum failover depth of 1",
+			wantCause: ErrRetryBudgetExhausted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags.Init(&flags.GlobalFlags{MaxFailoverDepth: tt.maxDepth})
+			defer flags.Init(nil)
+
+			currentSlot := uint64(131)
+			initializeRootCache(makeSequence(1, currentSlot), t)
+
+			p := p2pt.NewTestP2P(t)
+			beaconDB := dbtest.SetupDB(t)
+			defer dbtest.TeardownDB(t, beaconDB)
+			// Every peer fails to serve the first epoch, so each failed request is split again.
+			var data []*peerData
+			for i := 0; i < 4; i++ {
+				data = append(data, &peerData{
+					blocks:         makeSequence(1, currentSlot),
+					failureSlots:   makeSequence(1, 32),
+					finalizedEpoch: 1,
+					headSlot:       currentSlot,
+				})
+			}
+			connectPeers(t, p, data, p.Peers())
+
+			genesisRoot := rootCache[0]
+			if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+				Block: &eth.BeaconBlock{
+					Slot: 0,
+				}}); err != nil {
+				t.Fatal(err)
+			}
+			mc := &mock.ChainService{
+				State: &p2ppb.BeaconState{},
+				Root:  genesisRoot[:],
+				DB:    beaconDB,
+			}
+			s := &Service{
+				chain:        mc,
+				p2p:          p,
+				db:           beaconDB,
+				chainStarted: true,
+			}
+			err := s.roundRobinSync(makeGenesisTime(currentSlot))
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("Wanted error containing %q, received %v", tt.wantError, err)
+			}
+			if errors.Cause(err) != tt.wantCause {
+				t.Errorf("Wanted error caused by %v, received %v", tt.wantCause, errors.Cause(err))
+			}
+		})
+	}
+}
+
+func TestValidateRangeResponse(t *testing.T) {
+	req := &p2ppb.BeaconBlocksByRangeRequest{
+		StartSlot: 10,
+		Count:     4,
+		Step:      2,
+	}
+	blocksAt := func(slots ...uint64) []*eth.SignedBeaconBlock {
+		blks := make([]*eth.SignedBeaconBlock, len(slots))
+		for i, slot := range slots {
+			blks[i] = &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: slot}}
+		}
+		return blks
+	}
+	tests := []struct {
+		name    string
+		blocks  []*eth.SignedBeaconBlock
+		wantErr bool
+	}{
+		{name: "all requested slots", blocks: blocksAt(10, 12, 14, 16)},
+		{name: "skipped slots", blocks: blocksAt(12, 16)},
+		{name: "no blocks", blocks: nil},
+		{name: "too many blocks", blocks: blocksAt(10, 12, 14, 16, 16), wantErr: true},
+		{name: "before start slot", blocks: blocksAt(8), wantErr: true},
+		{name: "after last slot", blocks: blocksAt(18), wantErr: true},
+		{name: "between steps", blocks: blocksAt(11), wantErr: true},
+		{name: "nil block", blocks: []*eth.SignedBeaconBlock{{}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRangeResponse(req, tt.blocks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateRangeResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && errors.Cause(err) != ErrPeerMisbehavior {
+				t.Errorf("Wanted error caused by %v, received %v", ErrPeerMisbehavior, err)
+			}
+		})
+	}
+}
+
+func TestRequestBlocks_PeerMisbehavior(t *testing.T) {
+	initializeRootCache(makeSequence(1, 128), t)
+	p := p2pt.NewTestP2P(t)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:           makeSequence(1, 128),
+			finalizedEpoch:   3,
+			headSlot:         128,
+			outOfRangeBlocks: true,
+		},
+	}, p.Peers())
+	s := &Service{
+		chain: &mock.ChainService{State: &p2ppb.BeaconState{}},
+		p2p:   p,
+	}
+
+	req := &p2ppb.BeaconBlocksByRangeRequest{
+		HeadBlockRoot: []byte("head_root"),
+		StartSlot:     1,
+		Count:         32,
+		Step:          1,
+	}
+	pid := p.Peers().Connected()[0]
+	if _, err := s.requestBlocks(context.Background(), req, pid); errors.Cause(err) != ErrPeerMisbehavior {
+		t.Fatalf("Wanted error caused by %v, received %v", ErrPeerMisbehavior, err)
+	}
+	badResponses, err := p.Peers().BadResponses(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if badResponses != 1 {
+		t.Errorf("Wanted 1 bad response for the misbehaving peer, received %d", badResponses)
+	}
+}
+
+func TestRoundRobinSync_HeadSlotTolerance(t *testing.T) {
+	tests := []struct {
+		name         string
+		tolerance    uint64
+		wantRequests int
+	}{
+		{
+			name:         "exact head required",
+			tolerance:    0,
+			wantRequests: 2,
+		},
+		{
+			name:         "within tolerance of head",
+			tolerance:    2,
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags.Init(&flags.GlobalFlags{HeadSyncSlotTolerance: tt.tolerance})
+			defer flags.Init(nil)
+			hook := logTest.NewGlobal()
+
+			currentSlot := uint64(131)
+			initializeRootCache(makeSequence(1, currentSlot), t)
+
+			p := p2pt.NewTestP2P(t)
+			beaconDB := dbtest.SetupDB(t)
+			defer dbtest.TeardownDB(t, beaconDB)
+			// The peer has yet to receive the blocks of the last two slots.
+			connectPeers(t, p, []*peerData{
+				{
+					blocks:         makeSequence(1, currentSlot-2),
+					finalizedEpoch: 1,
+					headSlot:       currentSlot,
+				},
+			}, p.Peers())
+
+			genesisRoot := rootCache[0]
+			if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+				Block: &eth.BeaconBlock{
+					Slot: 0,
+				}}); err != nil {
+				t.Fatal(err)
+			}
+			mc := &mock.ChainService{
+				State: &p2ppb.BeaconState{},
+				Root:  genesisRoot[:],
+				DB:    beaconDB,
+			}
+			s := &Service{
+				chain:        mc,
+				p2p:          p,
+				db:           beaconDB,
+				chainStarted: true,
+			}
+			if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+				t.Fatal(err)
+			}
+			if s.chain.HeadSlot() != currentSlot-2 {
+				t.Errorf("Head slot (%d) is not %d", s.chain.HeadSlot(), currentSlot-2)
+			}
+			var requests int
+			for _, entry := range hook.AllEntries() {
+				if entry.Message == "Sending batch block request" {
+					requests++
+				}
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Wanted %d requests to sync to head, received %d", tt.wantRequests, requests)
+			}
+		})
+	}
+}
+
+func TestResync_HandsOffToGossipNearHead(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{HeadSyncSlotTolerance: 2, MinimumSyncPeers: 1})
+	defer flags.Init(nil)
+	hook := logTest.NewGlobal()
+
+	currentSlot := uint64(131)
+	// The peer would keep serving blocks up to the current slot if asked.
+	h, teardown := newSyncTestHarness(t, currentSlot, []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 1,
+			headSlot:       currentSlot,
+		},
+	})
+	defer teardown()
+	h.chain.State = &p2ppb.BeaconState{GenesisTime: uint64(makeGenesisTime(currentSlot).Unix())}
+	h.service.syncComplete = make(chan struct{})
+
+	if err := h.service.Resync(); err != nil {
+		t.Fatal(err)
+	}
+	var requests int
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Sending batch block request" {
+			requests++
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Wanted range requests to stop once within the tolerance of head, received %d requests", requests)
+	}
+	// Regular sync accepts gossip once initial sync is no longer syncing.
+	if h.service.Syncing() {
+		t.Error("Wanted sync to hand off to gossip near the head")
+	}
+	select {
+	case <-h.service.SyncComplete():
+	default:
+		t.Error("Wanted sync completion to be notified")
+	}
+}
+
+func TestPartialBatchSuffix(t *testing.T) {
+	blocksAt := func(slots ...uint64) []*eth.SignedBeaconBlock {
+		blks := make([]*eth.SignedBeaconBlock, len(slots))
+		for i, slot := range slots {
+			blks[i] = &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: slot}}
+		}
+		return blks
+	}
+	tests := []struct {
+		name     string
+		blocks   []*eth.SignedBeaconBlock
+		wantNext uint64
+		wantOk   bool
+	}{
+		{name: "empty batch", blocks: nil},
+		{name: "complete batch", blocks: blocksAt(1, 2, 64)},
+		{name: "skipped slots near the end", blocks: blocksAt(1, 40)},
+		{name: "prefix served", blocks: blocksAt(3, 1, 2), wantNext: 4, wantOk: true},
+		{name: "half of the range served", blocks: blocksAt(32)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, ok := partialBatchSuffix(tt.blocks, 1, 64)
+			if ok != tt.wantOk || next != tt.wantNext {
+				t.Errorf("partialBatchSuffix() = (%d, %v), want (%d, %v)", next, ok, tt.wantNext, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRoundRobinSync_RequestsMissingSuffixOfPartialBatch(t *testing.T) {
+	// The peer only serves a prefix of each requested range.
+	flags.Init(&flags.GlobalFlags{MaxBlocksPerPeer: 16})
+	defer flags.Init(nil)
+	hook := logTest.NewGlobal()
+
+	currentSlot := uint64(131)
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 1,
+			headSlot:       currentSlot,
+		},
+	}, p.Peers())
+
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  genesisRoot[:],
+		DB:    beaconDB,
+	}
+	s := &Service{
+		chain:        mc,
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if s.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", s.chain.HeadSlot(), currentSlot)
+	}
+
+	var found bool
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Requesting missing suffix of partially served batch" &&
+			entry.Data["start"] == uint64(17) && entry.Data["count"] == uint64(47) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected the missing suffix of the first batch to be requested")
+	}
+}
+
+// chainSwitchingChainService switches the connected peers from the peers serving the canned chain
+// to the peers serving a different chain once the block at changeSlot is processed, simulating
+// peers converging on a different finalized chain.
+type chainSwitchingChainService struct {
+	*reorgChainService
+	p          *p2pt.TestP2P
+	changeSlot uint64
+	oldPeers   []peer.ID
+	newPeers   []peer.ID
+}
+
+func (s *chainSwitchingChainService) ReceiveBlockNoPubsubForkchoice(ctx context.Context, block *eth.SignedBeaconBlock) error {
+	if err := s.reorgChainService.ReceiveBlockNoPubsubForkchoice(ctx, block); err != nil {
+		return err
+	}
+	if block.Block.Slot != s.changeSlot || len(s.oldPeers) == 0 {
+		return nil
+	}
+	for _, pid := range s.oldPeers {
+		s.p.Peers().SetConnectionState(pid, peers.PeerDisconnected)
+	}
+	for _, pid := range s.newPeers {
+		s.p.Peers().SetConnectionState(pid, peers.PeerConnected)
+	}
+	s.oldPeers = nil
+	return nil
+}
+
+// connectForkedChainPeer connects a peer serving the given chain of blocks, indexed by slot, which
+// reports the given finalized checkpoint. The peer is not considered connected until it is marked
+// as such. Requests received by the peer are passed to onRequest.
+func connectForkedChainPeer(t *testing.T, host *p2pt.TestP2P, chain map[uint64]*eth.SignedBeaconBlock, finalizedEpoch uint64, headSlot uint64, onRequest func(req *p2ppb.BeaconBlocksByRangeRequest)) peer.ID {
+	const topic = "/eth2/beacon_chain/req/beacon_blocks_by_range/1/ssz"
+	forked := p2pt.NewTestP2P(t)
+	forked.SetStreamHandler(topic, func(stream network.Stream) {
+		defer stream.Close()
+		req := &p2ppb.BeaconBlocksByRangeRequest{}
+		if err := forked.Encoding().DecodeWithLength(stream, req); err != nil {
+			t.Error(err)
+		}
+		onRequest(req)
+		for i := uint64(0); i < req.Count; i++ {
+			blk, ok := chain[req.StartSlot+i*req.Step]
+			if !ok {
+				continue
+			}
+			if err := sync.WriteChunk(stream, forked.Encoding(), blk); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	forked.Connect(host)
+
+	host.Peers().Add(forked.PeerID(), nil, network.DirOutbound)
+	host.Peers().SetConnectionState(forked.PeerID(), peers.PeerDisconnected)
+	host.Peers().SetChainState(forked.PeerID(), &p2ppb.Status{
+		HeadForkVersion: params.BeaconConfig().GenesisForkVersion,
+		FinalizedRoot:   []byte(fmt.Sprintf("forked finalized_root %d", finalizedEpoch)),
+		FinalizedEpoch:  finalizedEpoch,
+		HeadRoot:        []byte("forked head_root"),
+		HeadSlot:        headSlot,
+	})
+	return forked.PeerID()
+}
+
+func TestRoundRobinSync_RestartsOnFinalizedCheckpointOfDifferentChain(t *testing.T) {
+	tests := []struct {
+		name                 string
+		forkedFinalizedEpoch uint64
+	}{
+		{
+			name:                 "different root for the same finalized epoch",
+			forkedFinalizedEpoch: 3,
+		},
+		{
+			name:                 "higher finalized epoch on a different chain",
+			forkedFinalizedEpoch: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := logTest.NewGlobal()
+			currentSlot := uint64(200)
+			initializeRootCache(makeSequence(1, currentSlot), t)
+
+			p := p2pt.NewTestP2P(t)
+			beaconDB := dbtest.SetupDB(t)
+			defer dbtest.TeardownDB(t, beaconDB)
+			connectPeers(t, p, []*peerData{
+				{
+					blocks:         makeSequence(1, currentSlot),
+					finalizedEpoch: 3,
+					headSlot:       currentSlot,
+				},
+			}, p.Peers())
+			oldPeers := p.Peers().Connected()
+
+			// The forked chain branches off at genesis.
+			genesisRoot := rootCache[0]
+			forkedChain := make(map[uint64]*eth.SignedBeaconBlock)
+			forkedRoots := make(map[[32]byte]bool)
+			parentRoot := genesisRoot
+			for slot := uint64(1); slot <= currentSlot; slot++ {
+				blk := &eth.SignedBeaconBlock{
+					Block: &eth.BeaconBlock{
+						Slot:       slot,
+						ParentRoot: parentRoot[:],
+						StateRoot:  []byte("forked chain"),
+					},
+				}
+				root, err := ssz.HashTreeRoot(blk.Block)
+				if err != nil {
+					t.Fatal(err)
+				}
+				forkedChain[slot] = blk
+				forkedRoots[root] = true
+				parentRoot = root
+			}
+			forkedHeadRoot := parentRoot
+			var forkedReqsLock gosync.Mutex
+			var forkedReqs []*p2ppb.BeaconBlocksByRangeRequest
+			newPeer := connectForkedChainPeer(t, p, forkedChain, tt.forkedFinalizedEpoch, currentSlot, func(req *p2ppb.BeaconBlocksByRangeRequest) {
+				forkedReqsLock.Lock()
+				defer forkedReqsLock.Unlock()
+				forkedReqs = append(forkedReqs, req)
+			})
+
+			if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+				Block: &eth.BeaconBlock{
+					Slot: 0,
+				}}); err != nil {
+				t.Fatal(err)
+			}
+			mc := &mock.ChainService{
+				State: &p2ppb.BeaconState{},
+				Root:  genesisRoot[:],
+				DB:    beaconDB,
+			}
+			s := &Service{
+				// The peers switch to the forked chain while the first batch is processed.
+				chain: &chainSwitchingChainService{
+					reorgChainService: &reorgChainService{ChainService: mc},
+					p:                 p,
+					changeSlot:        10,
+					oldPeers:          oldPeers,
+					newPeers:          []peer.ID{newPeer},
+				},
+				p2p:          p,
+				db:           beaconDB,
+				chainStarted: true,
+			}
+			if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+				t.Fatal(err)
+			}
+
+			var restarts int
+			for _, entry := range hook.AllEntries() {
+				if entry.Message == "Best finalized checkpoint is not on the synced chain, restarting sync to the new finalized checkpoint" {
+					restarts++
+				}
+			}
+			if restarts != 1 {
+				t.Errorf("Wanted sync to restart once, restarted %d times", restarts)
+			}
+
+			// Sync restarts from genesis, where the chains branch off, so the forked chain is
+			// requested from its first block.
+			forkedReqsLock.Lock()
+			var restartedFromFork bool
+			for _, req := range forkedReqs {
+				if req.StartSlot == 1 {
+					restartedFromFork = true
+				}
+			}
+			forkedReqsLock.Unlock()
+			if !restartedFromFork {
+				t.Error("Expected the forked chain to be requested from the slot after the common ancestor")
+			}
+
+			// Every block received after switching to the forked chain is a block of the forked chain.
+			var switched bool
+			for _, blk := range mc.BlocksReceived {
+				root, err := ssz.HashTreeRoot(blk.Block)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if forkedRoots[root] {
+					switched = true
+				} else if switched {
+					t.Fatalf("Received block at slot %d of the previous chain after switching to the forked chain", blk.Block.Slot)
+				}
+			}
+			if !bytes.Equal(mc.Root, forkedHeadRoot[:]) {
+				t.Errorf("Wanted head root %#x of the forked chain, got %#x", forkedHeadRoot, mc.Root)
+			}
+			if s.chain.HeadSlot() != currentSlot {
+				t.Errorf("Head slot (%d) is not currentSlot (%d)", s.chain.HeadSlot(), currentSlot)
+			}
+		})
+	}
+}
+
+func TestRequestBlocks_OutOfOrderResponse(t *testing.T) {
+	initializeRootCache(makeSequence(1, 128), t)
+	p := p2pt.NewTestP2P(t)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:          makeSequence(1, 128),
+			finalizedEpoch:  3,
+			headSlot:        128,
+			descendingOrder: true,
+		},
+		{
+			blocks:         makeSequence(1, 128),
+			finalizedEpoch: 3,
+			headSlot:       128,
+		},
+	}, p.Peers())
+	s := &Service{
+		chain: &mock.ChainService{State: &p2ppb.BeaconState{}},
+		p2p:   p,
+	}
+
+	req := &p2ppb.BeaconBlocksByRangeRequest{
+		HeadBlockRoot: []byte("head_root"),
+		StartSlot:     1,
+		Count:         32,
+		Step:          1,
+	}
+	var outOfOrderScore, inOrderScore float64
+	for _, pid := range p.Peers().Connected() {
+		resp, err := s.requestBlocks(context.Background(), req, pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uint64(len(resp)) != req.Count {
+			t.Errorf("Wanted %d blocks from peer %s, received %d", req.Count, pid.Pretty(), len(resp))
+		}
+		score := s.PeerScores()[pid]
+		if inAscendingSlotOrder(resp) {
+			if score.OutOfOrderResponses != 0 {
+				t.Errorf("Wanted no out of order responses for peer %s, received %d", pid.Pretty(), score.OutOfOrderResponses)
+			}
+			inOrderScore = score.Score()
+		} else {
+			if score.OutOfOrderResponses != 1 {
+				t.Errorf("Wanted 1 out of order response for peer %s, received %d", pid.Pretty(), score.OutOfOrderResponses)
+			}
+			outOfOrderScore = score.Score()
+		}
+	}
+	if outOfOrderScore == 0 || outOfOrderScore >= inOrderScore {
+		t.Errorf("Wanted peer responding out of order to score lower, received %f and %f", outOfOrderScore, inOrderScore)
+	}
+}
+
+func TestRoundRobinSync_FinalityOnly(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{SyncFinalityOnly: true})
+	defer flags.Init(nil)
+	hook := logTest.NewGlobal()
+
+	currentSlot := uint64(131)
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 1,
+			headSlot:       currentSlot,
+		},
+	}, p.Peers())
+
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  genesisRoot[:],
+		DB:    beaconDB,
+	}
+	s := &Service{
+		chain:        mc,
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if s.chain.HeadSlot() == 0 || s.chain.HeadSlot() > helpers.StartSlot(2) {
+		t.Errorf("Wanted head slot synced to the finalized epoch, received %d", s.chain.HeadSlot())
+	}
+	var finalityOnly bool
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Sending batch block request" {
+			t.Fatal("Expected no blocks to be requested when syncing to head")
+		}
+		if strings.Contains(entry.Message, "finality only mode") {
+			finalityOnly = true
+		}
+	}
+	if !finalityOnly {
+		t.Error("Expected sync to report being synced to the finalized epoch")
+	}
+}
+
+func TestRoundRobinSync_MaxBufferedBlocks(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{SyncMaxBufferedBlocks: 16})
+	defer flags.Init(nil)
+	hook := logTest.NewGlobal()
+
+	currentSlot := uint64(131)
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 1,
+			headSlot:       currentSlot,
+		},
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 1,
+			headSlot:       currentSlot,
+		},
+	}, p.Peers())
+
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  genesisRoot[:],
+		DB:    beaconDB,
+	}
+	s := &Service{
+		chain:        mc,
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if s.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", s.chain.HeadSlot(), currentSlot)
+	}
+
+	var reduced bool
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Reducing batch size to stay within the maximum number of buffered blocks" && entry.Data["batchSize"] == uint64(8) {
+			reduced = true
+		}
+		if entry.Message == "Received blocks" && entry.Data["count"].(int) > 8 {
+			t.Errorf("Received %d blocks from a peer, more than the reduced batch size of 8", entry.Data["count"])
+		}
+	}
+	if !reduced {
+		t.Error("Expected the batch size to be reduced to the maximum number of buffered blocks split across peers")
+	}
+}
+
+func TestBufferedBatchSize(t *testing.T) {
+	tests := []struct {
+		maxBuffered int
+		peers       int
+		want        uint64
+	}{
+		{maxBuffered: 0, peers: 10, want: blockBatchSize},
+		{maxBuffered: 4096, peers: 10, want: blockBatchSize},
+		{maxBuffered: 100, peers: 4, want: 25},
+		{maxBuffered: 3, peers: 4, want: 1},
+	}
+	for _, tt := range tests {
+		flags.Init(&flags.GlobalFlags{SyncMaxBufferedBlocks: tt.maxBuffered})
+		if got := bufferedBatchSize(tt.peers); got != tt.want {
+			t.Errorf("bufferedBatchSize(%d) with maximum %d = %d, wanted %d", tt.peers, tt.maxBuffered, got, tt.want)
+		}
+	}
+	flags.Init(nil)
+}
+
+func TestRoundRobinSync_MaxDistinctPeers(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{SyncMaxDistinctPeers: 2, SyncFinalityOnly: true})
+	defer flags.Init(nil)
+
+	currentSlot := uint64(131)
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	var peers []*peerData
+	for i := 0; i < 4; i++ {
+		peers = append(peers, &peerData{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 3,
+			headSlot:       currentSlot,
+		})
+	}
+	connectPeers(t, p, peers, p.Peers())
+
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  genesisRoot[:],
+		DB:    beaconDB,
+	}
+	s := &Service{
+		chain:        mc,
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if want := helpers.StartSlot(4) - 1; s.chain.HeadSlot() < want {
+		t.Errorf("Head slot (%d) did not reach the end of the finalized epoch (%d)", s.chain.HeadSlot(), want)
+	}
+	if s.DistinctPeersQueried() != 2 {
+		t.Errorf("Wanted 2 distinct peers to be queried, received %d", s.DistinctPeersQueried())
+	}
+}
+
+func TestLogSyncStatus_UpdatesProgress(t *testing.T) {
+	s := &Service{p2p: p2pt.NewTestP2P(t)}
+	if s.Progress() != (SyncProgress{}) {
+		t.Errorf("Expected no progress before processing blocks, received %+v", s.Progress())
+	}
+
+	currentSlot := uint64(100)
+	counter := ratecounter.NewRateCounter(counterSeconds * time.Second)
+	s.logSyncStatus(makeGenesisTime(currentSlot), &eth.BeaconBlock{Slot: 40}, []peer.ID{"a", "b"}, counter)
+
+	progress := s.Progress()
+	if progress.CurrentSlot != 40 {
+		t.Errorf("Wanted current slot 40, received %d", progress.CurrentSlot)
+	}
+	if progress.HighestSlot != currentSlot {
+		t.Errorf("Wanted highest slot %d, received %d", currentSlot, progress.HighestSlot)
+	}
+	if progress.Peers != 2 {
+		t.Errorf("Wanted 2 peers, received %d", progress.Peers)
+	}
+	if progress.EstimatedTimeRemaining != "estimating..." {
+		t.Errorf("Wanted the time remaining to be estimated after a single block, received %s", progress.EstimatedTimeRemaining)
+	}
+}
+
+func TestRoundRobinSync_AdaptiveSpanCrossesEmptyGap(t *testing.T) {
+	currentSlot := uint64(1100)
+	blocks := append(makeSequence(1, 10), makeSequence(1000, currentSlot)...)
+	initializeRootCache(blocks, t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         blocks,
+			finalizedEpoch: 33,
+			headSlot:       currentSlot,
+		},
+	}, p.Peers())
+
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  genesisRoot[:],
+		DB:    beaconDB,
+	}
+	s := &Service{
+		chain:        mc,
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if s.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", s.chain.HeadSlot(), currentSlot)
+	}
+
+	var requests uint64
+	for _, score := range s.PeerScores() {
+		requests += score.Requests
+	}
+	// Skipping whole batches takes a request for every batch of the gap between slots 10 and 1000
+	// alone.
+	skippingRequests := uint64((1000 - 10) / blockBatchSize)
+	if requests >= skippingRequests {
+		t.Errorf("Wanted fewer than %d requests to cross the gap, received %d", skippingRequests, requests)
+	}
+}
+
+func TestRequestSpan_Observe(t *testing.T) {
+	span := &requestSpan{}
+	if got := span.count(blockBatchSize, 1); got != blockBatchSize {
+		t.Errorf("Wanted an initial count of %d, received %d", blockBatchSize, got)
+	}
+
+	// Sparse batches widen the span up to the maximum request size.
+	for want := uint64(2 * blockBatchSize); want <= params.BeaconConfig().MaxRequestBlocks; want *= 2 {
+		if !span.observe(span.count(blockBatchSize, 1), 0 /* received */, blockBatchSize, 1) {
+			t.Fatalf("Expected the span to be widened to %d", want)
+		}
+		if got := span.count(blockBatchSize, 1); got != want {
+			t.Errorf("Wanted count %d after a sparse batch, received %d", want, got)
+		}
+	}
+	if span.observe(params.BeaconConfig().MaxRequestBlocks, 0 /* received */, blockBatchSize, 1) {
+		t.Error("Expected the span not to be widened past the maximum request size")
+	}
+
+	// Dense batches narrow it back down to the batch size.
+	for span.count(blockBatchSize, 1) > blockBatchSize {
+		count := span.count(blockBatchSize, 1)
+		span.observe(count, count, blockBatchSize, 1)
+		if span.count(blockBatchSize, 1) != count/2 {
+			t.Fatalf("Wanted count %d after a dense batch, received %d", count/2, span.count(blockBatchSize, 1))
+		}
+	}
+	span.observe(blockBatchSize, blockBatchSize, blockBatchSize, 1)
+	if got := span.count(blockBatchSize, 1); got != blockBatchSize {
+		t.Errorf("Wanted the count not to be narrowed below the batch size, received %d", got)
+	}
+}
+
+func TestLogSyncStatus_Structured(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{SyncLogStructured: true})
+	defer flags.Init(nil)
+	hook := logTest.NewGlobal()
+
+	s := &Service{p2p: p2pt.NewTestP2P(t)}
+	counter := ratecounter.NewRateCounter(counterSeconds * time.Second)
+	s.logSyncStatus(makeGenesisTime(100), &eth.BeaconBlock{Slot: 40}, []peer.ID{"a", "b"}, counter)
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("Expected sync status to be logged")
+	}
+	if entry.Message != "Processing block" {
+		t.Errorf("Wanted a static log message, received %q", entry.Message)
+	}
+	wanted := logrus.Fields{
+		"currentSlot":    uint64(40),
+		"highestSlot":    uint64(100),
+		"peers":          2,
+		"connectedPeers": 0,
+		"etaSeconds":     -1.0,
+	}
+	for field, value := range wanted {
+		if entry.Data[field] != value {
+			t.Errorf("Wanted field %s to be %v, received %v", field, value, entry.Data[field])
+		}
+	}
+	if _, ok := entry.Data["blocksPerSecond"].(float64); !ok {
+		t.Errorf("Wanted a numeric blocksPerSecond field, received %v", entry.Data["blocksPerSecond"])
+	}
+}
+
+func TestRoundRobinSync_ConcurrentFailoverRequestsEverySlotOnce(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{SyncFinalityOnly: true})
+	defer flags.Init(nil)
+
+	currentSlot := uint64(200)
+	finalizedEpoch := uint64(5)
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	var lock gosync.Mutex
+	requested := make(map[uint64]int)
+	recordRequest := func(req *p2ppb.BeaconBlocksByRangeRequest) {
+		lock.Lock()
+		defer lock.Unlock()
+		for i := uint64(0); i < req.Count; i++ {
+			requested[req.StartSlot+i*req.Step]++
+		}
+	}
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	var data []*peerData
+	for i := 0; i < 2; i++ {
+		// Healthy peers respond after the failing peers have failed over to them.
+		data = append(data, &peerData{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: finalizedEpoch,
+			headSlot:       currentSlot,
+			responseDelay:  50 * time.Millisecond,
+			onRequest:      recordRequest,
+		})
+	}
+	for i := 0; i < 3; i++ {
+		data = append(data, &peerData{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: finalizedEpoch,
+			headSlot:       currentSlot,
+			failureSlots:   makeSequence(1, currentSlot),
+		})
+	}
+	connectPeers(t, p, data, p.Peers())
+
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  genesisRoot[:],
+		DB:    beaconDB,
+	}
+	s := &Service{
+		chain:        mc,
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+
+	lastSlot := helpers.StartSlot(finalizedEpoch+1) - 1
+	if s.chain.HeadSlot() != lastSlot {
+		t.Errorf("Head slot (%d) is not the last slot of the finalized epoch (%d)", s.chain.HeadSlot(), lastSlot)
+	}
+	for slot := uint64(1); slot <= lastSlot; slot++ {
+		if requested[slot] != 1 {
+			t.Errorf("Wanted slot %d to be requested once from the healthy peers, requested %d times", slot, requested[slot])
+		}
+	}
+	if len(mc.BlocksReceived) != int(lastSlot) {
+		t.Errorf("Processed wrong number of blocks. Wanted %d got %d", lastSlot, len(mc.BlocksReceived))
+	}
+}
+
+func TestRoundRobinSync_FailoverAfterEmptyRequestsRequestsFailedRange(t *testing.T) {
+	// Buffering a single batch per peer keeps the span from widening over the skipped slots, so
+	// the empty batch is skipped by the following requests instead.
+	flags.Init(&flags.GlobalFlags{SyncFinalityOnly: true, SyncMaxBufferedBlocks: 2 * blockBatchSize})
+	defer flags.Init(nil)
+
+	currentSlot := uint64(400)
+	finalizedEpoch := uint64(10)
+	// The second batch, slots 65 to 192, only has skipped slots.
+	slots := append(makeSequence(1, 64), makeSequence(300, currentSlot)...)
+	initializeRootCache(slots, t)
+
+	var lock gosync.Mutex
+	var healthyReqs, failingReqs []*p2ppb.BeaconBlocksByRangeRequest
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         slots,
+			finalizedEpoch: finalizedEpoch,
+			headSlot:       currentSlot,
+			onRequest: func(req *p2ppb.BeaconBlocksByRangeRequest) {
+				lock.Lock()
+				defer lock.Unlock()
+				healthyReqs = append(healthyReqs, req)
+			},
+		},
+		{
+			// The peer first fails in the batch following the empty batch.
+			blocks:         slots,
+			finalizedEpoch: finalizedEpoch,
+			headSlot:       currentSlot,
+			failureSlots:   makeSequence(257, currentSlot),
+			onRequest: func(req *p2ppb.BeaconBlocksByRangeRequest) {
+				lock.Lock()
+				defer lock.Unlock()
+				failingReqs = append(failingReqs, req)
+			},
+		},
+	}, p.Peers())
+
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  genesisRoot[:],
+		DB:    beaconDB,
+	}
+	s := &Service{
+		chain:        mc,
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	var failed *p2ppb.BeaconBlocksByRangeRequest
+	for _, req := range failingReqs {
+		if req.StartSlot+req.Count*req.Step >= 257 {
+			failed = req
+			break
+		}
+	}
+	if failed == nil {
+		t.Fatal("Expected the failing peer to receive a request past the empty batch")
+	}
+	if failed.StartSlot < 2*blockBatchSize+1 {
+		t.Errorf("Wanted the failed request to skip the empty batch, started at slot %d", failed.StartSlot)
+	}
+	// The failed over request covers the failed range, as its start slot already skipped the
+	// empty batch.
+	var failedOver bool
+	for _, req := range healthyReqs {
+		if req.StartSlot == failed.StartSlot && req.Step == failed.Step && req.Count == failed.Count {
+			failedOver = true
+		}
+	}
+	if !failedOver {
+		t.Errorf("Expected the healthy peer to be requested the failed range starting at slot %d, requests: %v", failed.StartSlot, healthyReqs)
+	}
+
+	lastSlot := helpers.StartSlot(finalizedEpoch+1) - 1
+	if s.chain.HeadSlot() != lastSlot {
+		t.Errorf("Head slot (%d) is not the last slot of the finalized epoch (%d)", s.chain.HeadSlot(), lastSlot)
+	}
+}
+
+// reorgChainService is a mock chain service which, like fork choice, switches its head to the
+// parent of a received block if the parent is in the db but not the head.
+type reorgChainService struct {
+	*mock.ChainService
+}
+
+func (c *reorgChainService) ReceiveBlockNoPubsubForkchoice(ctx context.Context, blk *eth.SignedBeaconBlock) error {
+	if c.DB.HasBlock(ctx, bytesutil.ToBytes32(blk.Block.ParentRoot)) {
+		c.Root = blk.Block.ParentRoot
+	}
+	return c.ChainService.ReceiveBlockNoPubsubForkchoice(ctx, blk)
+}
+
+func TestRoundRobinSync_HeadSyncOverlapReconcilesReorg(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{HeadSyncOverlap: 1})
+	defer flags.Init(nil)
+
+	currentSlot := uint64(160)
+	reorgSlot := uint64(120)
+	peers := []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+		},
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+	ctx := context.Background()
+
+	// The node synced the chain up to the slot before the reorg, but its head is a block at the
+	// reorg slot which the peers reorged out.
+	for slot := uint64(1); slot < reorgSlot; slot++ {
+		parentRoot := rootCache[parentSlotCache[slot]]
+		blk := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: slot, ParentRoot: parentRoot[:]}}
+		if err := h.chain.ReceiveBlockNoPubsubForkchoice(ctx, blk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	parentRoot := rootCache[reorgSlot-1]
+	stateRoot := bytesutil.ToBytes32([]byte("reorged"))
+	reorged := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{
+		Slot:       reorgSlot,
+		ParentRoot: parentRoot[:],
+		StateRoot:  stateRoot[:],
+	}}
+	if err := h.chain.ReceiveBlockNoPubsubForkchoice(ctx, reorged); err != nil {
+		t.Fatal(err)
+	}
+	h.chain.BlocksReceived = nil
+	h.service.chain = &reorgChainService{ChainService: h.chain}
+
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if h.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", h.chain.HeadSlot(), currentSlot)
+	}
+	if headRoot := rootCache[currentSlot]; !bytes.Equal(h.chain.Root, headRoot[:]) {
+		t.Errorf("Wanted head root %#x of the canonical chain, received %#x", headRoot, h.chain.Root)
+	}
+	if len(h.chain.BlocksReceived) == 0 || h.chain.BlocksReceived[0].Block.Slot != reorgSlot {
+		t.Fatal("Wanted the canonical block at the reorg slot to be received first")
+	}
+	if len(h.chain.BlocksReceived) != int(currentSlot-reorgSlot+1) {
+		t.Errorf("Wanted %d blocks to be received, received %d", currentSlot-reorgSlot+1, len(h.chain.BlocksReceived))
+	}
+}
+
+func TestRoundRobinSync_LatestHeadRoot(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{SyncLatestHeadRoot: true, SyncFinalityOnly: true})
+	defer flags.Init(nil)
+
+	currentSlot := uint64(160)
+	var lock gosync.Mutex
+	var requests []*p2ppb.BeaconBlocksByRangeRequest
+	peers := []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 4,
+			headSlot:       currentSlot,
+			onRequest: func(req *p2ppb.BeaconBlocksByRangeRequest) {
+				lock.Lock()
+				defer lock.Unlock()
+				requests = append(requests, req)
+			},
+		},
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) < 2 {
+		t.Fatalf("Wanted several requests, received %d", len(requests))
+	}
+	// Each request starts after the latest processed block, whose root is the head block root.
+	for _, req := range requests {
+		want := rootCache[req.StartSlot-1]
+		if !bytes.Equal(req.HeadBlockRoot, want[:]) {
+			t.Errorf("Request from slot %d: wanted head block root %#x, received %#x", req.StartSlot, want, req.HeadBlockRoot)
+		}
+	}
+}
+
+func TestRoundRobinSync_HeadSyncMaxRequests(t *testing.T) {
+	hook := logTest.NewGlobal()
+	maxRequests := 2
+	flags.Init(&flags.GlobalFlags{HeadSyncMaxRequests: maxRequests})
+	defer flags.Init(nil)
+
+	// Each Step 2 request serves at most 256 blocks, so the head falls further behind the current
+	// slot than the capped number of requests can catch up with.
+	currentSlot := uint64(800)
+	peers := []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+		},
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	if h.chain.HeadSlot() >= currentSlot {
+		t.Fatalf("Wanted sync to end before the head (%d) reached the current slot (%d)", h.chain.HeadSlot(), currentSlot)
+	}
+	var headSyncRequests int
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Sending batch block request" {
+			headSyncRequests++
+		}
+	}
+	if headSyncRequests != maxRequests {
+		t.Errorf("Wanted %d requests syncing to the chain head, received %d", maxRequests, headSyncRequests)
+	}
+}
+
+func TestRoundRobinSync_LogsBatchRequestPlan(t *testing.T) {
+	hook := logTest.NewGlobal()
+	currentSlot := uint64(160)
+	var lock gosync.Mutex
+	var requests []*p2ppb.BeaconBlocksByRangeRequest
+	var peers []*peerData
+	for i := 0; i < 3; i++ {
+		peers = append(peers, &peerData{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 2,
+			headSlot:       currentSlot,
+			onRequest: func(req *p2ppb.BeaconBlocksByRangeRequest) {
+				lock.Lock()
+				defer lock.Unlock()
+				requests = append(requests, req)
+			},
+		})
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+
+	var plan []plannedRequest
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Planned batch block requests" {
+			plan = entry.Data["plan"].([]plannedRequest)
+			break
+		}
+	}
+	if len(plan) != len(peers) {
+		t.Fatalf("Wanted the first batch plan to cover %d peers, received %v", len(peers), plan)
+	}
+	// The first batch of 64 slots per peer from slot 1 is interleaved across the 3 peers and
+	// cut off at the finalized boundary of slot 96.
+	wantCounts := []uint64{32, 32, 31}
+	for i, p := range plan {
+		if p.StartSlot != uint64(i+1) || p.Step != 3 || p.Count != wantCounts[i] {
+			t.Errorf("Wanted planned request %d to start at slot %d with step 3 and count %d, received %v", i, i+1, wantCounts[i], p)
+		}
+		// The plan describes the requests actually sent to the peers.
+		var sent bool
+		for _, req := range requests {
+			if req.StartSlot == p.StartSlot && req.Step == p.Step && req.Count == p.Count {
+				sent = true
+			}
+		}
+		if !sent {
+			t.Errorf("Planned request %v was not sent to a peer", p)
+		}
+	}
+}
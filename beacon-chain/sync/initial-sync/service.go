@@ -2,8 +2,12 @@ package initialsync
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/pkg/errors"
 	"github.com/prysmaticlabs/prysm/beacon-chain/blockchain"
 	"github.com/prysmaticlabs/prysm/beacon-chain/core/feed"
@@ -13,8 +17,10 @@ import (
 	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
 	"github.com/prysmaticlabs/prysm/beacon-chain/p2p"
 	"github.com/prysmaticlabs/prysm/shared"
+	"github.com/prysmaticlabs/prysm/shared/bytesutil"
 	"github.com/prysmaticlabs/prysm/shared/params"
 	"github.com/prysmaticlabs/prysm/shared/roughtime"
+	"github.com/prysmaticlabs/prysm/shared/slotutil"
 	"github.com/sirupsen/logrus"
 )
 
@@ -27,6 +33,7 @@ type blockchainService interface {
 
 const (
 	handshakePollingInterval = 5 * time.Second // Polling interval for checking the number of received handshakes.
+	genesisPollingInterval   = time.Second     // Polling interval for logging the countdown to genesis.
 )
 
 // Config to set up the initial sync service.
@@ -39,13 +46,41 @@ type Config struct {
 
 // Service service.
 type Service struct {
-	ctx           context.Context
-	chain         blockchainService
-	p2p           p2p.P2P
-	db            db.Database
-	synced        bool
-	chainStarted  bool
-	stateNotifier statefeed.Notifier
+	ctx              context.Context
+	chain            blockchainService
+	p2p              p2p.P2P
+	db               db.Database
+	synced           bool
+	chainStarted     bool
+	stateNotifier    statefeed.Notifier
+	syncComplete     chan struct{}
+	syncCompleteOnce sync.Once
+	peerScores       peerScorer
+	distinctPeers    distinctPeers
+	peerRotation     uint64
+	peerCooldowns    peerCooldowns
+	progressLock     sync.RWMutex
+	progress         SyncProgress
+	stats            syncStats
+	misbehavior      misbehaviorBreaker
+	served           servedTracker
+	throttle         processThrottle
+	capture          batchCapture
+	clockSkewLock    sync.RWMutex
+	clockSkew        ClockSkew
+}
+
+// SyncProgress describes how far initial sync has progressed, as of the last processed block.
+type SyncProgress struct {
+	// CurrentSlot is the slot of the last processed block.
+	CurrentSlot uint64
+	// HighestSlot is the current slot of the chain, which sync is progressing towards.
+	HighestSlot uint64
+	// Peers is the number of peers blocks were requested from for the last processed block.
+	Peers int
+	// EstimatedTimeRemaining until sync reaches the highest slot, based on the recent rate of
+	// block processing.
+	EstimatedTimeRemaining string
 }
 
 // NewInitialSync configures the initial sync service responsible for bringing the node up to the
@@ -57,6 +92,7 @@ func NewInitialSync(cfg *Config) *Service {
 		p2p:           cfg.P2P,
 		db:            cfg.DB,
 		stateNotifier: cfg.StateNotifier,
+		syncComplete:  make(chan struct{}),
 	}
 }
 
@@ -93,31 +129,28 @@ func (s *Service) Start() {
 		genesis = time.Unix(int64(headState.GenesisTime), 0)
 	}
 
-	if genesis.After(roughtime.Now()) {
-		log.WithField(
-			"genesis time",
-			genesis,
-		).Warn("Genesis time is in the future - waiting to start sync...")
-		time.Sleep(roughtime.Until(genesis))
+	if err := s.waitForGenesis(s.ctx, genesis); err != nil {
+		log.WithError(err).Debug("Context closed before genesis, exiting goroutine")
+		return
 	}
 	s.chainStarted = true
 	currentSlot := helpers.SlotsSince(genesis)
 	if helpers.SlotToEpoch(currentSlot) == 0 {
 		log.Info("Chain started within the last epoch - not syncing")
-		s.synced = true
+		s.markSynced()
 		return
 	}
 	log.Info("Starting initial chain sync...")
 	// Are we already in sync, or close to it?
 	if helpers.SlotToEpoch(s.chain.HeadSlot()) == helpers.SlotToEpoch(currentSlot) {
 		log.Info("Already synced to the current chain head")
-		s.synced = true
+		s.markSynced()
 		return
 	}
 	s.waitForMinimumPeers()
-	if err := s.roundRobinSync(genesis); err == nil {
+	if err := s.roundRobinSyncFrom(genesis, s.resumeAnchor(s.ctx)); err == nil {
 		log.Infof("Synced up to slot %d", s.chain.HeadSlot())
-		s.synced = true
+		s.markSynced()
 	}
 }
 
@@ -134,6 +167,13 @@ func (s *Service) Status() error {
 	return nil
 }
 
+// Progress returns the progress of initial sync as of the last processed block.
+func (s *Service) Progress() SyncProgress {
+	s.progressLock.RLock()
+	defer s.progressLock.RUnlock()
+	return s.progress
+}
+
 // Syncing returns true if initial sync is still running.
 func (s *Service) Syncing() bool {
 	return !s.synced
@@ -151,9 +191,9 @@ func (s *Service) Resync() error {
 	genesis := time.Unix(int64(headState.GenesisTime), 0)
 
 	s.waitForMinimumPeers()
-	err = s.roundRobinSync(genesis)
+	err = s.roundRobinSyncFrom(genesis, s.resumeAnchor(context.Background()))
 	if err == nil {
-		s.synced = true
+		s.markSynced()
 	} else {
 		log = log.WithError(err)
 	}
@@ -162,6 +202,88 @@ func (s *Service) Resync() error {
 	return nil
 }
 
+// SyncFromAnchor syncs the chain forward starting from the anchor block with the given root and
+// slot rather than from the current chain head. The anchor block must be present in the db.
+func (s *Service) SyncFromAnchor(ctx context.Context, anchorRoot []byte, anchorSlot uint64) error {
+	anchorBlock, err := s.db.Block(ctx, bytesutil.ToBytes32(anchorRoot))
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve anchor block")
+	}
+	if anchorBlock == nil || anchorBlock.Block == nil {
+		return fmt.Errorf("anchor block %#x is not in the db", anchorRoot)
+	}
+	if anchorBlock.Block.Slot != anchorSlot {
+		return fmt.Errorf("anchor block %#x is at slot %d, not slot %d", anchorRoot, anchorBlock.Block.Slot, anchorSlot)
+	}
+	headState, err := s.chain.HeadState(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve head state")
+	}
+	genesis := time.Unix(int64(headState.GenesisTime), 0)
+	if anchorSlot > helpers.SlotsSince(genesis) {
+		return fmt.Errorf("anchor slot %d is ahead of the current slot %d", anchorSlot, helpers.SlotsSince(genesis))
+	}
+
+	s.waitForMinimumPeers()
+	return s.roundRobinSyncFrom(genesis, &syncAnchor{
+		root: anchorRoot,
+		slot: anchorSlot,
+	})
+}
+
+// SyncComplete returns a channel which is closed once initial sync has completed, allowing
+// dependent services to wait for the node to be synced without polling. It is safe to call
+// before sync has started.
+func (s *Service) SyncComplete() <-chan struct{} {
+	return s.syncComplete
+}
+
+// markSynced marks initial sync as done and notifies listeners of SyncComplete. The
+// notification fires only once, even if the node resyncs later on.
+func (s *Service) markSynced() {
+	s.synced = true
+	s.syncCompleteOnce.Do(func() {
+		close(s.syncComplete)
+	})
+}
+
+// PeerSyncState is the state of a connected peer as considered when making sync decisions.
+type PeerSyncState struct {
+	PeerID         peer.ID
+	HeadSlot       uint64
+	FinalizedEpoch uint64
+	FinalizedRoot  []byte
+	Score          PeerScore
+	Served         PeerServed
+}
+
+// SyncPeerStates returns the reported chain state, along with the measured reliability and the
+// blocks served during the sync session, of each connected peer. The chain states are taken from
+// a single consistent snapshot of the peer statuses. Peers are ordered by their ID.
+func (s *Service) SyncPeerStates() []PeerSyncState {
+	chainStates := s.p2p.Peers().ConnectedChainStates()
+	scores := s.peerScores.snapshot()
+	served := s.served.snapshot()
+	states := make([]PeerSyncState, 0, len(chainStates))
+	for pid, chainState := range chainStates {
+		state := PeerSyncState{
+			PeerID: pid,
+			Score:  scores[pid],
+			Served: served[pid],
+		}
+		if chainState != nil {
+			state.HeadSlot = chainState.HeadSlot
+			state.FinalizedEpoch = chainState.FinalizedEpoch
+			state.FinalizedRoot = append([]byte{}, chainState.FinalizedRoot...)
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].PeerID < states[j].PeerID
+	})
+	return states
+}
+
 func (s *Service) waitForMinimumPeers() {
 	required := params.BeaconConfig().MaxPeersToSync
 	if flags.Get().MinimumSyncPeers < required {
@@ -178,3 +300,30 @@ func (s *Service) waitForMinimumPeers() {
 		time.Sleep(handshakePollingInterval)
 	}
 }
+
+// waitForGenesis blocks until the genesis time is reached, logging the time remaining until then.
+func (s *Service) waitForGenesis(ctx context.Context, genesis time.Time) error {
+	if slotutil.GenesisReached(genesis, roughtime.Now()) {
+		return nil
+	}
+	log.WithField(
+		"genesis time",
+		genesis,
+	).Warn("Genesis time is in the future - waiting to start sync...")
+	for {
+		remaining := roughtime.Until(genesis)
+		if remaining <= 0 || slotutil.GenesisReached(genesis, roughtime.Now()) {
+			return nil
+		}
+		log.WithField("timeUntilGenesis", remaining.Round(time.Second)).Info("Waiting for genesis")
+		wait := genesisPollingInterval
+		if remaining < wait {
+			wait = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
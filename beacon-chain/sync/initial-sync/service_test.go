@@ -0,0 +1,235 @@
+package initialsync
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	mock "github.com/prysmaticlabs/prysm/beacon-chain/blockchain/testing"
+	dbtest "github.com/prysmaticlabs/prysm/beacon-chain/db/testing"
+	"github.com/prysmaticlabs/prysm/beacon-chain/p2p/peers"
+	p2pt "github.com/prysmaticlabs/prysm/beacon-chain/p2p/testing"
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/roughtime"
+	logTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestSyncComplete_ClosesOnSuccessfulSync(t *testing.T) {
+	currentSlot := uint64(131)
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 1,
+			headSlot:       currentSlot,
+		},
+	}, p.Peers())
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{
+			GenesisTime: uint64(makeGenesisTime(currentSlot).Unix()),
+		},
+		Root: genesisRoot[:],
+		DB:   beaconDB,
+	}
+	s := NewInitialSync(&Config{
+		Chain: mc,
+		P2P:   p,
+		DB:    beaconDB,
+	})
+
+	// Waiting on the channel before sync starts must not fire.
+	select {
+	case <-s.SyncComplete():
+		t.Fatal("Sync complete channel closed before sync started")
+	default:
+	}
+
+	go s.Start()
+	select {
+	case <-s.SyncComplete():
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for sync to complete")
+	}
+	if s.Syncing() {
+		t.Error("Expected service to no longer be syncing")
+	}
+	if s.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", s.chain.HeadSlot(), currentSlot)
+	}
+
+	// Completing sync again must not close the channel twice.
+	s.markSynced()
+}
+
+func TestSyncFromAnchor_MidChain(t *testing.T) {
+	currentSlot := uint64(160)
+	anchorSlot := uint64(64)
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 4,
+			headSlot:       currentSlot,
+		},
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 4,
+			headSlot:       currentSlot,
+		},
+	}, p.Peers())
+
+	anchorParent := rootCache[parentSlotCache[anchorSlot]]
+	anchorBlock := &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot:       anchorSlot,
+			ParentRoot: anchorParent[:],
+		},
+	}
+	if err := beaconDB.SaveBlock(context.Background(), anchorBlock); err != nil {
+		t.Fatal(err)
+	}
+	anchorRoot := rootCache[anchorSlot]
+
+	// The chain head is at genesis, so syncing from the head would fail to link blocks to the
+	// chain. Only blocks after the anchor build on the mock chain's root.
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{
+			GenesisTime: uint64(makeGenesisTime(currentSlot).Unix()),
+		},
+		Root: anchorRoot[:],
+		DB:   beaconDB,
+	}
+	s := &Service{
+		chain: mc,
+		p2p:   p,
+		db:    beaconDB,
+	}
+	if err := s.SyncFromAnchor(context.Background(), anchorRoot[:], anchorSlot); err != nil {
+		t.Fatal(err)
+	}
+	if s.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", s.chain.HeadSlot(), currentSlot)
+	}
+	if len(mc.BlocksReceived) != int(currentSlot-anchorSlot) {
+		t.Errorf("Processes wrong number of blocks. Wanted %d got %d", currentSlot-anchorSlot, len(mc.BlocksReceived))
+	}
+	for _, blk := range mc.BlocksReceived {
+		if blk.Block.Slot <= anchorSlot {
+			t.Errorf("Received block at slot %d, which is not after the anchor slot %d", blk.Block.Slot, anchorSlot)
+		}
+	}
+}
+
+func TestSyncFromAnchor_MissingAnchorBlock(t *testing.T) {
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	s := &Service{
+		chain: &mock.ChainService{State: &p2ppb.BeaconState{}},
+		db:    beaconDB,
+	}
+	if err := s.SyncFromAnchor(context.Background(), []byte("unknown"), 64); err == nil {
+		t.Error("Expected error when the anchor block is not in the db")
+	}
+}
+
+func TestSyncPeerStates_ReflectsPeerStatus(t *testing.T) {
+	p := p2pt.NewTestP2P(t)
+	chainStates := map[peer.ID]*p2ppb.Status{
+		"a": {HeadSlot: 100, FinalizedEpoch: 2, FinalizedRoot: []byte("root_a")},
+		"b": {HeadSlot: 250, FinalizedEpoch: 6, FinalizedRoot: []byte("root_b")},
+	}
+	for pid, chainState := range chainStates {
+		p.Peers().Add(pid, nil, network.DirOutbound)
+		p.Peers().SetConnectionState(pid, peers.PeerConnected)
+		p.Peers().SetChainState(pid, chainState)
+	}
+	p.Peers().Add("c", nil, network.DirOutbound)
+	p.Peers().SetConnectionState("c", peers.PeerDisconnected)
+	p.Peers().SetChainState("c", &p2ppb.Status{HeadSlot: 1000})
+
+	s := &Service{p2p: p}
+	s.peerScores.record("a", 50*time.Millisecond, false /* failed */)
+
+	states := s.SyncPeerStates()
+	if len(states) != len(chainStates) {
+		t.Fatalf("Wanted %d peer states, received %d", len(chainStates), len(states))
+	}
+	for _, state := range states {
+		want, ok := chainStates[state.PeerID]
+		if !ok {
+			t.Fatalf("Unexpected peer %s in snapshot", state.PeerID)
+		}
+		if state.HeadSlot != want.HeadSlot || state.FinalizedEpoch != want.FinalizedEpoch || !bytes.Equal(state.FinalizedRoot, want.FinalizedRoot) {
+			t.Errorf("Peer %s: wanted %v, received %+v", state.PeerID, want, state)
+		}
+	}
+	if states[0].PeerID != "a" || states[0].Score.Requests != 1 || states[0].Score.AverageLatency != 50*time.Millisecond {
+		t.Errorf("Unexpected score for peer a: %+v", states[0].Score)
+	}
+	if states[1].Score.Requests != 0 {
+		t.Errorf("Expected no score history for peer b, received %+v", states[1].Score)
+	}
+}
+
+func TestWaitForGenesis_WaitsUntilGenesis(t *testing.T) {
+	hook := logTest.NewGlobal()
+	s := &Service{}
+	genesis := roughtime.Now().Add(1500 * time.Millisecond)
+
+	start := time.Now()
+	if err := s.waitForGenesis(context.Background(), genesis); err != nil {
+		t.Fatal(err)
+	}
+	if roughtime.Now().Before(genesis) {
+		t.Errorf("Returned %v before genesis", roughtime.Until(genesis))
+	}
+	// The countdown is logged once per polling interval rather than in a busy loop.
+	var countdowns int
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Waiting for genesis" {
+			countdowns++
+		}
+	}
+	maxCountdowns := int(time.Since(start)/genesisPollingInterval) + 1
+	if countdowns == 0 || countdowns > maxCountdowns {
+		t.Errorf("Wanted between 1 and %d countdown logs, received %d", maxCountdowns, countdowns)
+	}
+}
+
+func TestWaitForGenesis_ContextCancelled(t *testing.T) {
+	s := &Service{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.waitForGenesis(ctx, roughtime.Now().Add(time.Hour)); err != context.Canceled {
+		t.Errorf("Wanted context cancelled error, received %v", err)
+	}
+}
+
+func TestWaitForGenesis_AfterGenesis(t *testing.T) {
+	s := &Service{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.waitForGenesis(ctx, roughtime.Now().Add(-time.Hour)); err != nil {
+		t.Errorf("Expected no wait after genesis, received %v", err)
+	}
+}
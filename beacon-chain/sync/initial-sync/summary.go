@@ -0,0 +1,61 @@
+package initialsync
+
+import (
+	"time"
+
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+	"github.com/sirupsen/logrus"
+)
+
+// syncStats accumulates statistics of a run of initial sync, summarized once sync completes.
+type syncStats struct {
+	start      time.Time
+	phaseStart time.Time
+	blocks     uint64
+	phases     []syncPhase
+}
+
+// syncPhase is the time spent in a phase of initial sync.
+type syncPhase struct {
+	name     string
+	duration time.Duration
+}
+
+// newSyncStats starts collecting statistics of a run of initial sync starting at now.
+func newSyncStats(now time.Time) syncStats {
+	return syncStats{start: now, phaseStart: now}
+}
+
+// endPhase records the time spent in the named phase, which ends at now, and starts the next one.
+func (st *syncStats) endPhase(name string, now time.Time) {
+	st.phases = append(st.phases, syncPhase{name: name, duration: now.Sub(st.phaseStart)})
+	st.phaseStart = now
+}
+
+// completeSync logs the blocks served by each peer, and the summary of the run of initial sync if
+// enabled, once it completes.
+func (s *Service) completeSync() {
+	s.logPeersServed()
+	if flags.Get().SyncLogSummary {
+		s.logSyncSummary(time.Now())
+	}
+}
+
+// logSyncSummary logs a single line summarizing the run of initial sync, which completed at now.
+func (s *Service) logSyncSummary(now time.Time) {
+	elapsed := now.Sub(s.stats.start)
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(s.stats.blocks) / elapsed.Seconds()
+	}
+	fields := logrus.Fields{
+		"blocks":          s.stats.blocks,
+		"elapsed":         elapsed.Round(time.Millisecond).String(),
+		"blocksPerSecond": rate,
+		"distinctPeers":   s.DistinctPeersQueried(),
+	}
+	for _, phase := range s.stats.phases {
+		fields[phase.name+"PhaseTime"] = phase.duration.Round(time.Millisecond).String()
+	}
+	log.WithFields(fields).Info("Initial sync completed")
+}
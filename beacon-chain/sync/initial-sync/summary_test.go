@@ -0,0 +1,81 @@
+package initialsync
+
+import (
+	"context"
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	mock "github.com/prysmaticlabs/prysm/beacon-chain/blockchain/testing"
+	dbtest "github.com/prysmaticlabs/prysm/beacon-chain/db/testing"
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+	p2pt "github.com/prysmaticlabs/prysm/beacon-chain/p2p/testing"
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	logTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestRoundRobinSync_LogsSummary(t *testing.T) {
+	flags.Init(&flags.GlobalFlags{SyncLogSummary: true})
+	defer flags.Init(nil)
+	hook := logTest.NewGlobal()
+
+	currentSlot := uint64(131)
+	initializeRootCache(makeSequence(1, currentSlot), t)
+
+	p := p2pt.NewTestP2P(t)
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	connectPeers(t, p, []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 1,
+			headSlot:       currentSlot,
+		},
+	}, p.Peers())
+
+	genesisRoot := rootCache[0]
+	if err := beaconDB.SaveBlock(context.Background(), &eth.SignedBeaconBlock{
+		Block: &eth.BeaconBlock{
+			Slot: 0,
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	mc := &mock.ChainService{
+		State: &p2ppb.BeaconState{},
+		Root:  genesisRoot[:],
+		DB:    beaconDB,
+	}
+	s := &Service{
+		chain:        mc,
+		p2p:          p,
+		db:           beaconDB,
+		chainStarted: true,
+	}
+	if err := s.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+
+	var summaries int
+	for _, entry := range hook.AllEntries() {
+		if entry.Message != "Initial sync completed" {
+			continue
+		}
+		summaries++
+		if blocks := entry.Data["blocks"]; blocks != uint64(len(mc.BlocksReceived)) {
+			t.Errorf("Wanted %d blocks in the summary, received %v", len(mc.BlocksReceived), blocks)
+		}
+		if peers := entry.Data["distinctPeers"]; peers != 1 {
+			t.Errorf("Wanted 1 distinct peer in the summary, received %v", peers)
+		}
+		if rate, ok := entry.Data["blocksPerSecond"].(float64); !ok || rate <= 0 {
+			t.Errorf("Wanted a positive rate in the summary, received %v", entry.Data["blocksPerSecond"])
+		}
+		for _, field := range []string{"elapsed", "finalizedPhaseTime", "verificationPhaseTime", "headPhaseTime"} {
+			if value, ok := entry.Data[field].(string); !ok || value == "" {
+				t.Errorf("Wanted field %s in the summary, received %v", field, entry.Data[field])
+			}
+		}
+	}
+	if summaries != 1 {
+		t.Errorf("Wanted a single summary to be logged, received %d", summaries)
+	}
+}
@@ -0,0 +1,38 @@
+package initialsync
+
+import (
+	"context"
+	"time"
+)
+
+// processThrottle paces the processing of blocks to a maximum number of blocks per second, so that
+// sync trades speed for a lower CPU footprint. The zero value is ready to use.
+type processThrottle struct {
+	next time.Time
+}
+
+// wait until the next block may be processed at the rate, returning early with the context error
+// if the context is cancelled. Time spent idle, e.g. waiting for blocks to be received, does not
+// accumulate into a burst of blocks processed above the rate. A rate of 0 does not limit processing.
+func (t *processThrottle) wait(ctx context.Context, rate int) error {
+	if rate <= 0 {
+		return nil
+	}
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	delay := t.next.Sub(now)
+	t.next = t.next.Add(time.Second / time.Duration(rate))
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,102 @@
+package initialsync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
+)
+
+func TestProcessThrottle_LimitsRate(t *testing.T) {
+	throttle := &processThrottle{}
+	rate := 200
+	blocks := 21
+	start := time.Now()
+	for i := 0; i < blocks; i++ {
+		if err := throttle.wait(context.Background(), rate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first block is processed right away, each following one after 5ms.
+	elapsed := time.Since(start)
+	if minimum := 100 * time.Millisecond; elapsed < minimum {
+		t.Errorf("Wanted processing %d blocks at %d blocks per second to take at least %v, took %v", blocks, rate, minimum, elapsed)
+	}
+	if maximum := time.Second; elapsed > maximum {
+		t.Errorf("Wanted processing %d blocks at %d blocks per second to take less than %v, took %v", blocks, rate, maximum, elapsed)
+	}
+}
+
+func TestProcessThrottle_Unlimited(t *testing.T) {
+	throttle := &processThrottle{}
+	start := time.Now()
+	for i := 0; i < 10000; i++ {
+		if err := throttle.wait(context.Background(), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Wanted processing not to be throttled without a rate, took %v", elapsed)
+	}
+}
+
+func TestProcessThrottle_IdleTimeDoesNotBurst(t *testing.T) {
+	throttle := &processThrottle{}
+	rate := 100
+	if err := throttle.wait(context.Background(), rate); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := throttle.wait(context.Background(), rate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Wanted blocks after an idle period to be processed at the rate, 3 blocks took %v", elapsed)
+	}
+}
+
+func TestProcessThrottle_ContextCancelled(t *testing.T) {
+	throttle := &processThrottle{}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := throttle.wait(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := throttle.wait(ctx, 1); err != context.Canceled {
+		t.Errorf("Wanted the context error while waiting, received %v", err)
+	}
+}
+
+func TestRoundRobinSync_MaxProcessRate(t *testing.T) {
+	rate := 100
+	flags.Init(&flags.GlobalFlags{SyncMaxProcessRate: rate})
+	defer flags.Init(nil)
+
+	currentSlot := uint64(20)
+	peers := []*peerData{
+		{
+			blocks:         makeSequence(1, currentSlot),
+			finalizedEpoch: 0,
+			headSlot:       currentSlot,
+		},
+	}
+	h, teardown := newSyncTestHarness(t, currentSlot, peers)
+	defer teardown()
+
+	start := time.Now()
+	if err := h.service.roundRobinSync(makeGenesisTime(currentSlot)); err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	if h.chain.HeadSlot() != currentSlot {
+		t.Errorf("Head slot (%d) is not currentSlot (%d)", h.chain.HeadSlot(), currentSlot)
+	}
+	// The 20 blocks are processed at least 10ms apart.
+	if minimum := time.Duration(currentSlot-1) * time.Second / time.Duration(rate); elapsed < minimum {
+		t.Errorf("Wanted syncing %d blocks at %d blocks per second to take at least %v, took %v", currentSlot, rate, minimum, elapsed)
+	}
+}
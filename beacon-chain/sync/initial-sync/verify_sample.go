@@ -0,0 +1,104 @@
+package initialsync
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/beacon-chain/core/state"
+	"github.com/prysmaticlabs/prysm/beacon-chain/db/filters"
+	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/bytesutil"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrSampledBlockInvalid is returned when a block synced without verifying its contents fails
+// full verification after initial sync.
+var ErrSampledBlockInvalid = errors.New("sampled block failed verification")
+
+// maxSampleReplayBlocks is the most blocks replayed onto the closest saved ancestor state to
+// regenerate the pre-state of a sampled block.
+const maxSampleReplayBlocks = 256
+
+// verifySampledBlocks fully verifies a random sample of the blocks in the db from the start slot up
+// to the chain head, including their signatures and post-state roots. Each slot is sampled with the
+// given rate before any block is read, so only the blocks of the sampled slots are loaded. The
+// pre-state of a sampled block is regenerated from the closest ancestor whose state is saved.
+// Sampled blocks without such an ancestor can't be verified and are skipped.
+func (s *Service) verifySampledBlocks(ctx context.Context, randGenerator *rand.Rand, startSlot uint64, rate float64) error {
+	headSlot := s.chain.HeadSlot()
+	if rate <= 0 || startSlot > headSlot {
+		return nil
+	}
+	var sampled []uint64
+	for slot := startSlot; slot <= headSlot; slot++ {
+		if randGenerator.Float64() < rate {
+			sampled = append(sampled, slot)
+		}
+	}
+	var verified, skipped int
+	for _, slot := range sampled {
+		blocks, err := s.db.Blocks(ctx, filters.NewFilter().SetStartSlot(slot).SetEndSlot(slot))
+		if err != nil {
+			return errors.Wrapf(err, "could not retrieve synced blocks at slot %d", slot)
+		}
+		for _, blk := range blocks {
+			preState, err := s.sampledBlockPreState(ctx, blk.Block)
+			if err != nil {
+				return err
+			}
+			if preState == nil {
+				log.WithField("slot", slot).Debug("No saved ancestor state to regenerate the pre-state of the sampled block from, skipping it")
+				skipped++
+				continue
+			}
+			if _, err := state.ExecuteStateTransition(ctx, preState, blk); err != nil {
+				log.WithError(err).WithField("slot", slot).Error("Synced block failed verification")
+				return errors.Wrapf(ErrSampledBlockInvalid, "block at slot %d: %v", slot, err)
+			}
+			verified++
+		}
+	}
+	log.WithFields(logrus.Fields{
+		"sampledSlots": len(sampled),
+		"verified":     verified,
+		"skipped":      skipped,
+	}).Info("Verified sampled synced blocks")
+	return nil
+}
+
+// sampledBlockPreState returns the pre-state of the block, regenerated by replaying the ancestors
+// of the block onto the state of the closest ancestor whose state is saved. The replayed blocks are
+// not verified. It returns nil if there is no such ancestor within maxSampleReplayBlocks blocks.
+func (s *Service) sampledBlockPreState(ctx context.Context, b *eth.BeaconBlock) (*pb.BeaconState, error) {
+	var replay []*eth.SignedBeaconBlock
+	root := bytesutil.ToBytes32(b.ParentRoot)
+	for len(replay) <= maxSampleReplayBlocks {
+		ancestorState, err := s.db.State(ctx, root)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not retrieve state of ancestor %#x of block at slot %d", root, b.Slot)
+		}
+		if ancestorState != nil {
+			preState := proto.Clone(ancestorState).(*pb.BeaconState)
+			for i := len(replay) - 1; i >= 0; i-- {
+				preState, err = state.ExecuteStateTransitionNoVerify(ctx, preState, replay[i])
+				if err != nil {
+					return nil, errors.Wrapf(err, "could not replay ancestor at slot %d of block at slot %d", replay[i].Block.Slot, b.Slot)
+				}
+			}
+			return preState, nil
+		}
+		ancestor, err := s.db.Block(ctx, root)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not retrieve ancestor %#x of block at slot %d", root, b.Slot)
+		}
+		if ancestor == nil || ancestor.Block == nil {
+			return nil, nil
+		}
+		replay = append(replay, ancestor)
+		root = bytesutil.ToBytes32(ancestor.Block.ParentRoot)
+	}
+	return nil, nil
+}
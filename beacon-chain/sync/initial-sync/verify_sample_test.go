@@ -0,0 +1,136 @@
+package initialsync
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	mock "github.com/prysmaticlabs/prysm/beacon-chain/blockchain/testing"
+	"github.com/prysmaticlabs/prysm/beacon-chain/core/state"
+	"github.com/prysmaticlabs/prysm/beacon-chain/db"
+	dbtest "github.com/prysmaticlabs/prysm/beacon-chain/db/testing"
+	p2ppb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/bytesutil"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+	logTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// saveSampleChain saves a chain of valid blocks from genesis to the db, along with the pre-state of
+// each block, and returns the blocks and the head state.
+func saveSampleChain(t *testing.T, beaconDB db.Database, length uint64) ([]*eth.SignedBeaconBlock, *p2ppb.BeaconState) {
+	ctx := context.Background()
+	beaconState, privKeys := testutil.DeterministicGenesisState(t, 64)
+	blocks := make([]*eth.SignedBeaconBlock, 0, length)
+	for slot := uint64(1); slot <= length; slot++ {
+		blk, err := testutil.GenerateFullBlock(beaconState, privKeys, nil, slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := beaconDB.SaveState(ctx, beaconState, bytesutil.ToBytes32(blk.Block.ParentRoot)); err != nil {
+			t.Fatal(err)
+		}
+		if err := beaconDB.SaveBlock(ctx, blk); err != nil {
+			t.Fatal(err)
+		}
+		beaconState, err = state.ExecuteStateTransition(ctx, proto.Clone(beaconState).(*p2ppb.BeaconState), blk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, blk)
+	}
+	return blocks, beaconState
+}
+
+func TestVerifySampledBlocks_ValidChain(t *testing.T) {
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	_, headState := saveSampleChain(t, beaconDB, 4)
+	s := &Service{chain: &mock.ChainService{State: headState}, db: beaconDB}
+
+	if err := s.verifySampledBlocks(context.Background(), rand.New(rand.NewSource(1)), 1, 1); err != nil {
+		t.Errorf("Wanted valid blocks to verify, received %v", err)
+	}
+}
+
+func TestVerifySampledBlocks_CatchesInvalidBlock(t *testing.T) {
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	ctx := context.Background()
+	blocks, headState := saveSampleChain(t, beaconDB, 4)
+	s := &Service{chain: &mock.ChainService{State: headState}, db: beaconDB}
+
+	// Save a copy of a synced block with the wrong state root, as if a peer had served an invalid
+	// block which was not verified when syncing.
+	bad := proto.Clone(blocks[2]).(*eth.SignedBeaconBlock)
+	bad.Block.StateRoot = bytesutil.Bytes32(1)
+	if err := beaconDB.SaveBlock(ctx, bad); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.verifySampledBlocks(ctx, rand.New(rand.NewSource(1)), 1, 1); errors.Cause(err) != ErrSampledBlockInvalid {
+		t.Errorf("Wanted error %v, received %v", ErrSampledBlockInvalid, err)
+	}
+	// No block is sampled at a rate of 0, so the invalid block goes unnoticed.
+	if err := s.verifySampledBlocks(ctx, rand.New(rand.NewSource(1)), 1, 0); err != nil {
+		t.Errorf("Wanted no blocks to be verified, received %v", err)
+	}
+}
+
+func TestVerifySampledBlocks_RegeneratesPreState(t *testing.T) {
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	ctx := context.Background()
+	blocks, headState := saveSampleChain(t, beaconDB, 4)
+	s := &Service{chain: &mock.ChainService{State: headState}, db: beaconDB}
+
+	// Only the genesis state is kept, so the pre-states of the later blocks are regenerated.
+	for _, blk := range blocks[1:] {
+		if err := beaconDB.DeleteState(ctx, bytesutil.ToBytes32(blk.Block.ParentRoot)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bad := proto.Clone(blocks[3]).(*eth.SignedBeaconBlock)
+	bad.Block.StateRoot = bytesutil.Bytes32(1)
+	if err := beaconDB.SaveBlock(ctx, bad); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.verifySampledBlocks(ctx, rand.New(rand.NewSource(1)), 1, 1); errors.Cause(err) != ErrSampledBlockInvalid {
+		t.Errorf("Wanted error %v, received %v", ErrSampledBlockInvalid, err)
+	}
+	// The blocks before the invalid block verify against their regenerated pre-states.
+	s.chain = &mock.ChainService{State: &p2ppb.BeaconState{Slot: 3}}
+	if err := s.verifySampledBlocks(ctx, rand.New(rand.NewSource(1)), 1, 1); err != nil {
+		t.Errorf("Wanted valid blocks to verify, received %v", err)
+	}
+}
+
+func TestVerifySampledBlocks_CountsSkippedBlocks(t *testing.T) {
+	hook := logTest.NewGlobal()
+	beaconDB := dbtest.SetupDB(t)
+	defer dbtest.TeardownDB(t, beaconDB)
+	ctx := context.Background()
+	blocks, headState := saveSampleChain(t, beaconDB, 4)
+	s := &Service{chain: &mock.ChainService{State: headState}, db: beaconDB}
+
+	// Without any saved state, no pre-state can be regenerated.
+	for _, blk := range blocks {
+		if err := beaconDB.DeleteState(ctx, bytesutil.ToBytes32(blk.Block.ParentRoot)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.verifySampledBlocks(ctx, rand.New(rand.NewSource(1)), 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	entry := hook.LastEntry()
+	if entry == nil || entry.Message != "Verified sampled synced blocks" {
+		t.Fatalf("Wanted the verification summary to be logged, received %v", entry)
+	}
+	if entry.Data["verified"] != 0 || entry.Data["skipped"] != len(blocks) {
+		t.Errorf("Wanted 0 verified and %d skipped blocks, received %v verified and %v skipped", len(blocks), entry.Data["verified"], entry.Data["skipped"])
+	}
+}
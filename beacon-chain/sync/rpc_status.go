@@ -151,7 +151,7 @@ func (r *Service) validateStatusMessage(msg *pb.Status, stream network.Stream) e
 		return errWrongForkVersion
 	}
 	genesis := r.chain.GenesisTime()
-	maxEpoch := slotutil.EpochsSinceGenesis(genesis)
+	maxEpoch := slotutil.EpochsSinceGenesis(genesis, params.BeaconConfig().SecondsPerSlot, roughtime.Since)
 	// It would take a minimum of 2 epochs to finalize a
 	// previous epoch
 	maxFinalizedEpoch := maxEpoch - 2
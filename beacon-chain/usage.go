@@ -99,6 +99,30 @@ var appHelpFlagGroups = []flagGroup{
 			cmd.EnableUPnPFlag,
 			cmd.P2PEncoding,
 			flags.MinSyncPeers,
+			flags.InitSyncBatchSaveBlocks,
+			flags.DisablePeerShuffleFlag,
+			flags.MaxBlocksPerPeerFlag,
+			flags.EnablePreferReliablePeersFlag,
+			flags.EnableBatchPrefetchFlag,
+			flags.VerifyFinalizedRootFlag,
+			flags.MaxFailoverDepthFlag,
+			flags.HeadSyncSlotToleranceFlag,
+			flags.SyncFinalityOnlyFlag,
+			flags.SyncCheckpointIntervalFlag,
+			flags.SyncMaxBufferedBlocksFlag,
+			flags.SyncMaxDistinctPeersFlag,
+			flags.SyncLogStructuredFlag,
+			flags.MinPeerReliabilityFlag,
+			flags.SyncVerifySampleRateFlag,
+			flags.SyncLogSummaryFlag,
+			flags.SyncMaxMisbehaviorRateFlag,
+			flags.SyncVerifyContiguityFlag,
+			flags.SyncHeadForkchoiceFlag,
+			flags.HeadSyncOverlapFlag,
+			flags.SyncLatestHeadRootFlag,
+			flags.HeadSyncMaxRequestsFlag,
+			flags.SyncMaxProcessRateFlag,
+			flags.SyncCaptureDirFlag,
 		},
 	},
 	{
@@ -20,6 +20,7 @@ type BeaconChainConfig struct {
 	TargetCommitteeSize            uint64 `yaml:"TARGET_COMMITTEE_SIZE"`        // TargetCommitteeSize is the number of validators in a committee when the chain is healthy.
 	MaxValidatorsPerCommittee      uint64 `yaml:"MAX_VALIDATORS_PER_COMMITTEE"` // MaxValidatorsPerCommittee defines the upper bound of the size of a committee.
 	MaxCommitteesPerSlot           uint64 // MaxCommitteesPerSlot defines the max amount of committee in a single slot.
+	SyncCommitteeSize              uint64 `yaml:"SYNC_COMMITTEE_SIZE"`                // SyncCommitteeSize is the number of validators in a sync committee.
 	MinPerEpochChurnLimit          uint64 `yaml:"MIN_PER_EPOCH_CHURN_LIMIT"`          // MinPerEpochChurnLimit is the minimum amount of churn allotted for validator rotations.
 	ChurnLimitQuotient             uint64 `yaml:"CHURN_LIMIT_QUOTIENT"`               // ChurnLimitQuotient is used to determine the limit of how many validators can rotate per epoch.
 	ShuffleRoundCount              uint64 `yaml:"SHUFFLE_ROUND_COUNT"`                // ShuffleRoundCount is used for retrieving the permuted index.
@@ -28,14 +29,18 @@ type BeaconChainConfig struct {
 	TargetAggregatorsPerCommittee  uint64 // TargetAggregatorsPerCommittee defines the number of aggregators inside one committee.
 
 	// Gwei value constants.
-	MinDepositAmount          uint64 `yaml:"MIN_DEPOSIT_AMOUNT"`          // MinDepositAmount is the maximal amount of Gwei a validator can send to the deposit contract at once.
-	MaxEffectiveBalance       uint64 `yaml:"MAX_EFFECTIVE_BALANCE"`       // MaxEffectiveBalance is the maximal amount of Gwei that is effective for staking.
-	EjectionBalance           uint64 `yaml:"EJECTION_BALANCE"`            // EjectionBalance is the minimal GWei a validator needs to have before ejected.
-	EffectiveBalanceIncrement uint64 `yaml:"EFFECTIVE_BALANCE_INCREMENT"` // EffectiveBalanceIncrement is used for converting the high balance into the low balance for validators.
+	MinDepositAmount           uint64 `yaml:"MIN_DEPOSIT_AMOUNT"`            // MinDepositAmount is the maximal amount of Gwei a validator can send to the deposit contract at once.
+	MaxEffectiveBalance        uint64 `yaml:"MAX_EFFECTIVE_BALANCE"`         // MaxEffectiveBalance is the maximal amount of Gwei that is effective for staking.
+	MaxEffectiveBalanceElectra uint64 `yaml:"MAX_EFFECTIVE_BALANCE_ELECTRA"` // MaxEffectiveBalanceElectra is the maximal amount of Gwei that is effective for staking for validators with compounding withdrawal credentials.
+	MinActivationBalance       uint64 `yaml:"MIN_ACTIVATION_BALANCE"`        // MinActivationBalance is the minimal effective balance in Gwei for a validator to be placed into the activation queue. MaxEffectiveBalance is used when unset.
+	EjectionBalance            uint64 `yaml:"EJECTION_BALANCE"`              // EjectionBalance is the minimal GWei a validator needs to have before ejected.
+	EffectiveBalanceIncrement  uint64 `yaml:"EFFECTIVE_BALANCE_INCREMENT"`   // EffectiveBalanceIncrement is used for converting the high balance into the low balance for validators.
 
 	// Initial value constants.
-	BLSWithdrawalPrefixByte byte     `yaml:"BLS_WITHDRAWAL_PREFIX_BYTE"` // BLSWithdrawalPrefixByte is used for BLS withdrawal and it's the first byte.
-	ZeroHash                [32]byte // ZeroHash is used to represent a zeroed out 32 byte array.
+	BLSWithdrawalPrefixByte         byte     `yaml:"BLS_WITHDRAWAL_PREFIX_BYTE"`          // BLSWithdrawalPrefixByte is used for BLS withdrawal and it's the first byte.
+	ETH1AddressWithdrawalPrefixByte byte     `yaml:"ETH1_ADDRESS_WITHDRAWAL_PREFIX_BYTE"` // ETH1AddressWithdrawalPrefixByte is the first byte of withdrawal credentials withdrawing to an ETH1 address.
+	CompoundingWithdrawalPrefixByte byte     `yaml:"COMPOUNDING_WITHDRAWAL_PREFIX"`       // CompoundingWithdrawalPrefixByte is the first byte of withdrawal credentials withdrawing to an ETH1 address which compound rewards above the max effective balance.
+	ZeroHash                        [32]byte // ZeroHash is used to represent a zeroed out 32 byte array.
 
 	// Time parameters constants.
 	MinAttestationInclusionDelay     uint64 `yaml:"MIN_ATTESTATION_INCLUSION_DELAY"`     // MinAttestationInclusionDelay defines how many slots validator has to wait to include attestation for beacon block.
@@ -48,8 +53,10 @@ type BeaconChainConfig struct {
 	MinValidatorWithdrawabilityDelay uint64 `yaml:"MIN_VALIDATOR_WITHDRAWABILITY_DELAY"` // MinValidatorWithdrawabilityDelay is the shortest amount of time a validator has to wait to withdraw.
 	PersistentCommitteePeriod        uint64 `yaml:"PERSISTENT_COMMITTEE_PERIOD"`         // PersistentCommitteePeriod is the minimum amount of epochs a validator must participate before exiting.
 	MinEpochsToInactivityPenalty     uint64 `yaml:"MIN_EPOCHS_TO_INACTIVITY_PENALTY"`    // MinEpochsToInactivityPenalty defines the minimum amount of epochs since finality to begin penalizing inactivity.
+	EpochsPerSyncCommitteePeriod     uint64 `yaml:"EPOCHS_PER_SYNC_COMMITTEE_PERIOD"`    // EpochsPerSyncCommitteePeriod is the number of epochs a sync committee serves for.
 	Eth1FollowDistance               uint64 // Eth1FollowDistance is the number of eth1.0 blocks to wait before considering a new deposit for voting. This only applies after the chain as been started.
 	SafeSlotsToUpdateJustified       uint64 // SafeSlotsToUpdateJustified is the minimal slots needed to update justified check point.
+	ProposerScoreBoost               uint64 `yaml:"PROPOSER_SCORE_BOOST"` // ProposerScoreBoost is the percentage of the committee weight added to the fork choice weight of a timely proposed block.
 	AttestationPropagationSlotRange  uint64 // AttestationPropagationSlotRange is the maximum number of slots during which an attestation can be propagated.
 
 	// State list lengths
@@ -64,6 +71,9 @@ type BeaconChainConfig struct {
 	ProposerRewardQuotient      uint64 `yaml:"PROPOSER_REWARD_QUOTIENT"`      // ProposerRewardQuotient is used to calculate the reward for proposers.
 	InactivityPenaltyQuotient   uint64 `yaml:"INACTIVITY_PENALTY_QUOTIENT"`   // InactivityPenaltyQuotient is used to calculate the penalty for a validator that is offline.
 	MinSlashingPenaltyQuotient  uint64 `yaml:"MIN_SLASHING_PENALTY_QUOTIENT"` // MinSlashingPenaltyQuotient is used to calculate the minimum penalty to prevent DoS attacks.
+	SyncRewardWeight            uint64 `yaml:"SYNC_REWARD_WEIGHT"`            // SyncRewardWeight is the weight of the sync committee participation reward in the Altair rewards.
+	ProposerWeight              uint64 `yaml:"PROPOSER_WEIGHT"`               // ProposerWeight is the weight of the proposer reward in the Altair rewards.
+	WeightDenominator           uint64 `yaml:"WEIGHT_DENOMINATOR"`            // WeightDenominator is the sum of the weights of the Altair rewards.
 
 	// Max operations per block constants.
 	MaxProposerSlashings uint64 `yaml:"MAX_PROPOSER_SLASHINGS"` // MaxProposerSlashings defines the maximum number of slashings of proposers possible in a block.
@@ -96,6 +106,7 @@ type BeaconChainConfig struct {
 	DefaultPageSize           int           // DefaultPageSize defines the default page size for RPC server request.
 	MaxPageSize               int           // MaxPageSize defines the max page size for RPC server respond.
 	MaxPeersToSync            int           // MaxPeersToSync describes the limit for number of peers in round robin sync.
+	MaxRequestBlocks          uint64        `yaml:"MAX_REQUEST_BLOCKS"` // MaxRequestBlocks is the maximum number of blocks in a single blocks by range request.
 
 	// Slasher constants.
 	WeakSubjectivityPeriod    uint64 // WeakSubjectivityPeriod defines the time period expressed in number of epochs were proof of stake network should validate block headers and attestations for slashable events.
@@ -113,6 +124,7 @@ var defaultBeaconConfig = &BeaconChainConfig{
 	TargetCommitteeSize:            128,
 	MaxValidatorsPerCommittee:      2048,
 	MaxCommitteesPerSlot:           64,
+	SyncCommitteeSize:              512,
 	MinPerEpochChurnLimit:          4,
 	ChurnLimitQuotient:             1 << 16,
 	ShuffleRoundCount:              90,
@@ -121,14 +133,17 @@ var defaultBeaconConfig = &BeaconChainConfig{
 	TargetAggregatorsPerCommittee:  16,
 
 	// Gwei value constants.
-	MinDepositAmount:          1 * 1e9,
-	MaxEffectiveBalance:       32 * 1e9,
-	EjectionBalance:           16 * 1e9,
-	EffectiveBalanceIncrement: 1 * 1e9,
+	MinDepositAmount:           1 * 1e9,
+	MaxEffectiveBalance:        32 * 1e9,
+	MaxEffectiveBalanceElectra: 2048 * 1e9,
+	EjectionBalance:            16 * 1e9,
+	EffectiveBalanceIncrement:  1 * 1e9,
 
 	// Initial value constants.
-	BLSWithdrawalPrefixByte: byte(0),
-	ZeroHash:                [32]byte{},
+	BLSWithdrawalPrefixByte:         byte(0),
+	ETH1AddressWithdrawalPrefixByte: byte(1),
+	CompoundingWithdrawalPrefixByte: byte(2),
+	ZeroHash:                        [32]byte{},
 
 	// Time parameter constants.
 	MinAttestationInclusionDelay:     1,
@@ -141,8 +156,10 @@ var defaultBeaconConfig = &BeaconChainConfig{
 	MinValidatorWithdrawabilityDelay: 256,
 	PersistentCommitteePeriod:        2048,
 	MinEpochsToInactivityPenalty:     4,
+	EpochsPerSyncCommitteePeriod:     256,
 	Eth1FollowDistance:               1024,
 	SafeSlotsToUpdateJustified:       8,
+	ProposerScoreBoost:               70,
 	AttestationPropagationSlotRange:  32,
 
 	// State list length constants.
@@ -157,6 +174,9 @@ var defaultBeaconConfig = &BeaconChainConfig{
 	ProposerRewardQuotient:      8,
 	InactivityPenaltyQuotient:   1 << 25,
 	MinSlashingPenaltyQuotient:  32,
+	SyncRewardWeight:            2,
+	ProposerWeight:              8,
+	WeightDenominator:           64,
 
 	// Max operations per block constants.
 	MaxProposerSlashings: 16,
@@ -188,6 +208,7 @@ var defaultBeaconConfig = &BeaconChainConfig{
 	DefaultPageSize:           250,
 	MaxPageSize:               500,
 	MaxPeersToSync:            15,
+	MaxRequestBlocks:          1024,
 
 	// Slasher related values.
 	WeakSubjectivityPeriod:    54000,
@@ -233,6 +254,7 @@ func MinimalSpecConfig() *BeaconChainConfig {
 	minimalConfig := *defaultBeaconConfig
 	// Misc
 	minimalConfig.MaxCommitteesPerSlot = 4
+	minimalConfig.SyncCommitteeSize = 32
 	minimalConfig.TargetCommitteeSize = 4
 	minimalConfig.MaxValidatorsPerCommittee = 2048
 	minimalConfig.MinPerEpochChurnLimit = 4
@@ -246,11 +268,14 @@ func MinimalSpecConfig() *BeaconChainConfig {
 	// Gwei values
 	minimalConfig.MinDepositAmount = 1e9
 	minimalConfig.MaxEffectiveBalance = 32e9
+	minimalConfig.MaxEffectiveBalanceElectra = 2048e9
 	minimalConfig.EjectionBalance = 16e9
 	minimalConfig.EffectiveBalanceIncrement = 1e9
 
 	// Initial values
 	minimalConfig.BLSWithdrawalPrefixByte = byte(0)
+	minimalConfig.ETH1AddressWithdrawalPrefixByte = byte(1)
+	minimalConfig.CompoundingWithdrawalPrefixByte = byte(2)
 
 	// Time parameters
 	minimalConfig.SecondsPerSlot = 6
@@ -263,6 +288,7 @@ func MinimalSpecConfig() *BeaconChainConfig {
 	minimalConfig.MinValidatorWithdrawabilityDelay = 256
 	minimalConfig.PersistentCommitteePeriod = 2048
 	minimalConfig.MinEpochsToInactivityPenalty = 4
+	minimalConfig.EpochsPerSyncCommitteePeriod = 8
 	minimalConfig.SafeSlotsToUpdateJustified = 2
 
 	// State vector lengths
@@ -277,6 +303,9 @@ func MinimalSpecConfig() *BeaconChainConfig {
 	minimalConfig.ProposerRewardQuotient = 8
 	minimalConfig.InactivityPenaltyQuotient = 33554432
 	minimalConfig.MinSlashingPenaltyQuotient = 32
+	minimalConfig.SyncRewardWeight = 2
+	minimalConfig.ProposerWeight = 8
+	minimalConfig.WeightDenominator = 64
 
 	// Max operations per block
 	minimalConfig.MaxProposerSlashings = 16
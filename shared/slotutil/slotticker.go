@@ -46,13 +46,29 @@ func GetSlotTicker(genesisTime time.Time, secondsPerSlot uint64) *SlotTicker {
 		c:    make(chan uint64),
 		done: make(chan struct{}),
 	}
-	ticker.start(genesisTime, secondsPerSlot, roughtime.Since, roughtime.Until, time.After)
+	ticker.start(genesisTime, secondsPerSlot, false /* startNow */, roughtime.Since, roughtime.Until, time.After)
+	return ticker
+}
+
+// GetSlotTickerStartingNow is the constructor for a SlotTicker which also emits the current slot
+// as soon as it starts, rather than waiting for the next slot to begin. Before genesis, the first
+// slot is emitted at genesis as usual.
+func GetSlotTickerStartingNow(genesisTime time.Time, secondsPerSlot uint64) *SlotTicker {
+	if genesisTime.Unix() == 0 {
+		panic("zero genesis time")
+	}
+	ticker := &SlotTicker{
+		c:    make(chan uint64),
+		done: make(chan struct{}),
+	}
+	ticker.start(genesisTime, secondsPerSlot, true /* startNow */, roughtime.Since, roughtime.Until, time.After)
 	return ticker
 }
 
 func (s *SlotTicker) start(
 	genesisTime time.Time,
 	secondsPerSlot uint64,
+	startNow bool,
 	since func(time.Time) time.Duration,
 	until func(time.Time) time.Duration,
 	after func(time.Duration) <-chan time.Time) {
@@ -72,6 +88,13 @@ func (s *SlotTicker) start(
 			nextTick := sinceGenesis.Truncate(d) + d
 			nextTickTime = genesisTime.Add(nextTick)
 			slot = uint64(nextTick / d)
+			if startNow {
+				select {
+				case s.c <- slot - 1:
+				case <-s.done:
+					return
+				}
+			}
 		}
 
 		for {
@@ -38,7 +38,7 @@ func TestSlotTicker(t *testing.T) {
 	// Make this a buffered channel to prevent a deadlock since
 	// the other goroutine calls a function in this goroutine.
 	tick = make(chan time.Time, 2)
-	ticker.start(genesisTime, secondsPerSlot, since, until, after)
+	ticker.start(genesisTime, secondsPerSlot, false /* startNow */, since, until, after)
 
 	// Tick once.
 	tick <- time.Now()
@@ -86,7 +86,7 @@ func TestSlotTickerGenesis(t *testing.T) {
 	// Make this a buffered channel to prevent a deadlock since
 	// the other goroutine calls a function in this goroutine.
 	tick = make(chan time.Time, 2)
-	ticker.start(genesisTime, secondsPerSlot, since, until, after)
+	ticker.start(genesisTime, secondsPerSlot, false /* startNow */, since, until, after)
 
 	// Tick once.
 	tick <- time.Now()
@@ -102,3 +102,39 @@ func TestSlotTickerGenesis(t *testing.T) {
 		t.Fatalf("Expected %d, got %d", 1, slot)
 	}
 }
+
+func TestSlotTickerStartingNow(t *testing.T) {
+	ticker := &SlotTicker{
+		c:    make(chan uint64),
+		done: make(chan struct{}),
+	}
+	defer ticker.Done()
+
+	since := func(time.Time) time.Duration {
+		return 20 * time.Second
+	}
+	until := func(time.Time) time.Duration {
+		return 4 * time.Second
+	}
+	tick := make(chan time.Time, 1)
+	after := func(time.Duration) <-chan time.Time {
+		return tick
+	}
+
+	genesisTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	secondsPerSlot := uint64(8)
+
+	// Test the current slot is emitted without waiting for the next slot to begin.
+	ticker.start(genesisTime, secondsPerSlot, true /* startNow */, since, until, after)
+	slot := <-ticker.C()
+	if slot != 2 {
+		t.Fatalf("Expected %d, got %d", 2, slot)
+	}
+
+	// Tick at the start of the next slot.
+	tick <- time.Now()
+	slot = <-ticker.C()
+	if slot != 3 {
+		t.Fatalf("Expected %d, got %d", 3, slot)
+	}
+}
@@ -3,6 +3,7 @@ package slotutil
 import (
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/prysmaticlabs/prysm/shared/params"
 	"github.com/prysmaticlabs/prysm/shared/roughtime"
 )
@@ -21,8 +22,34 @@ func SlotsSinceGenesis(genesis time.Time) uint64 {
 	return uint64(roughtime.Since(genesis).Seconds()) / params.BeaconConfig().SecondsPerSlot
 }
 
-// EpochsSinceGenesis returns the number of slots since
-// the provided genesis time.
-func EpochsSinceGenesis(genesis time.Time) uint64 {
-	return SlotsSinceGenesis(genesis) / params.BeaconConfig().SlotsPerEpoch
+// EpochsSinceGenesis returns the number of epochs since the provided
+// genesis time, measuring the time elapsed with since. Slots are rounded
+// down before being converted to epochs, consistently with SlotToEpoch.
+// It returns 0 before genesis or with zero seconds per slot.
+func EpochsSinceGenesis(genesisTime time.Time, secondsPerSlot uint64, since func(time.Time) time.Duration) uint64 {
+	elapsed := since(genesisTime)
+	if secondsPerSlot == 0 || elapsed < 0 {
+		return 0
+	}
+	slot := uint64(elapsed.Seconds()) / secondsPerSlot
+	return slot / params.BeaconConfig().SlotsPerEpoch
+}
+
+// SlotFromTime returns the slot that the provided time falls in, given the
+// genesis time and the slot duration. It returns an error if the time is
+// before genesis.
+func SlotFromTime(genesisTime time.Time, t time.Time, secondsPerSlot uint64) (uint64, error) {
+	if secondsPerSlot == 0 {
+		return 0, errors.New("seconds per slot cannot be zero")
+	}
+	if t.Before(genesisTime) {
+		return 0, errors.Errorf("time %v is before genesis time %v", t, genesisTime)
+	}
+	return uint64(t.Sub(genesisTime).Seconds()) / secondsPerSlot, nil
+}
+
+// GenesisReached returns true if the provided time is at or after the
+// genesis time.
+func GenesisReached(genesisTime time.Time, now time.Time) bool {
+	return !now.Before(genesisTime)
 }
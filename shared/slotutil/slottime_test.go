@@ -0,0 +1,95 @@
+package slotutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prysmaticlabs/prysm/shared/params"
+)
+
+func TestSlotFromTime(t *testing.T) {
+	genesisTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	secondsPerSlot := uint64(12)
+	tests := []struct {
+		name    string
+		time    time.Time
+		slot    uint64
+		wantErr bool
+	}{
+		{name: "At genesis", time: genesisTime, slot: 0},
+		{name: "Mid slot", time: genesisTime.Add(30 * time.Second), slot: 2},
+		{name: "Slot boundary", time: genesisTime.Add(36 * time.Second), slot: 3},
+		{name: "Before genesis", time: genesisTime.Add(-1 * time.Second), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot, err := SlotFromTime(genesisTime, tt.time, secondsPerSlot)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SlotFromTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if slot != tt.slot {
+				t.Errorf("SlotFromTime() = %d, want %d", slot, tt.slot)
+			}
+		})
+	}
+}
+
+func TestSlotFromTime_ZeroSecondsPerSlot(t *testing.T) {
+	genesisTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := SlotFromTime(genesisTime, genesisTime, 0); err == nil {
+		t.Error("Expected error with zero seconds per slot")
+	}
+}
+
+func TestGenesisReached(t *testing.T) {
+	genesisTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{name: "Before genesis", now: genesisTime.Add(-1 * time.Second), want: false},
+		{name: "At genesis", now: genesisTime, want: true},
+		{name: "After genesis", now: genesisTime.Add(time.Hour), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenesisReached(genesisTime, tt.now); got != tt.want {
+				t.Errorf("GenesisReached() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEpochsSinceGenesis(t *testing.T) {
+	genesisTime := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	secondsPerSlot := uint64(12)
+	epochDuration := time.Duration(secondsPerSlot*params.BeaconConfig().SlotsPerEpoch) * time.Second
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		epoch   uint64
+	}{
+		{name: "At genesis", elapsed: 0, epoch: 0},
+		{name: "Last slot of first epoch", elapsed: epochDuration - time.Duration(secondsPerSlot)*time.Second, epoch: 0},
+		{name: "Just before epoch boundary", elapsed: epochDuration - time.Second, epoch: 0},
+		{name: "Epoch boundary slot", elapsed: epochDuration, epoch: 1},
+		{name: "Mid epoch", elapsed: 5*epochDuration + epochDuration/2, epoch: 5},
+		{name: "Before genesis", elapsed: -time.Hour, epoch: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since := func(time.Time) time.Duration { return tt.elapsed }
+			if got := EpochsSinceGenesis(genesisTime, secondsPerSlot, since); got != tt.epoch {
+				t.Errorf("EpochsSinceGenesis() = %d, want %d", got, tt.epoch)
+			}
+		})
+	}
+}
+
+func TestEpochsSinceGenesis_ZeroSecondsPerSlot(t *testing.T) {
+	since := func(time.Time) time.Duration { return time.Hour }
+	if got := EpochsSinceGenesis(time.Now(), 0, since); got != 0 {
+		t.Errorf("Wanted 0 epochs with zero seconds per slot, received %d", got)
+	}
+}
@@ -253,7 +253,7 @@ func (v *validator) UpdateDuties(ctx context.Context, slot uint64) error {
 
 	v.duties = resp
 	// Only log the full assignments output on epoch start to be less verbose.
-	if slot%params.BeaconConfig().SlotsPerEpoch == 0 {
+	if helpers.IsEpochStart(slot) {
 		v.pubKeyToIDLock.Lock()
 		defer v.pubKeyToIDLock.Unlock()
 
@@ -329,10 +329,7 @@ func (v *validator) RolesAt(ctx context.Context, slot uint64) (map[[48]byte][]pb
 // isAggregator checks if a validator is an aggregator of a given slot, it uses the selection algorithm outlined in:
 // https://github.com/ethereum/eth2.0-specs/blob/v0.9.0/specs/validator/0_beacon-chain-validator.md#aggregation-selection
 func (v *validator) isAggregator(ctx context.Context, committee []uint64, slot uint64, pubKey [48]byte) (bool, error) {
-	modulo := uint64(1)
-	if len(committee)/int(params.BeaconConfig().TargetAggregatorsPerCommittee) > 1 {
-		modulo = uint64(len(committee)) / params.BeaconConfig().TargetAggregatorsPerCommittee
-	}
+	modulo := helpers.AggregatorModulo(uint64(len(committee)))
 
 	slotSig, err := v.signSlot(ctx, pubKey, slot)
 	if err != nil {